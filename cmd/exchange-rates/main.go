@@ -0,0 +1,68 @@
+// Command exchange-rates loads a file of exchange rates into the database,
+// e.g. the daily rates of the European Central Bank:
+//
+//	curl -O https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml
+//	go run ./cmd/exchange-rates -format ecb -file eurofxref-daily.xml
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "path of the rates file, standard input when empty")
+	format := flag.String("format", service.RatesCSV, "format of the rates file, csv or ecb")
+	flag.Parse()
+
+	// The variables can also be set in the environment
+	godotenv.Load()
+
+	dbUrl, exists := os.LookupEnv("DB_URL")
+	if !exists {
+		fmt.Println("Environment variable DB_URL must be set")
+		os.Exit(1)
+	}
+
+	in := os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Println("failed to open file:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, dbUrl)
+	if err != nil {
+		fmt.Println("failed to connect to database:", err)
+		os.Exit(1)
+	}
+	defer conn.Close(ctx)
+
+	rates := service.ExchangeRate{
+		DB:      conn,
+		Queries: repository.New(conn),
+	}
+
+	loaded, err := rates.Load(ctx, in, *format)
+	if err != nil {
+		fmt.Println("failed to load exchange rates:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Loaded", loaded, "exchange rates")
+}
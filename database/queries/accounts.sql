@@ -0,0 +1,80 @@
+-- name: CreateAccount :one
+INSERT INTO accounts (id, created_at, updated_at, name, type, currency, opening_balance, workspace_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetAccountByID :one
+SELECT * FROM accounts WHERE id = $1 AND workspace_id = $2;
+
+-- name: GetUserAccounts :many
+-- Every account with its balance at as_of
+SELECT sqlc.embed(a),
+    CAST(a.opening_balance
+        + COALESCE((SELECT SUM(i.amount) FROM incomes i WHERE i.account_id = a.id AND i.received_at <= sqlc.arg(as_of)::timestamptz), 0)
+        - COALESCE((SELECT SUM(e.amount) FROM expenses e WHERE e.account_id = a.id AND e.spent_at <= sqlc.arg(as_of)::timestamptz), 0)
+        - COALESCE((SELECT SUM(t.amount) FROM transfers t WHERE t.from_account_id = a.id AND t.transferred_at <= sqlc.arg(as_of)::timestamptz), 0)
+        + COALESCE((SELECT SUM(t.to_amount) FROM transfers t WHERE t.to_account_id = a.id AND t.transferred_at <= sqlc.arg(as_of)::timestamptz), 0)
+    AS NUMERIC(14, 2)) AS balance
+FROM accounts a
+WHERE a.workspace_id = sqlc.arg(workspace_id)
+ORDER BY a.name ASC;
+
+-- name: GetAccountBalance :one
+-- The opening balance plus every movement of the account up to as_of
+SELECT CAST(a.opening_balance
+        + COALESCE((SELECT SUM(i.amount) FROM incomes i WHERE i.account_id = a.id AND i.received_at <= sqlc.arg(as_of)::timestamptz), 0)
+        - COALESCE((SELECT SUM(e.amount) FROM expenses e WHERE e.account_id = a.id AND e.spent_at <= sqlc.arg(as_of)::timestamptz), 0)
+        - COALESCE((SELECT SUM(t.amount) FROM transfers t WHERE t.from_account_id = a.id AND t.transferred_at <= sqlc.arg(as_of)::timestamptz), 0)
+        + COALESCE((SELECT SUM(t.to_amount) FROM transfers t WHERE t.to_account_id = a.id AND t.transferred_at <= sqlc.arg(as_of)::timestamptz), 0)
+    AS NUMERIC(14, 2)) AS balance
+FROM accounts a
+WHERE a.id = sqlc.arg(id) AND a.workspace_id = sqlc.arg(workspace_id);
+
+-- name: GetAccountChanges :many
+-- How much the balance changed in each bucket, truncated in the given time
+-- zone like the reports. Buckets without movements are left out
+WITH movements AS (
+    SELECT incomes.received_at AS at, incomes.amount AS change
+    FROM incomes
+    WHERE incomes.account_id = sqlc.arg(account_id)::uuid
+    AND incomes.received_at >= sqlc.arg(start_date)::timestamptz AND incomes.received_at <= sqlc.arg(end_date)::timestamptz
+    UNION ALL
+    SELECT expenses.spent_at, -expenses.amount
+    FROM expenses
+    WHERE expenses.account_id = sqlc.arg(account_id)::uuid
+    AND expenses.spent_at >= sqlc.arg(start_date)::timestamptz AND expenses.spent_at <= sqlc.arg(end_date)::timestamptz
+    UNION ALL
+    SELECT transfers.transferred_at, -transfers.amount
+    FROM transfers
+    WHERE transfers.from_account_id = sqlc.arg(account_id)::uuid
+    AND transfers.transferred_at >= sqlc.arg(start_date)::timestamptz AND transfers.transferred_at <= sqlc.arg(end_date)::timestamptz
+    UNION ALL
+    SELECT transfers.transferred_at, transfers.to_amount
+    FROM transfers
+    WHERE transfers.to_account_id = sqlc.arg(account_id)::uuid
+    AND transfers.transferred_at >= sqlc.arg(start_date)::timestamptz AND transfers.transferred_at <= sqlc.arg(end_date)::timestamptz
+)
+SELECT CAST((date_trunc(sqlc.arg(bucket)::text, (movements.at AT TIME ZONE sqlc.arg(time_zone)::text) + make_interval(days => sqlc.arg(shift_days)::int))
+    - make_interval(days => sqlc.arg(shift_days)::int)) AT TIME ZONE sqlc.arg(time_zone)::text AS TIMESTAMPTZ) AS period,
+    CAST(SUM(movements.change) AS NUMERIC(14, 2)) AS change
+FROM movements
+GROUP BY period
+ORDER BY period ASC;
+
+-- name: UpdateAccount :one
+UPDATE accounts SET name = $1, type = $2, opening_balance = $3, updated_at = $4
+WHERE id = $5 AND workspace_id = $6 RETURNING *;
+
+-- name: DeleteAccount :one
+DELETE FROM accounts WHERE id = $1 AND workspace_id = $2 RETURNING *;
+
+-- name: ClearAccountExpenses :execrows
+-- Marks the expenses of the account up to the statement date as cleared
+UPDATE expenses SET cleared_at = sqlc.arg(cleared_at)::timestamptz
+WHERE account_id = sqlc.arg(account_id)::uuid AND spent_at <= sqlc.arg(statement_date)::timestamptz AND cleared_at IS NULL;
+
+-- name: SetAccountReconciled :one
+-- Keeps the latest statement date when reconciling an older statement
+UPDATE accounts SET reconciled_at = GREATEST(reconciled_at, sqlc.arg(statement_date)::timestamptz),
+    updated_at = sqlc.arg(updated_at)::timestamptz
+WHERE id = sqlc.arg(id) AND workspace_id = sqlc.arg(workspace_id) RETURNING *;
@@ -0,0 +1,21 @@
+-- name: GetUserAPIKeys :many
+SELECT * FROM api_keys WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT * FROM api_keys WHERE prefix = $1;
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, created_at, name, prefix, secret_hash, scopes, expires_at, user_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+-- Keeps when the key was last used, at most once a minute so a busy
+-- integration does not write on every request
+UPDATE api_keys SET last_used_at = sqlc.arg(used_at)
+WHERE id = sqlc.arg(id)
+    AND (last_used_at IS NULL OR last_used_at < sqlc.arg(used_at)::timestamptz - INTERVAL '1 minute');
+
+-- name: DeleteAPIKey :one
+DELETE FROM api_keys WHERE id = $1 AND user_id = $2 RETURNING *;
@@ -0,0 +1,34 @@
+-- name: FireBudgetAlerts :exec
+-- Records the thresholds reached by the budgets updated for an expense, the
+-- ones that already fired are skipped so each one fires once per period
+WITH RECURSIVE ancestors AS (
+    SELECT categories.id, categories.parent_id FROM categories WHERE categories.id = sqlc.arg(category_id)
+    UNION
+    SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+)
+INSERT INTO budget_alerts (id, created_at, threshold, amount, goal, budget_id, workspace_id)
+SELECT gen_random_uuid(), sqlc.arg(created_at)::timestamptz, t.threshold, b.amount, b.effective_goal, b.id, b.workspace_id
+FROM budgets b, unnest(b.thresholds) AS t(threshold)
+WHERE b.workspace_id = sqlc.arg(workspace_id) AND b.closed_at IS NULL
+AND b.start_date <= sqlc.arg(spent_at) AND b.end_date >= sqlc.arg(spent_at)
+AND (
+    NOT EXISTS (SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id)
+    OR EXISTS (
+        SELECT 1 FROM budget_categories bc
+        WHERE bc.budget_id = b.id AND bc.category_id IN (SELECT ancestors.id FROM ancestors)
+    )
+)
+AND b.effective_goal > 0 AND b.amount * 100 >= b.effective_goal * t.threshold
+ON CONFLICT (budget_id, threshold) DO NOTHING;
+
+-- name: GetBudgetAlerts :many
+SELECT * FROM budget_alerts WHERE budget_id = $1 AND workspace_id = $2
+ORDER BY threshold ASC;
+
+-- name: GetPendingBudgetAlerts :many
+-- The alerts fired but not sent yet, the oldest first
+SELECT * FROM budget_alerts WHERE notified_at IS NULL
+ORDER BY created_at ASC, id ASC;
+
+-- name: MarkBudgetAlertNotified :exec
+UPDATE budget_alerts SET notified_at = $2 WHERE id = $1;
@@ -1,27 +1,115 @@
 -- name: CreateBudget :one
-INSERT INTO budgets (id, created_at, updated_at, amount, goal, start_date, end_date, user_id, category_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+INSERT INTO budgets (
+    id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency,
+    period, series_id, rollover, rollover_cap, carried, thresholds, time_zone
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 RETURNING *;
 
 -- name: DeleteBudget :one
-DELETE FROM budgets WHERE id = $1 AND user_id = $2 RETURNING *;
+DELETE FROM budgets WHERE id = $1 AND workspace_id = $2 RETURNING *;
 
 -- name: GetUserBudgetsPaged :many
-SELECT * FROM budgets WHERE user_id = $1
+SELECT * FROM budgets WHERE workspace_id = $1
 AND created_at >= $2 AND id < $3
 ORDER BY created_at ASC, id DESC
 LIMIT $4;
 
 -- name: GetUserBudgets :many
-SELECT * FROM budgets WHERE user_id = $1
+SELECT * FROM budgets WHERE workspace_id = $1
 ORDER BY created_at ASC, id DESC
 LIMIT $2;
 
 -- name: GetBudgetByID :one
-SELECT * FROM budgets WHERE id = $1 AND user_id = $2;
+SELECT * FROM budgets WHERE id = $1 AND workspace_id = $2;
+
+-- name: UpdateBudget :one
+-- Closed periods are read-only
+UPDATE budgets SET goal = $1, start_date = $2, end_date = $3, amount = $4,
+    period = $5, series_id = $6, rollover = $7, rollover_cap = $8, thresholds = $9, updated_at = $10
+WHERE id = $11 AND workspace_id = $12 AND closed_at IS NULL RETURNING *;
+
+-- name: AddBudgetCategories :exec
+INSERT INTO budget_categories (budget_id, category_id)
+SELECT sqlc.arg(budget_id)::uuid, unnest(sqlc.arg(category_ids)::uuid[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBudgetCategories :exec
+DELETE FROM budget_categories WHERE budget_id = $1;
+
+-- name: GetBudgetsCategories :many
+-- Categories of each budget, for the budgets in a page
+SELECT budget_categories.budget_id, categories.id, categories.name FROM budget_categories
+JOIN categories ON categories.id = budget_categories.category_id
+WHERE budget_categories.budget_id = ANY(sqlc.arg(budget_ids)::uuid[])
+ORDER BY categories.name ASC;
+
+-- name: DeleteCategoryOnlyBudgets :exec
+-- Budgets of a category being deleted that have no other category, they
+-- would become account-wide otherwise
+DELETE FROM budgets b WHERE b.workspace_id = sqlc.arg(workspace_id)
+AND EXISTS (
+    SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id AND bc.category_id = sqlc.arg(category_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id AND bc.category_id <> sqlc.arg(category_id)
+);
+
+-- name: GetBudgetPeriods :many
+-- Every period of a recurring budget, the latest first
+SELECT * FROM budgets WHERE series_id = $1 AND workspace_id = $2
+ORDER BY start_date DESC;
+
+-- name: GetDueBudgetPeriods :many
+-- Used by the scheduler, so it is not scoped to a workspace
+SELECT * FROM budgets
+WHERE period IS NOT NULL AND closed_at IS NULL AND end_date < sqlc.arg(due_at)
+ORDER BY end_date ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: CloseBudgetPeriod :exec
+UPDATE budgets SET closed_at = $1, updated_at = $1 WHERE id = $2;
 
 -- name: UpdateBudgetAmount :exec
 -- Since UpdateBudgetAmount is only called by the API, there is no need to
--- check if the user is the owner of the budget since the API already does that
-UPDATE budgets SET amount = amount + $2
-WHERE category_id = $1 AND start_date <= $3 AND end_date >= $3;
+-- check if the budget belongs to the workspace since the API already does that.
+-- Budgets are in the base currency of the workspace. The budgets of the parent categories include the expenses of their children,
+-- the account-wide ones, without categories, include every expense. A budget with several matching categories counts it once.
+WITH RECURSIVE ancestors AS (
+    SELECT categories.id, categories.parent_id FROM categories WHERE categories.id = sqlc.arg(category_id)
+    UNION
+    SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+)
+UPDATE budgets b SET amount = b.amount + sqlc.arg(base_amount)
+WHERE b.workspace_id = sqlc.arg(workspace_id) AND b.closed_at IS NULL
+AND b.start_date <= sqlc.arg(spent_at) AND b.end_date >= sqlc.arg(spent_at)
+AND (
+    NOT EXISTS (SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id)
+    OR EXISTS (
+        SELECT 1 FROM budget_categories bc
+        WHERE bc.budget_id = b.id AND bc.category_id IN (SELECT ancestors.id FROM ancestors)
+    )
+);
+
+-- name: RecalculateUserBudgetAmounts :exec
+-- Used when the category tree changes, closed periods keep their final amount.
+-- Split expenses count each split against its own category
+WITH RECURSIVE tree AS (
+    SELECT categories.id AS root_id, categories.id FROM categories WHERE categories.workspace_id = sqlc.arg(workspace_id)
+    UNION ALL
+    SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+UPDATE budgets b SET amount = COALESCE((
+    SELECT SUM(COALESCE(es.base_amount, e.base_amount)) FROM expenses e
+    LEFT JOIN expense_splits es ON es.expense_id = e.id
+    WHERE e.workspace_id = b.workspace_id AND e.spent_at >= b.start_date AND e.spent_at <= b.end_date
+    AND (
+        NOT EXISTS (SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id)
+        OR COALESCE(es.category_id, e.category_id) IN (
+            SELECT t.id FROM tree t
+            JOIN budget_categories bc ON bc.category_id = t.root_id
+            WHERE bc.budget_id = b.id
+        )
+    )
+), 0)
+WHERE b.workspace_id = sqlc.arg(workspace_id) AND b.closed_at IS NULL;
@@ -1,25 +1,42 @@
 -- name: CreateCategory :one
-INSERT INTO categories (id, created_at, updated_at, name, user_id)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO categories (id, created_at, updated_at, name, workspace_id, parent_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: DeleteCategory :one
-DELETE FROM categories WHERE id = $1 AND user_id = $2 RETURNING *;
+DELETE FROM categories WHERE id = $1 AND workspace_id = $2 RETURNING *;
 
 -- name: GetUserCategoriesPaged :many
-SELECT * FROM categories WHERE user_id = $1
+SELECT * FROM categories WHERE workspace_id = $1
 AND created_at >= $2 AND id < $3
 ORDER BY created_at ASC, id DESC
 LIMIT $4;
 
 -- name: GetUserCategories :many
-SELECT * FROM categories WHERE user_id = $1
+SELECT * FROM categories WHERE workspace_id = $1
 ORDER BY created_at ASC, id DESC
 LIMIT $2;
 
 -- name: GetCategoryByID :one
-SELECT * FROM categories WHERE id = $1 AND user_id = $2;
+SELECT * FROM categories WHERE id = $1 AND workspace_id = $2;
 
 -- name: UpdateCategory :one
-UPDATE categories SET name = $1 AND updated_at = $2
-WHERE id = $3 AND user_id = $4 RETURNING *;
+UPDATE categories SET name = $1, parent_id = $2, updated_at = $3
+WHERE id = $4 AND workspace_id = $5 RETURNING *;
+
+-- name: IsCategoryInSubtree :one
+-- Whether category_id is root_id or one of its descendants
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = sqlc.arg(root_id)
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE subtree.id = sqlc.arg(category_id)::uuid) AS in_subtree;
+
+-- name: GetAllUserCategories :many
+SELECT * FROM categories WHERE workspace_id = $1
+ORDER BY name ASC;
+
+-- name: CountUserCategories :one
+-- Used to check that all the given categories belong to the workspace
+SELECT COUNT(*) FROM categories WHERE workspace_id = sqlc.arg(workspace_id) AND id = ANY(sqlc.arg(ids)::uuid[]);
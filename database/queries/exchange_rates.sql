@@ -0,0 +1,11 @@
+-- name: UpsertExchangeRate :exec
+INSERT INTO exchange_rates (date, base, currency, rate)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (base, currency, date) DO UPDATE SET rate = EXCLUDED.rate;
+
+-- name: GetExchangeRate :one
+-- The last rate published on or before the date
+SELECT * FROM exchange_rates
+WHERE base = $1 AND currency = $2 AND date <= sqlc.arg(on_date)::date
+ORDER BY date DESC
+LIMIT 1;
@@ -0,0 +1,21 @@
+-- name: CreateExpenseSplit :one
+INSERT INTO expense_splits (id, position, amount, base_amount, note, category_id, expense_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: DeleteExpenseSplits :many
+DELETE FROM expense_splits WHERE expense_id = $1 RETURNING *;
+
+-- name: GetExpensesSplits :many
+-- Splits of each expense, for the expenses in a page
+SELECT * FROM expense_splits
+WHERE expense_id = ANY(sqlc.arg(expense_ids)::uuid[])
+ORDER BY expense_id, position ASC;
+
+-- name: DeleteCategorySplitExpenses :exec
+-- Like the expenses of a deleted category, the ones with a split in it are
+-- deleted, otherwise their splits would no longer add up to their amount
+DELETE FROM expenses e
+WHERE e.workspace_id = sqlc.arg(workspace_id) AND EXISTS (
+    SELECT 1 FROM expense_splits s WHERE s.expense_id = e.id AND s.category_id = sqlc.arg(category_id)
+);
@@ -1,48 +1,76 @@
--- TODO: add query (and route) to get all expenses in a time interval
-
 -- name: CreateExpense :one
-INSERT INTO expenses (id, created_at, updated_at, description, amount, category_id, user_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO expenses (
+    id, created_at, updated_at, description, amount, category_id, workspace_id, spent_at,
+    recurring_expense_id, currency, base_amount, merchant, notes, account_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 RETURNING *;
 
 -- name: DeleteExpense :one
-DELETE FROM expenses WHERE id = $1 AND user_id = $2 RETURNING *;
-
--- name: GetUserExpensesPaged :many
-SELECT * FROM expenses WHERE user_id = $1
-AND created_at <= $2 AND id < $3
-ORDER BY created_at DESC, id DESC
-LIMIT $4;
-
--- name: GetUserExpenses :many
-SELECT * FROM expenses WHERE user_id = $1
-ORDER BY created_at DESC, id DESC
-LIMIT $2;
-
--- name: GetCategoryExpensesPaged :many
-SELECT * FROM expenses WHERE category_id = $1 AND user_id = $2
-AND created_at <= $3 AND id < $4
-ORDER BY created_at DESC, id DESC
-LIMIT $5;
-
--- name: GetCategoryExpenses :many
-SELECT * FROM expenses WHERE category_id = $1 AND user_id = $2
-ORDER BY created_at DESC, id DESC
-LIMIT $3;
+DELETE FROM expenses WHERE id = $1 AND workspace_id = $2 RETURNING *;
 
 -- name: UpdateExpense :one
 -- No need to get nullable params since when using update it need to get the
 -- old values to update the budget
-UPDATE expenses SET description = $1, amount = $2, category_id = $3, updated_at = $4
-WHERE id = $5 AND user_id = $6 RETURNING *;
+UPDATE expenses SET description = $1, amount = $2, category_id = $3, spent_at = $4, currency = $5,
+    base_amount = $6, merchant = $7, notes = $8, account_id = $9, cleared_at = $10, updated_at = $11
+WHERE id = $12 AND workspace_id = $13 RETURNING *;
 
 -- name: GetExpenseByID :one
-SELECT * FROM expenses WHERE id = $1 AND user_id = $2;
+SELECT * FROM expenses WHERE id = $1 AND workspace_id = $2;
 
 -- name: GetTotalSpent :one
-SELECT CAST(SUM(amount) AS NUMERIC(10, 4)) FROM expenses
-WHERE user_id = $1 AND created_at >= sqlc.arg(start_date) AND created_at <= sqlc.arg(end_date);
+SELECT CAST(COALESCE(SUM(base_amount), 0) AS NUMERIC(10, 4)) AS total FROM expenses
+WHERE workspace_id = $1 AND spent_at >= sqlc.arg(start_date) AND spent_at <= sqlc.arg(end_date);
+
+-- name: GetTotalSpentInCategories :one
+-- Includes the expenses of the subcategories, or every expense without categories.
+-- Only the splits of split expenses in the categories count
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = ANY(sqlc.arg(category_ids)::uuid[])
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT CAST(COALESCE(SUM(COALESCE(es.base_amount, e.base_amount)), 0) AS NUMERIC(10, 4)) AS total
+FROM expenses e
+LEFT JOIN expense_splits es ON es.expense_id = e.id
+WHERE e.workspace_id = sqlc.arg(workspace_id)
+AND (cardinality(sqlc.arg(category_ids)::uuid[]) = 0 OR COALESCE(es.category_id, e.category_id) IN (SELECT subtree.id FROM subtree))
+AND e.spent_at >= sqlc.arg(start_date) AND e.spent_at <= sqlc.arg(end_date);
+
+-- name: GetUserExpenseKeysInRange :many
+-- Used to find duplicates when importing expenses
+SELECT spent_at, amount, currency, description FROM expenses
+WHERE workspace_id = $1 AND spent_at >= sqlc.arg(start_date) AND spent_at <= sqlc.arg(end_date);
 
--- name: GetTotalSpentInCategory :one
-SELECT CAST(SUM(amount) AS NUMERIC(10, 4)) FROM expenses
-WHERE user_id = $1 AND category_id = $2 AND created_at >= sqlc.arg(start_date) AND created_at <= sqlc.arg(end_date);
+-- name: SearchExpenses :many
+-- Ordered by relevance, the cursor is the rank and id of the last expense.
+-- The date range and category (with its subcategories and splits) are optional
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = sqlc.narg(category_id)::uuid
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+),
+search AS (
+    SELECT to_tsquery('simple', sqlc.arg(query)::text) AS query
+)
+SELECT sqlc.embed(e),
+    CAST(ts_rank(e.search_vector, search.query) AS REAL) AS rank,
+    CAST(ts_headline(
+        'simple', concat_ws(' | ', e.description, e.merchant, e.notes), search.query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5'
+    ) AS TEXT) AS snippet
+FROM expenses e, search
+WHERE e.workspace_id = sqlc.arg(workspace_id) AND e.search_vector @@ search.query
+AND (sqlc.narg(start_date)::timestamptz IS NULL OR e.spent_at >= sqlc.narg(start_date))
+AND (sqlc.narg(end_date)::timestamptz IS NULL OR e.spent_at <= sqlc.narg(end_date))
+AND (sqlc.narg(category_id)::uuid IS NULL OR e.category_id IN (SELECT subtree.id FROM subtree) OR EXISTS (
+    SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.category_id IN (SELECT subtree.id FROM subtree)
+))
+AND (
+    sqlc.narg(cursor_rank)::real IS NULL
+    OR ts_rank(e.search_vector, search.query) < sqlc.narg(cursor_rank)
+    OR (ts_rank(e.search_vector, search.query) = sqlc.narg(cursor_rank) AND e.id < sqlc.arg(cursor_id))
+)
+ORDER BY rank DESC, e.id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,81 @@
+-- Exports are streamed with the iterators in internal/repository/exports.go,
+-- which reuse these queries and their row types.
+
+-- name: ExportExpenses :many
+-- Filtering by a category includes its subcategories and the expenses split in them
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = sqlc.narg(category_id)::uuid
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT e.id, e.spent_at, e.description, e.amount, e.category_id, c.name AS category_name,
+    e.recurring_expense_id, e.created_at, e.updated_at, e.currency, e.base_amount,
+    e.merchant, e.notes,
+    ARRAY(
+        SELECT t.name FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+        WHERE et.expense_id = e.id ORDER BY t.name
+    )::text[] AS tags,
+    e.account_id, e.cleared_at
+FROM expenses e
+JOIN categories c ON c.id = e.category_id
+WHERE e.workspace_id = sqlc.arg(workspace_id)
+AND (sqlc.narg(start_date)::timestamptz IS NULL OR e.spent_at >= sqlc.narg(start_date))
+AND (sqlc.narg(end_date)::timestamptz IS NULL OR e.spent_at <= sqlc.narg(end_date))
+AND (sqlc.narg(category_id)::uuid IS NULL OR e.category_id IN (SELECT subtree.id FROM subtree) OR EXISTS (
+    SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.category_id IN (SELECT subtree.id FROM subtree)
+))
+ORDER BY e.spent_at ASC, e.id ASC;
+
+-- name: ExportCategories :many
+SELECT * FROM categories WHERE workspace_id = $1
+ORDER BY created_at ASC, id ASC;
+
+-- name: ExportBudgets :many
+-- The categories of a budget are joined like the tags of an expense, they
+-- are empty for the account-wide budgets
+SELECT b.id,
+    CAST(COALESCE(string_agg(c.id::text, '|' ORDER BY c.name), '') AS TEXT) AS category_ids,
+    CAST(COALESCE(string_agg(c.name, '|' ORDER BY c.name), '') AS TEXT) AS category_names,
+    b.amount, b.goal, b.start_date, b.end_date,
+    b.created_at, b.updated_at, b.currency, b.period, b.series_id, b.closed_at,
+    b.rollover, b.rollover_cap, b.carried, b.thresholds
+FROM budgets b
+LEFT JOIN budget_categories bc ON bc.budget_id = b.id
+LEFT JOIN categories c ON c.id = bc.category_id
+WHERE b.workspace_id = $1
+GROUP BY b.id
+ORDER BY b.start_date ASC, b.id ASC;
+
+-- name: ExportRecurringExpenses :many
+SELECT r.id, r.description, r.amount, r.category_id, c.name AS category_name, r.frequency,
+    r.repeat_interval, r.start_date, r.end_date, r.occurrence_limit, r.occurrences,
+    r.next_occurrence, r.created_at, r.updated_at, r.currency
+FROM recurring_expenses r
+JOIN categories c ON c.id = r.category_id
+WHERE r.workspace_id = $1
+ORDER BY r.created_at ASC, r.id ASC;
+
+-- name: ExportIncomes :many
+SELECT i.id, i.received_at, i.source, i.amount, i.category_id, c.name AS category_name,
+    i.created_at, i.updated_at, i.currency, i.base_amount, i.account_id
+FROM incomes i
+LEFT JOIN categories c ON c.id = i.category_id
+WHERE i.workspace_id = $1
+ORDER BY i.received_at ASC, i.id ASC;
+
+-- name: ExportAccounts :many
+SELECT * FROM accounts WHERE workspace_id = $1
+ORDER BY created_at ASC, id ASC;
+
+-- name: ExportTransfers :many
+SELECT * FROM transfers WHERE workspace_id = $1
+ORDER BY transferred_at ASC, id ASC;
+
+-- name: ExportExpenseSplits :many
+SELECT es.id, es.expense_id, es.position, es.amount, es.base_amount, es.note,
+    es.category_id, c.name AS category_name
+FROM expense_splits es
+JOIN expenses e ON e.id = es.expense_id
+JOIN categories c ON c.id = es.category_id
+WHERE e.workspace_id = $1
+ORDER BY e.spent_at ASC, es.expense_id ASC, es.position ASC;
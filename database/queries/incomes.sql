@@ -0,0 +1,30 @@
+-- name: CreateIncome :one
+INSERT INTO incomes (
+    id, created_at, updated_at, source, amount, currency, base_amount, received_at, category_id, workspace_id,
+    account_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: GetIncomeByID :one
+SELECT * FROM incomes WHERE id = $1 AND workspace_id = $2;
+
+-- name: UpdateIncome :one
+UPDATE incomes SET source = $1, amount = $2, currency = $3, base_amount = $4, received_at = $5,
+    category_id = $6, account_id = $7, updated_at = $8
+WHERE id = $9 AND workspace_id = $10 RETURNING *;
+
+-- name: DeleteIncome :one
+DELETE FROM incomes WHERE id = $1 AND workspace_id = $2 RETURNING *;
+
+-- name: GetUserIncomes :many
+-- The latest first, the cursor is the last income of the previous page. The
+-- date range is optional
+SELECT * FROM incomes
+WHERE workspace_id = sqlc.arg(workspace_id)
+AND (sqlc.narg(start_date)::timestamptz IS NULL OR received_at >= sqlc.narg(start_date))
+AND (sqlc.narg(end_date)::timestamptz IS NULL OR received_at <= sqlc.narg(end_date))
+AND (sqlc.narg(cursor_received_at)::timestamptz IS NULL
+    OR (received_at, id) < (sqlc.narg(cursor_received_at)::timestamptz, sqlc.narg(cursor_id)::uuid))
+ORDER BY received_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,70 @@
+-- name: SetUserTOTPSecret :exec
+-- Starts the enrollment again, two-factor authentication stays off until a
+-- code of the new secret is confirmed
+UPDATE users SET totp_secret = $1, totp_enabled_at = NULL, totp_last_step = 0, updated_at = $2
+WHERE id = $3;
+
+-- name: EnableUserTOTP :execrows
+UPDATE users SET totp_enabled_at = sqlc.arg(enabled_at), updated_at = sqlc.arg(enabled_at)
+WHERE id = sqlc.arg(id) AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL;
+
+-- name: DisableUserTOTP :exec
+UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = $1
+WHERE id = $2;
+
+-- name: UseTOTPStep :execrows
+-- Only succeeds for a time step after the last one used, so a code can not
+-- be replayed
+UPDATE users SET totp_last_step = sqlc.arg(step)
+WHERE id = sqlc.arg(id) AND totp_last_step < sqlc.arg(step);
+
+-- name: AddRecoveryCodes :exec
+INSERT INTO mfa_recovery_codes (id, created_at, code_hash, user_id)
+SELECT unnest(sqlc.arg(ids)::uuid[]), sqlc.arg(created_at)::timestamptz,
+    unnest(sqlc.arg(code_hashes)::text[]), sqlc.arg(user_id)::uuid;
+
+-- name: UseRecoveryCode :execrows
+UPDATE mfa_recovery_codes SET used_at = $1
+WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM mfa_recovery_codes WHERE user_id = $1;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (id, created_at, token_hash, device, expires_at, user_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: AttemptMFAChallenge :one
+-- Counts a try to answer the challenge, failing once it expired or ran out
+-- of tries
+UPDATE mfa_challenges SET attempts = attempts + 1
+WHERE token_hash = sqlc.arg(token_hash) AND expires_at > sqlc.arg(now)
+    AND attempts < sqlc.arg(max_attempts)::int
+RETURNING *;
+
+-- name: DeleteMFAChallenge :execrows
+DELETE FROM mfa_challenges WHERE id = $1;
+
+-- name: DeleteExpiredMFAChallenges :execrows
+DELETE FROM mfa_challenges WHERE expires_at < sqlc.arg(expired_before);
+
+-- name: FailMFAAttempt :exec
+-- Counts a wrong password or code, locking the user out until locked_until
+-- on the last attempt allowed and starting the count again
+UPDATE users SET
+    mfa_failed_attempts = CASE
+        WHEN mfa_failed_attempts + 1 >= sqlc.arg(max_attempts)::int THEN 0
+        ELSE mfa_failed_attempts + 1
+    END,
+    mfa_locked_until = CASE
+        WHEN mfa_failed_attempts + 1 >= sqlc.arg(max_attempts)::int THEN sqlc.arg(locked_until)::timestamptz
+        ELSE mfa_locked_until
+    END
+WHERE id = sqlc.arg(id);
+
+-- name: ResetMFAAttempts :exec
+UPDATE users SET mfa_failed_attempts = 0, mfa_locked_until = NULL WHERE id = $1;
@@ -0,0 +1,18 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (id, created_at, kind, title, message, data, user_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetUserNotifications :many
+-- The latest first, the cursor is the last notification of the previous page
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(unread_only)::bool OR read_at IS NULL)
+AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamptz, sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: MarkNotificationRead :one
+UPDATE notifications SET read_at = COALESCE(read_at, sqlc.arg(read_at)::timestamptz)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) RETURNING *;
@@ -0,0 +1,42 @@
+-- name: CreateRecurringExpense :one
+INSERT INTO recurring_expenses (
+    id, created_at, updated_at, description, amount, frequency, repeat_interval,
+    start_date, end_date, occurrence_limit, next_occurrence, category_id, workspace_id, currency
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING *;
+
+-- name: DeleteRecurringExpense :one
+DELETE FROM recurring_expenses WHERE id = $1 AND workspace_id = $2 RETURNING *;
+
+-- name: GetUserRecurringExpensesPaged :many
+SELECT * FROM recurring_expenses WHERE workspace_id = $1
+AND (created_at > sqlc.arg(cursor_created_at) OR (created_at = sqlc.arg(cursor_created_at) AND id < sqlc.arg(cursor_id)))
+ORDER BY created_at ASC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetUserRecurringExpenses :many
+SELECT * FROM recurring_expenses WHERE workspace_id = $1
+ORDER BY created_at ASC, id DESC
+LIMIT $2;
+
+-- name: GetRecurringExpenseByID :one
+SELECT * FROM recurring_expenses WHERE id = $1 AND workspace_id = $2;
+
+-- name: UpdateRecurringExpense :one
+UPDATE recurring_expenses SET
+    description = $1, amount = $2, frequency = $3, repeat_interval = $4, start_date = $5,
+    end_date = $6, occurrence_limit = $7, next_occurrence = $8, category_id = $9, currency = $10,
+    updated_at = $11
+WHERE id = $12 AND workspace_id = $13 RETURNING *;
+
+-- name: GetDueRecurringExpenses :many
+-- Used by the scheduler, so it is not scoped to a workspace
+SELECT * FROM recurring_expenses
+WHERE next_occurrence IS NOT NULL AND next_occurrence <= sqlc.arg(due_at)::timestamptz
+ORDER BY next_occurrence ASC, id ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: AdvanceRecurringExpense :exec
+UPDATE recurring_expenses SET occurrences = $1, next_occurrence = $2, updated_at = $3
+WHERE id = $4;
@@ -0,0 +1,39 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, device, issued_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetRefreshTokenByHash :one
+SELECT * FROM refresh_tokens WHERE token_hash = $1;
+
+-- name: RotateRefreshToken :execrows
+-- Only succeeds once, a second rotation of the same token means it was reused
+UPDATE refresh_tokens SET revoked_at = sqlc.arg(used_at), last_used_at = sqlc.arg(used_at), replaced_by = sqlc.arg(replaced_by)
+WHERE id = sqlc.arg(id) AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens SET revoked_at = sqlc.arg(revoked_at)
+WHERE family_id = sqlc.arg(family_id) AND revoked_at IS NULL;
+
+-- name: RevokeUserSession :execrows
+UPDATE refresh_tokens SET revoked_at = sqlc.arg(revoked_at)
+WHERE family_id = sqlc.arg(family_id) AND user_id = sqlc.arg(user_id) AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens SET revoked_at = sqlc.arg(revoked_at)
+WHERE user_id = sqlc.arg(user_id) AND revoked_at IS NULL;
+
+-- name: GetUserSessions :many
+-- A session is a token family with a token that can still be used
+SELECT family_id AS id, device,
+    CAST(MIN(issued_at) AS TIMESTAMPTZ) AS signed_in_at,
+    CAST(MAX(COALESCE(last_used_at, issued_at)) AS TIMESTAMPTZ) AS last_active_at,
+    CAST(MAX(expires_at) AS TIMESTAMPTZ) AS expires_at
+FROM refresh_tokens
+WHERE user_id = $1
+GROUP BY family_id, device
+HAVING bool_or(revoked_at IS NULL AND expires_at > sqlc.arg(now))
+ORDER BY last_active_at DESC;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens WHERE expires_at < sqlc.arg(expired_before);
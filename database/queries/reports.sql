@@ -0,0 +1,118 @@
+-- Totals are in the base currency of the workspace
+
+-- name: GetCategoryTotals :many
+-- The total of a category includes its subcategories, so only the totals of
+-- the top level categories (without parent_id) add up to the whole spending.
+-- Split expenses count each split against its own category
+WITH RECURSIVE tree AS (
+    SELECT categories.id AS root_id, categories.id FROM categories WHERE categories.workspace_id = $1
+    UNION ALL
+    SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+),
+spent AS (
+    SELECT e.id AS expense_id,
+        COALESCE(es.category_id, e.category_id) AS category_id,
+        COALESCE(es.base_amount, e.base_amount) AS base_amount
+    FROM expenses e
+    LEFT JOIN expense_splits es ON es.expense_id = e.id
+    WHERE e.workspace_id = $1 AND e.spent_at >= sqlc.arg(start_date) AND e.spent_at <= sqlc.arg(end_date)
+)
+SELECT c.id AS category_id, c.name AS category_name, c.parent_id,
+    CAST(SUM(s.base_amount) AS NUMERIC(12, 2)) AS total,
+    CAST(COUNT(DISTINCT s.expense_id) AS BIGINT) AS expense_count
+FROM categories c
+JOIN tree t ON t.root_id = c.id
+JOIN spent s ON s.category_id = t.id
+GROUP BY c.id, c.name, c.parent_id
+ORDER BY total DESC, c.name ASC;
+
+-- name: GetTagTotals :many
+-- An expense with several tags counts towards each of them, so the totals do
+-- not add up to the whole spending
+SELECT t.id AS tag_id, t.name AS tag_name,
+    CAST(SUM(e.base_amount) AS NUMERIC(12, 2)) AS total,
+    COUNT(e.id) AS expense_count
+FROM tags t
+JOIN expense_tags et ON et.tag_id = t.id
+JOIN expenses e ON e.id = et.expense_id
+WHERE t.workspace_id = $1 AND e.spent_at >= sqlc.arg(start_date) AND e.spent_at <= sqlc.arg(end_date)
+GROUP BY t.id, t.name
+ORDER BY total DESC, t.name ASC;
+
+-- name: GetSpendingTimeSeries :many
+-- Buckets are truncated in the given time zone, so a month starts at
+-- midnight of the user and not at midnight UTC. They are shifted by
+-- shift_days before truncating and back after it, to start the weeks on
+-- another day than Monday and the months on another day than the 1st.
+-- Filtering by a category includes its subcategories and the splits in them
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = sqlc.narg(category_id)::uuid
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT CAST((date_trunc(sqlc.arg(bucket)::text, (e.spent_at AT TIME ZONE sqlc.arg(time_zone)::text) + make_interval(days => sqlc.arg(shift_days)::int))
+    - make_interval(days => sqlc.arg(shift_days)::int)) AT TIME ZONE sqlc.arg(time_zone)::text AS TIMESTAMPTZ) AS period,
+    CAST(SUM(COALESCE(es.base_amount, e.base_amount)) AS NUMERIC(12, 2)) AS total
+FROM expenses e
+LEFT JOIN expense_splits es ON es.expense_id = e.id
+WHERE e.workspace_id = $1 AND e.spent_at >= sqlc.arg(start_date) AND e.spent_at <= sqlc.arg(end_date)
+AND (sqlc.narg(category_id)::uuid IS NULL OR COALESCE(es.category_id, e.category_id) IN (SELECT subtree.id FROM subtree))
+GROUP BY period
+ORDER BY period ASC;
+
+-- name: GetSpendingTimeSeriesByCategory :many
+SELECT CAST((date_trunc(sqlc.arg(bucket)::text, (e.spent_at AT TIME ZONE sqlc.arg(time_zone)::text) + make_interval(days => sqlc.arg(shift_days)::int))
+    - make_interval(days => sqlc.arg(shift_days)::int)) AT TIME ZONE sqlc.arg(time_zone)::text AS TIMESTAMPTZ) AS period,
+    CAST(COALESCE(es.category_id, e.category_id) AS UUID) AS category_id,
+    CAST(SUM(COALESCE(es.base_amount, e.base_amount)) AS NUMERIC(12, 2)) AS total
+FROM expenses e
+LEFT JOIN expense_splits es ON es.expense_id = e.id
+WHERE e.workspace_id = $1 AND e.spent_at >= sqlc.arg(start_date) AND e.spent_at <= sqlc.arg(end_date)
+GROUP BY period, category_id
+ORDER BY period ASC, category_id ASC;
+
+-- name: GetCashFlow :many
+-- Incomes and expenses per bucket, truncated in the given time zone like the
+-- spending time series. Buckets without any of them are left out
+WITH flows AS (
+    SELECT incomes.received_at AS at, incomes.base_amount AS income, CAST(0 AS NUMERIC) AS spent
+    FROM incomes
+    WHERE incomes.workspace_id = sqlc.arg(workspace_id)::uuid
+    AND incomes.received_at >= sqlc.arg(start_date)::timestamptz AND incomes.received_at <= sqlc.arg(end_date)::timestamptz
+    UNION ALL
+    SELECT expenses.spent_at, CAST(0 AS NUMERIC), expenses.base_amount
+    FROM expenses
+    WHERE expenses.workspace_id = sqlc.arg(workspace_id)::uuid
+    AND expenses.spent_at >= sqlc.arg(start_date)::timestamptz AND expenses.spent_at <= sqlc.arg(end_date)::timestamptz
+)
+SELECT CAST((date_trunc(sqlc.arg(bucket)::text, (flows.at AT TIME ZONE sqlc.arg(time_zone)::text) + make_interval(days => sqlc.arg(shift_days)::int))
+    - make_interval(days => sqlc.arg(shift_days)::int)) AT TIME ZONE sqlc.arg(time_zone)::text AS TIMESTAMPTZ) AS period,
+    CAST(SUM(flows.income) AS NUMERIC(12, 2)) AS income,
+    CAST(SUM(flows.spent) AS NUMERIC(12, 2)) AS expenses
+FROM flows
+GROUP BY period
+ORDER BY period ASC;
+
+-- name: GetIncomeVsExpensesByCategory :many
+-- Totals of the categories themselves, without their subcategories, so they
+-- add up to the whole income and spending. Incomes without a category are
+-- grouped under a null category, split expenses under the category of each split
+WITH flows AS (
+    SELECT incomes.category_id, incomes.base_amount AS income, CAST(0 AS NUMERIC) AS spent
+    FROM incomes
+    WHERE incomes.workspace_id = sqlc.arg(workspace_id)::uuid
+    AND incomes.received_at >= sqlc.arg(start_date)::timestamptz AND incomes.received_at <= sqlc.arg(end_date)::timestamptz
+    UNION ALL
+    SELECT COALESCE(es.category_id, e.category_id), CAST(0 AS NUMERIC), COALESCE(es.base_amount, e.base_amount)
+    FROM expenses e
+    LEFT JOIN expense_splits es ON es.expense_id = e.id
+    WHERE e.workspace_id = sqlc.arg(workspace_id)::uuid
+    AND e.spent_at >= sqlc.arg(start_date)::timestamptz AND e.spent_at <= sqlc.arg(end_date)::timestamptz
+)
+SELECT flows.category_id AS category_id, c.name AS category_name, c.parent_id,
+    CAST(SUM(flows.income) AS NUMERIC(12, 2)) AS income,
+    CAST(SUM(flows.spent) AS NUMERIC(12, 2)) AS expenses
+FROM flows
+LEFT JOIN categories c ON c.id = flows.category_id
+GROUP BY flows.category_id, c.name, c.parent_id
+ORDER BY c.name ASC NULLS LAST;
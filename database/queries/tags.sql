@@ -0,0 +1,43 @@
+-- name: CreateTag :one
+INSERT INTO tags (id, created_at, updated_at, name, workspace_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: DeleteTag :one
+DELETE FROM tags WHERE id = $1 AND workspace_id = $2 RETURNING *;
+
+-- name: GetUserTags :many
+SELECT * FROM tags WHERE workspace_id = $1
+ORDER BY name ASC;
+
+-- name: GetTagByID :one
+SELECT * FROM tags WHERE id = $1 AND workspace_id = $2;
+
+-- name: GetUserTagsByName :many
+SELECT * FROM tags WHERE workspace_id = sqlc.arg(workspace_id) AND name = ANY(sqlc.arg(names)::text[]);
+
+-- name: UpdateTag :one
+UPDATE tags SET name = $1, updated_at = $2
+WHERE id = $3 AND workspace_id = $4 RETURNING *;
+
+-- name: AddExpenseTag :exec
+INSERT INTO expense_tags (expense_id, tag_id) VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteExpenseTags :exec
+DELETE FROM expense_tags WHERE expense_id = $1;
+
+-- name: GetExpensesTags :many
+-- Tags of each expense, for the expenses in a page
+SELECT expense_tags.expense_id, tags.name FROM expense_tags
+JOIN tags ON tags.id = expense_tags.tag_id
+WHERE expense_tags.expense_id = ANY(sqlc.arg(expense_ids)::uuid[])
+ORDER BY tags.name ASC;
+
+-- name: MoveExpenseTags :exec
+-- Links the expenses of the source tag to the target one, skipping the
+-- expenses that already have both
+INSERT INTO expense_tags (expense_id, tag_id)
+SELECT expense_tags.expense_id, sqlc.arg(target_id)::uuid FROM expense_tags
+WHERE expense_tags.tag_id = sqlc.arg(source_id)::uuid
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,24 @@
+-- name: CreateTransfer :one
+INSERT INTO transfers (
+    id, created_at, from_account_id, to_account_id, amount, to_amount, description, transferred_at, workspace_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetTransferByID :one
+SELECT * FROM transfers WHERE id = $1 AND workspace_id = $2;
+
+-- name: GetUserTransfers :many
+-- The latest first, the cursor is the last transfer of the previous page.
+-- Filtering by an account keeps the transfers from and to it
+SELECT * FROM transfers
+WHERE workspace_id = sqlc.arg(workspace_id)
+AND (sqlc.narg(account_id)::uuid IS NULL
+    OR from_account_id = sqlc.narg(account_id)::uuid OR to_account_id = sqlc.narg(account_id)::uuid)
+AND (sqlc.narg(cursor_transferred_at)::timestamptz IS NULL
+    OR (transferred_at, id) < (sqlc.narg(cursor_transferred_at)::timestamptz, sqlc.narg(cursor_id)::uuid))
+ORDER BY transferred_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteTransfer :one
+DELETE FROM transfers WHERE id = $1 AND workspace_id = $2 RETURNING *;
@@ -0,0 +1,18 @@
+-- name: CreateUserToken :one
+INSERT INTO user_tokens (id, created_at, kind, token_hash, expires_at, user_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UseUserToken :one
+-- Only succeeds once and before the token expires
+UPDATE user_tokens SET used_at = sqlc.arg(used_at)
+WHERE token_hash = sqlc.arg(token_hash) AND kind = sqlc.arg(kind)
+    AND used_at IS NULL AND expires_at > sqlc.arg(used_at)
+RETURNING *;
+
+-- name: DeleteUserTokens :exec
+-- A new token replaces the ones of the same kind sent before
+DELETE FROM user_tokens WHERE user_id = $1 AND kind = $2;
+
+-- name: DeleteExpiredUserTokens :execrows
+DELETE FROM user_tokens WHERE expires_at < sqlc.arg(expired_before);
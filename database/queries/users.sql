@@ -7,9 +7,25 @@ SELECT * FROM users WHERE id = $1;
 SELECT * FROM users WHERE email = $1;
 
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name, email, password)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO users (id, created_at, updated_at, name, email, password, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: DeleteUser :one
 DELETE FROM users WHERE id = $1 RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET password = $1, updated_at = $2 WHERE id = $3;
+
+-- name: SetUserEmailVerified :one
+UPDATE users SET email_verified_at = $1, updated_at = $2 WHERE id = $3
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users SET name = $1, email = $2, email_verified_at = $3, updated_at = $4 WHERE id = $5
+RETURNING *;
+
+-- name: UpdateUserSettings :one
+UPDATE users SET currency = $1, time_zone = $2, locale = $3, week_start = $4, month_start_day = $5, updated_at = $6
+WHERE id = $7
+RETURNING *;
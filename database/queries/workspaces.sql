@@ -0,0 +1,102 @@
+-- name: CreateWorkspace :one
+INSERT INTO workspaces (id, created_at, updated_at, name, currency, personal)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetWorkspaceByID :one
+SELECT * FROM workspaces WHERE id = $1;
+
+-- name: GetUserWorkspaces :many
+-- Workspaces the user is a member of, the personal one first
+SELECT sqlc.embed(w), m.role FROM workspaces w
+JOIN workspace_members m ON m.workspace_id = w.id
+WHERE m.user_id = $1
+ORDER BY w.personal DESC, w.name ASC, w.id ASC;
+
+-- name: UpdateWorkspace :one
+UPDATE workspaces SET name = $1, updated_at = $2
+WHERE id = $3 RETURNING *;
+
+-- name: UpdateWorkspaceCurrency :exec
+UPDATE workspaces SET currency = $1, updated_at = $2 WHERE id = $3;
+
+-- name: WorkspaceHasBaseAmounts :one
+-- Whether the workspace has amounts stored in its base currency, which can
+-- not change afterwards
+SELECT CAST(
+    EXISTS (SELECT 1 FROM expenses WHERE expenses.workspace_id = sqlc.arg(workspace_id)::uuid)
+    OR EXISTS (SELECT 1 FROM incomes WHERE incomes.workspace_id = sqlc.arg(workspace_id)::uuid)
+    OR EXISTS (SELECT 1 FROM budgets WHERE budgets.workspace_id = sqlc.arg(workspace_id)::uuid)
+AS BOOLEAN) AS has_base_amounts;
+
+-- name: DeleteWorkspace :one
+DELETE FROM workspaces WHERE id = $1 RETURNING *;
+
+-- name: DeleteUserWorkspaces :exec
+-- The workspaces the user is the only owner of are deleted with the user,
+-- the ones shared with other owners are kept
+DELETE FROM workspaces w
+WHERE EXISTS (
+    SELECT 1 FROM workspace_members m
+    WHERE m.workspace_id = w.id AND m.user_id = sqlc.arg(user_id) AND m.role = 'owner'
+)
+AND NOT EXISTS (
+    SELECT 1 FROM workspace_members m
+    WHERE m.workspace_id = w.id AND m.user_id <> sqlc.arg(user_id) AND m.role = 'owner'
+);
+
+-- name: GetWorkspaceRole :one
+SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2;
+
+-- name: GetWorkspaceMembers :many
+SELECT m.user_id, u.name, u.email, m.role, m.created_at FROM workspace_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.workspace_id = $1
+ORDER BY m.created_at ASC, m.user_id ASC;
+
+-- name: AddWorkspaceMember :exec
+-- Members keep their role when accepting another invitation
+INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+-- name: UpdateWorkspaceMember :one
+UPDATE workspace_members SET role = $1
+WHERE workspace_id = $2 AND user_id = $3 RETURNING *;
+
+-- name: DeleteWorkspaceMember :one
+DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2 RETURNING *;
+
+-- name: CountWorkspaceOwners :one
+SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'owner';
+
+-- name: CreateWorkspaceInvitation :one
+INSERT INTO workspace_invitations (id, created_at, email, role, token_hash, expires_at, workspace_id, invited_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetWorkspaceInvitations :many
+-- Invitations not accepted yet, the latest first
+SELECT * FROM workspace_invitations
+WHERE workspace_id = $1 AND accepted_at IS NULL
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetWorkspaceInvitationByHash :one
+SELECT * FROM workspace_invitations WHERE token_hash = $1;
+
+-- name: AcceptWorkspaceInvitation :execrows
+-- Affects no rows when the invitation was already accepted
+UPDATE workspace_invitations SET accepted_at = $1
+WHERE id = $2 AND accepted_at IS NULL;
+
+-- name: DeleteWorkspaceInvitation :one
+DELETE FROM workspace_invitations WHERE id = $1 AND workspace_id = $2 RETURNING *;
+
+-- name: GetWorkspaceTimeZone :one
+-- The days of the workspace are in the time zone of its first owner, the
+-- user of a personal workspace
+SELECT u.time_zone FROM workspace_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.workspace_id = $1 AND m.role = 'owner'
+ORDER BY m.created_at ASC, m.user_id ASC
+LIMIT 1;
@@ -0,0 +1,13 @@
+-- +goose Up
+
+ALTER TABLE expenses ADD COLUMN spent_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Existing expenses were always registered at the time they were created
+UPDATE expenses SET spent_at = created_at;
+
+CREATE INDEX idx_expenses_spent_at ON expenses (user_id, spent_at, id);
+
+-- +goose Down
+
+DROP INDEX idx_expenses_spent_at;
+ALTER TABLE expenses DROP COLUMN spent_at;
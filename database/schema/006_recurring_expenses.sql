@@ -0,0 +1,38 @@
+-- +goose Up
+
+CREATE TABLE recurring_expenses (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+
+    description TEXT NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    frequency VARCHAR(7) NOT NULL,
+    repeat_interval INTEGER NOT NULL DEFAULT 1,
+    start_date TIMESTAMPTZ NOT NULL,
+    end_date TIMESTAMPTZ, -- NULL repeats forever
+    occurrence_limit INTEGER, -- NULL repeats forever
+    occurrences INTEGER NOT NULL DEFAULT 0, -- how many were already added to expenses
+    next_occurrence TIMESTAMPTZ, -- NULL when there are no more occurrences
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT frequency_check CHECK (frequency IN ('daily', 'weekly', 'monthly', 'yearly')),
+    CONSTRAINT repeat_interval_check CHECK (repeat_interval > 0),
+    CONSTRAINT occurrence_limit_check CHECK (occurrence_limit IS NULL OR occurrence_limit > 0),
+    CONSTRAINT date_check CHECK (end_date IS NULL OR start_date <= end_date)
+);
+
+CREATE INDEX idx_recurring_expenses_pagination ON recurring_expenses (created_at, id);
+CREATE INDEX idx_recurring_expenses_next_occurrence ON recurring_expenses (next_occurrence);
+
+ALTER TABLE expenses ADD COLUMN recurring_expense_id UUID REFERENCES recurring_expenses(id) ON DELETE SET NULL;
+
+-- An occurrence can only be added once, even if the scheduler is restarted mid run
+CREATE UNIQUE INDEX idx_expenses_recurring_occurrence ON expenses (recurring_expense_id, spent_at);
+
+-- +goose Down
+
+DROP INDEX idx_expenses_recurring_occurrence;
+ALTER TABLE expenses DROP COLUMN recurring_expense_id;
+DROP TABLE recurring_expenses;
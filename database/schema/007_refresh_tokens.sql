@@ -0,0 +1,21 @@
+-- +goose Up
+
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY, -- jti claim of the token
+    family_id UUID NOT NULL, -- shared by every token rotated from the same login
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- hex encoded SHA-256 of the token
+    device VARCHAR(255) NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    replaced_by UUID REFERENCES refresh_tokens(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens (user_id);
+
+-- +goose Down
+
+DROP TABLE refresh_tokens;
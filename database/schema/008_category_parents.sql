@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Subcategories become top level categories when their parent is deleted
+ALTER TABLE categories ADD COLUMN parent_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE categories ADD CONSTRAINT parent_check CHECK (parent_id <> id);
+
+CREATE INDEX idx_categories_parent ON categories (parent_id);
+
+-- +goose Down
+
+ALTER TABLE categories DROP COLUMN parent_id;
@@ -0,0 +1,43 @@
+-- +goose Up
+
+-- Currencies are ISO 4217 codes, amounts in the base currency of the user are
+-- converted with the exchange rate of the day they were spent at
+ALTER TABLE users ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'EUR';
+
+-- Existing amounts are in the base currency of their user
+ALTER TABLE expenses ADD COLUMN currency CHAR(3);
+ALTER TABLE expenses ADD COLUMN base_amount NUMERIC(10, 2);
+UPDATE expenses SET currency = users.currency, base_amount = expenses.amount
+FROM users WHERE users.id = expenses.user_id;
+ALTER TABLE expenses ALTER COLUMN currency SET NOT NULL, ALTER COLUMN base_amount SET NOT NULL;
+
+ALTER TABLE recurring_expenses ADD COLUMN currency CHAR(3);
+UPDATE recurring_expenses SET currency = users.currency
+FROM users WHERE users.id = recurring_expenses.user_id;
+ALTER TABLE recurring_expenses ALTER COLUMN currency SET NOT NULL;
+
+-- Budgets track the expenses in the base currency of the user
+ALTER TABLE budgets ADD COLUMN currency CHAR(3);
+UPDATE budgets SET currency = users.currency
+FROM users WHERE users.id = budgets.user_id;
+ALTER TABLE budgets ALTER COLUMN currency SET NOT NULL;
+
+-- 1 base = rate currency, on the given date. The ECB publishes rates with EUR
+-- as the base on working days only, so the last rate before a date is used
+CREATE TABLE exchange_rates (
+    date DATE NOT NULL,
+    base CHAR(3) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    rate NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (base, currency, date)
+);
+
+-- +goose Down
+
+DROP TABLE exchange_rates;
+
+ALTER TABLE budgets DROP COLUMN currency;
+ALTER TABLE recurring_expenses DROP COLUMN currency;
+ALTER TABLE expenses DROP COLUMN base_amount;
+ALTER TABLE expenses DROP COLUMN currency;
+ALTER TABLE users DROP COLUMN currency;
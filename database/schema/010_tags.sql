@@ -0,0 +1,26 @@
+-- +goose Up
+
+CREATE TABLE tags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+
+    name VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT tags_user_name UNIQUE (user_id, name)
+);
+
+CREATE TABLE expense_tags (
+    expense_id UUID NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+
+    PRIMARY KEY (expense_id, tag_id)
+);
+
+CREATE INDEX idx_expense_tags_tag ON expense_tags (tag_id);
+
+-- +goose Down
+
+DROP TABLE expense_tags;
+DROP TABLE tags;
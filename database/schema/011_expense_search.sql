@@ -0,0 +1,20 @@
+-- +goose Up
+
+ALTER TABLE expenses ADD COLUMN merchant VARCHAR(255);
+ALTER TABLE expenses ADD COLUMN notes TEXT;
+
+-- The simple configuration does not stem words, since descriptions and
+-- merchants are in any language. Matches in the description rank higher.
+ALTER TABLE expenses ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(description, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(merchant, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(notes, '')), 'C')
+) STORED;
+
+CREATE INDEX idx_expenses_search ON expenses USING GIN (search_vector);
+
+-- +goose Down
+
+ALTER TABLE expenses DROP COLUMN search_vector;
+ALTER TABLE expenses DROP COLUMN notes;
+ALTER TABLE expenses DROP COLUMN merchant;
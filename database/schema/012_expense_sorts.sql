@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Keyset pagination of the expenses sorted by amount or description, the
+-- date sort uses idx_expenses_spent_at
+CREATE INDEX idx_expenses_base_amount ON expenses (user_id, base_amount, id);
+CREATE INDEX idx_expenses_description ON expenses (user_id, description, id);
+
+-- +goose Down
+
+DROP INDEX idx_expenses_description;
+DROP INDEX idx_expenses_base_amount;
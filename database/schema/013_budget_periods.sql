@@ -0,0 +1,24 @@
+-- +goose Up
+
+-- A recurring budget is a budget per period, all sharing the same series_id.
+-- When a period ends it is closed, keeping its final amount, and the next
+-- one is started.
+ALTER TABLE budgets ADD COLUMN period VARCHAR(9); -- NULL for a single period
+ALTER TABLE budgets ADD COLUMN series_id UUID;
+ALTER TABLE budgets ADD COLUMN closed_at TIMESTAMPTZ; -- NULL while the period is open
+
+ALTER TABLE budgets ADD CONSTRAINT period_check
+    CHECK (period IN ('weekly', 'monthly', 'quarterly', 'yearly'));
+
+-- A period can only be started once, even if the scheduler is restarted mid run
+CREATE UNIQUE INDEX idx_budgets_series_period ON budgets (series_id, start_date);
+CREATE INDEX idx_budgets_open_periods ON budgets (end_date) WHERE period IS NOT NULL AND closed_at IS NULL;
+
+-- +goose Down
+
+DROP INDEX idx_budgets_open_periods;
+DROP INDEX idx_budgets_series_period;
+ALTER TABLE budgets DROP CONSTRAINT period_check;
+ALTER TABLE budgets DROP COLUMN closed_at;
+ALTER TABLE budgets DROP COLUMN series_id;
+ALTER TABLE budgets DROP COLUMN period;
@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- What is left of a period of a recurring budget (its effective goal minus
+-- the amount spent) can be carried into the next one, depending on the
+-- rollover policy. The capped policy carries both up to rollover_cap.
+ALTER TABLE budgets ADD COLUMN rollover VARCHAR(7) NOT NULL DEFAULT 'none';
+ALTER TABLE budgets ADD COLUMN rollover_cap NUMERIC(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE budgets ADD COLUMN carried NUMERIC(10, 2) NOT NULL DEFAULT 0; -- from the previous period
+ALTER TABLE budgets ADD COLUMN effective_goal NUMERIC(10, 2) NOT NULL GENERATED ALWAYS AS (goal + carried) STORED;
+
+ALTER TABLE budgets ADD CONSTRAINT rollover_check
+    CHECK (rollover IN ('none', 'surplus', 'deficit', 'both', 'capped'));
+ALTER TABLE budgets ADD CONSTRAINT rollover_cap_check CHECK (rollover_cap >= 0);
+
+-- +goose Down
+
+ALTER TABLE budgets DROP CONSTRAINT rollover_cap_check;
+ALTER TABLE budgets DROP CONSTRAINT rollover_check;
+ALTER TABLE budgets DROP COLUMN effective_goal;
+ALTER TABLE budgets DROP COLUMN carried;
+ALTER TABLE budgets DROP COLUMN rollover_cap;
+ALTER TABLE budgets DROP COLUMN rollover;
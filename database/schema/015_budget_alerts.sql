@@ -0,0 +1,40 @@
+-- +goose Up
+
+-- Percentages of the effective goal that alert the user once reached
+ALTER TABLE budgets ADD COLUMN thresholds INTEGER[] NOT NULL DEFAULT '{}';
+
+-- The thresholds fired by each budget. Every period of a recurring budget is
+-- its own row, so a threshold fires once per period.
+CREATE TABLE budget_alerts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+
+    threshold INTEGER NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL, -- spent when the alert fired
+    goal NUMERIC(10, 2) NOT NULL,
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT budget_alerts_budget_threshold UNIQUE (budget_id, threshold)
+);
+
+-- In-app inbox
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+
+    kind VARCHAR(32) NOT NULL,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMPTZ,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_user ON notifications (user_id, created_at, id);
+
+-- +goose Down
+
+DROP TABLE notifications;
+DROP TABLE budget_alerts;
+ALTER TABLE budgets DROP COLUMN thresholds;
@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- A budget counts the expenses of all its categories, and their
+-- subcategories, or of the whole account when it has none.
+CREATE TABLE budget_categories (
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+
+    PRIMARY KEY (budget_id, category_id)
+);
+
+CREATE INDEX idx_budget_categories_category ON budget_categories (category_id);
+
+INSERT INTO budget_categories (budget_id, category_id)
+SELECT id, category_id FROM budgets;
+
+ALTER TABLE budgets DROP COLUMN category_id;
+
+-- Account-wide budgets are found by user instead of category
+CREATE INDEX idx_budgets_user_dates ON budgets (user_id, start_date, end_date) WHERE closed_at IS NULL;
+
+-- +goose Down
+
+-- Budgets keep one of their categories, the account-wide ones are removed
+ALTER TABLE budgets ADD COLUMN category_id UUID REFERENCES categories(id) ON DELETE CASCADE;
+
+UPDATE budgets b SET category_id = (
+    SELECT bc.category_id FROM budget_categories bc WHERE bc.budget_id = b.id LIMIT 1
+);
+
+DELETE FROM budgets WHERE category_id IS NULL;
+ALTER TABLE budgets ALTER COLUMN category_id SET NOT NULL;
+
+DROP INDEX idx_budgets_user_dates;
+DROP TABLE budget_categories;
@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- Incomes are kept apart from the expenses, so the totals of the expenses and
+-- the budgets never count them. The category is optional
+CREATE TABLE incomes (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+
+    source VARCHAR(255) NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    base_amount NUMERIC(10, 2) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL,
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_incomes_user_received ON incomes (user_id, received_at, id);
+
+-- +goose Down
+
+DROP TABLE incomes;
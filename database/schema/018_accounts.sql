@@ -0,0 +1,61 @@
+-- +goose Up
+
+-- Accounts are where the money is kept, their balance is in their own
+-- currency and starts at the opening balance. Credit cards usually have a
+-- negative balance
+CREATE TABLE accounts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('checking', 'savings', 'credit_card', 'cash')),
+    currency CHAR(3) NOT NULL,
+    opening_balance NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    -- Date of the last statement the account was reconciled with
+    reconciled_at TIMESTAMPTZ,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    CONSTRAINT accounts_user_name UNIQUE (user_id, name)
+);
+
+-- Expenses and incomes in an account are in its currency. Cleared expenses
+-- were matched with a statement of the account
+ALTER TABLE expenses ADD COLUMN account_id UUID REFERENCES accounts(id) ON DELETE SET NULL;
+ALTER TABLE expenses ADD COLUMN cleared_at TIMESTAMPTZ;
+CREATE INDEX idx_expenses_account ON expenses (account_id, spent_at) WHERE account_id IS NOT NULL;
+
+ALTER TABLE incomes ADD COLUMN account_id UUID REFERENCES accounts(id) ON DELETE SET NULL;
+CREATE INDEX idx_incomes_account ON incomes (account_id, received_at) WHERE account_id IS NOT NULL;
+
+-- Transfers move money between accounts without being spending. The amount
+-- leaves the first account in its currency and to_amount arrives in the
+-- currency of the second one
+CREATE TABLE transfers (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+
+    from_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    amount NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    to_amount NUMERIC(12, 2) NOT NULL CHECK (to_amount > 0),
+    description TEXT NOT NULL DEFAULT '',
+    transferred_at TIMESTAMPTZ NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX idx_transfers_from ON transfers (from_account_id, transferred_at);
+CREATE INDEX idx_transfers_to ON transfers (to_account_id, transferred_at);
+CREATE INDEX idx_transfers_user ON transfers (user_id, transferred_at, id);
+
+-- +goose Down
+
+DROP TABLE transfers;
+
+ALTER TABLE incomes DROP COLUMN account_id;
+ALTER TABLE expenses DROP COLUMN cleared_at;
+ALTER TABLE expenses DROP COLUMN account_id;
+
+DROP TABLE accounts;
@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- An expense split across several categories counts each line against its own
+-- category instead of the one of the expense, which is kept as the category
+-- of the first line. The lines add up to the amount of the expense
+CREATE TABLE expense_splits (
+    id UUID PRIMARY KEY,
+
+    position INT NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL,
+    base_amount NUMERIC(10, 2) NOT NULL,
+    note TEXT,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    expense_id UUID NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+
+    CONSTRAINT expense_splits_expense_position UNIQUE (expense_id, position)
+);
+
+CREATE INDEX idx_expense_splits_category ON expense_splits (category_id);
+
+-- +goose Down
+
+DROP TABLE expense_splits;
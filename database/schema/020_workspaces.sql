@@ -0,0 +1,148 @@
+-- +goose Up
+
+-- Workspaces own the ledgers, with their categories, expenses, budgets and
+-- everything in them, and are shared with their members. Every user has a
+-- personal workspace with the ID of the user, the default one
+CREATE TABLE workspaces (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+
+    name VARCHAR(255) NOT NULL,
+    currency CHAR(3) NOT NULL, -- base currency of the budgets and reports
+    personal BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- Owners manage the workspace and its members, editors change the ledger and
+-- viewers only read it
+CREATE TABLE workspace_members (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX idx_workspace_members_user ON workspace_members (user_id);
+
+CREATE TABLE workspace_invitations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- hex encoded SHA-256 of the token
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_workspace_invitations_workspace ON workspace_invitations (workspace_id, created_at);
+
+INSERT INTO workspaces (id, created_at, updated_at, name, currency, personal)
+SELECT users.id, users.created_at, users.updated_at, users.name, users.currency, TRUE FROM users;
+
+INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+SELECT users.id, users.id, 'owner', users.created_at FROM users;
+
+-- The ledgers of the users become the ones of their personal workspaces
+ALTER TABLE categories RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE categories DROP CONSTRAINT categories_user_id_fkey;
+ALTER TABLE categories ADD CONSTRAINT categories_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE expenses RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE expenses DROP CONSTRAINT expenses_user_id_fkey;
+ALTER TABLE expenses ADD CONSTRAINT expenses_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE budgets RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE budgets DROP CONSTRAINT budgets_user_id_fkey;
+ALTER TABLE budgets ADD CONSTRAINT budgets_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE recurring_expenses RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE recurring_expenses DROP CONSTRAINT recurring_expenses_user_id_fkey;
+ALTER TABLE recurring_expenses ADD CONSTRAINT recurring_expenses_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE tags RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE tags DROP CONSTRAINT tags_user_id_fkey;
+ALTER TABLE tags ADD CONSTRAINT tags_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE budget_alerts RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE budget_alerts DROP CONSTRAINT budget_alerts_user_id_fkey;
+ALTER TABLE budget_alerts ADD CONSTRAINT budget_alerts_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE incomes RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE incomes DROP CONSTRAINT incomes_user_id_fkey;
+ALTER TABLE incomes ADD CONSTRAINT incomes_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE accounts RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE accounts DROP CONSTRAINT accounts_user_id_fkey;
+ALTER TABLE accounts ADD CONSTRAINT accounts_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+ALTER TABLE transfers RENAME COLUMN user_id TO workspace_id;
+ALTER TABLE transfers DROP CONSTRAINT transfers_user_id_fkey;
+ALTER TABLE transfers ADD CONSTRAINT transfers_workspace_id_fkey
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
+-- +goose Down
+
+-- Only the personal ledgers go back to their users
+DELETE FROM workspaces WHERE NOT personal;
+
+ALTER TABLE categories DROP CONSTRAINT categories_workspace_id_fkey;
+ALTER TABLE categories RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE categories ADD CONSTRAINT categories_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE expenses DROP CONSTRAINT expenses_workspace_id_fkey;
+ALTER TABLE expenses RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE expenses ADD CONSTRAINT expenses_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE budgets DROP CONSTRAINT budgets_workspace_id_fkey;
+ALTER TABLE budgets RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE budgets ADD CONSTRAINT budgets_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE recurring_expenses DROP CONSTRAINT recurring_expenses_workspace_id_fkey;
+ALTER TABLE recurring_expenses RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE recurring_expenses ADD CONSTRAINT recurring_expenses_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE tags DROP CONSTRAINT tags_workspace_id_fkey;
+ALTER TABLE tags RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE tags ADD CONSTRAINT tags_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE budget_alerts DROP CONSTRAINT budget_alerts_workspace_id_fkey;
+ALTER TABLE budget_alerts RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE budget_alerts ADD CONSTRAINT budget_alerts_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE incomes DROP CONSTRAINT incomes_workspace_id_fkey;
+ALTER TABLE incomes RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE incomes ADD CONSTRAINT incomes_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE accounts DROP CONSTRAINT accounts_workspace_id_fkey;
+ALTER TABLE accounts RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE accounts ADD CONSTRAINT accounts_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE transfers DROP CONSTRAINT transfers_workspace_id_fkey;
+ALTER TABLE transfers RENAME COLUMN workspace_id TO user_id;
+ALTER TABLE transfers ADD CONSTRAINT transfers_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+DROP TABLE workspace_invitations;
+DROP TABLE workspace_members;
+DROP TABLE workspaces;
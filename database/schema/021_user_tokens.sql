@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- Users verify their email with a token sent to it, the ones registered
+-- before are not verified until they ask for a new token
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- Single use tokens sent by email to verify it or reset the password
+CREATE TABLE user_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    kind VARCHAR(32) NOT NULL CHECK (kind IN ('email_verification', 'password_reset')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- hex encoded SHA-256 of the token
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_tokens_user ON user_tokens (user_id, kind);
+
+-- +goose Down
+
+DROP TABLE user_tokens;
+
+ALTER TABLE users DROP COLUMN email_verified_at;
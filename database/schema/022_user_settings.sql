@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Settings of the user for the date boundaries of the budgets and reports.
+-- Weeks start on week_start (0 is Sunday) and months on month_start_day, for
+-- people paid late in the month, which is at most 28 so every month has it
+ALTER TABLE users ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC'; -- IANA name
+ALTER TABLE users ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT 'en-US'; -- BCP 47 tag
+ALTER TABLE users ADD COLUMN week_start SMALLINT NOT NULL DEFAULT 1 CHECK (week_start BETWEEN 0 AND 6);
+ALTER TABLE users ADD COLUMN month_start_day SMALLINT NOT NULL DEFAULT 1 CHECK (month_start_day BETWEEN 1 AND 28);
+
+-- Periods of a recurring budget start at midnight in the time zone of the
+-- user who created it, whatever the time zone of the server
+ALTER TABLE budgets ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- +goose Down
+
+ALTER TABLE budgets DROP COLUMN time_zone;
+
+ALTER TABLE users DROP COLUMN month_start_day;
+ALTER TABLE users DROP COLUMN week_start;
+ALTER TABLE users DROP COLUMN locale;
+ALTER TABLE users DROP COLUMN time_zone;
@@ -0,0 +1,43 @@
+-- +goose Up
+
+-- TOTP (RFC 6238) two-factor authentication. The secret is kept while the
+-- enrollment is confirmed with a first code, and a code is only accepted
+-- once since the time step of the last one is kept
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64); -- base32 encoded
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Single use codes to sign in without the authenticator
+CREATE TABLE mfa_recovery_codes (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    code_hash VARCHAR(64) NOT NULL, -- hex encoded SHA-256 of the code
+    used_at TIMESTAMPTZ,
+
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, code_hash)
+);
+
+-- Logins waiting for the second factor, exchanged for the session tokens
+-- with a code
+CREATE TABLE mfa_challenges (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- hex encoded SHA-256 of the token
+    device VARCHAR(255) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_mfa_challenges_expires_at ON mfa_challenges (expires_at);
+
+-- +goose Down
+
+DROP TABLE mfa_challenges;
+DROP TABLE mfa_recovery_codes;
+
+ALTER TABLE users DROP COLUMN totp_last_step;
+ALTER TABLE users DROP COLUMN totp_enabled_at;
+ALTER TABLE users DROP COLUMN totp_secret;
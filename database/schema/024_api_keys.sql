@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Keys for scripts and integrations to use the API without the password of
+-- the user, limited to the scopes they were given
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE, -- shown to tell the keys apart
+    secret_hash VARCHAR(64) NOT NULL, -- hex encoded SHA-256 of the secret
+    scopes TEXT[] NOT NULL, -- like expenses:read or budgets:write
+    expires_at TIMESTAMPTZ, -- NULL when it never expires
+    last_used_at TIMESTAMPTZ,
+
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_api_keys_user ON api_keys (user_id, created_at);
+
+-- +goose Down
+
+DROP TABLE api_keys;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Wrong passwords and codes given to disable two-factor authentication or
+-- regenerate the recovery codes. Too many lock the user out of both for a
+-- while, so the codes can not be guessed with an access token
+ALTER TABLE users ADD COLUMN mfa_failed_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN mfa_locked_until TIMESTAMPTZ;
+
+-- +goose Down
+
+ALTER TABLE users DROP COLUMN mfa_locked_until;
+ALTER TABLE users DROP COLUMN mfa_failed_attempts;
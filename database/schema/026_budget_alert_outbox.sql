@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Alerts are sent by the scheduler rather than by the request that fired
+-- them, so slow email and webhook deliveries do not hold the request. The
+-- ones already fired were sent then.
+ALTER TABLE budget_alerts ADD COLUMN notified_at TIMESTAMPTZ;
+UPDATE budget_alerts SET notified_at = created_at;
+
+CREATE INDEX idx_budget_alerts_pending ON budget_alerts (created_at, id) WHERE notified_at IS NULL;
+
+-- +goose Down
+
+DROP INDEX idx_budget_alerts_pending;
+ALTER TABLE budget_alerts DROP COLUMN notified_at;
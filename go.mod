@@ -12,10 +12,10 @@ require (
 require (
 	github.com/jackc/pgx/v5 v5.7.2
 	golang.org/x/crypto v0.31.0
+	golang.org/x/text v0.21.0
 )
 
 require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
-	golang.org/x/text v0.21.0 // indirect
 )
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/jamcunha/expense-tracker/internal/mailer"
 	"github.com/jamcunha/expense-tracker/internal/middleware"
+	"github.com/jamcunha/expense-tracker/internal/notify"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 
 	"github.com/jackc/pgx/v5"
@@ -36,12 +38,48 @@ func New(config Config) (*App, error) {
 	return app, nil
 }
 
+// newNotifier returns the notifier of the budget alerts. The inbox writes
+// with queries, so it must be bound to the connection of the caller.
+func (a *App) newNotifier(queries *repository.Queries) notify.Notifier {
+	notifier := notify.Multi{&notify.Inbox{Queries: queries}}
+
+	if a.config.SMTPAddr != "" || a.config.MailOutbox != "" {
+		notifier = append(notifier, &notify.Email{Mailer: a.newMailer()})
+	}
+
+	if a.config.WebhookURL != "" {
+		notifier = append(notifier, &notify.Webhook{
+			URL:    a.config.WebhookURL,
+			Secret: a.config.WebhookSecret,
+		})
+	}
+
+	return notifier
+}
+
+// newMailer returns the SMTP mailer when it is configured, or the outbox
+// for local development otherwise.
+func (a *App) newMailer() mailer.Mailer {
+	if a.config.SMTPAddr == "" {
+		return &mailer.Outbox{Dir: a.config.MailOutbox, From: a.config.SMTPFrom}
+	}
+
+	return &mailer.SMTP{
+		Addr:     a.config.SMTPAddr,
+		Username: a.config.SMTPUsername,
+		Password: a.config.SMTPPassword,
+		From:     a.config.SMTPFrom,
+	}
+}
+
 func (a *App) Start(ctx context.Context) error {
 	server := http.Server{
 		Addr:    ":" + a.config.ServerPort,
 		Handler: middleware.Logging(a.router),
 	}
 
+	go a.runScheduler(ctx)
+
 	ch := make(chan error, 1)
 
 	go func() {
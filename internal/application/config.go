@@ -15,11 +15,29 @@ type Config struct {
 	JWTRefreshSecret string
 	JWTAccessExp     time.Duration
 	JWTRefreshExp    time.Duration
+
+	// How often the recurring expenses are checked for due occurrences
+	SchedulerInterval time.Duration
+
+	// Token of the admin routes, they are disabled when it is empty
+	AdminToken string
+
+	// Emails are sent through SMTPAddr (host:port) when it is set, otherwise
+	// they are written to the MailOutbox directory, or printed when it is
+	// empty. Budget alerts are only emailed with either of them set and are
+	// posted to WebhookURL when it is set, besides the in-app inbox
+	SMTPAddr      string
+	SMTPUsername  string
+	SMTPPassword  string
+	SMTPFrom      string
+	MailOutbox    string
+	WebhookURL    string
+	WebhookSecret string
 }
 
 func LoadConfig() (Config, error) {
 	// Preload default values
-	cfg := Config{ServerPort: "8080"}
+	cfg := Config{ServerPort: "8080", SchedulerInterval: 15 * time.Minute}
 
 	if port, exists := os.LookupEnv("PORT"); exists {
 		cfg.ServerPort = port
@@ -60,6 +78,41 @@ func LoadConfig() (Config, error) {
 	cfg.JWTAccessExp = accessExpDuration
 	cfg.JWTRefreshExp = refreshExpDuration
 
+	if interval, exists := os.LookupEnv("SCHEDULER_INTERVAL"); exists {
+		intervalDuration, err := time.ParseDuration(interval + "m")
+		if err != nil {
+			return Config{}, fmt.Errorf("Failed to parse SCHEDULER_INTERVAL: %w", err)
+		}
+
+		cfg.SchedulerInterval = intervalDuration
+	}
+
+	if token, exists := os.LookupEnv("ADMIN_TOKEN"); exists {
+		cfg.AdminToken = token
+	}
+
+	if addr, exists := os.LookupEnv("SMTP_ADDR"); exists {
+		cfg.SMTPAddr = addr
+
+		from, exists := os.LookupEnv("SMTP_FROM")
+		if !exists {
+			return Config{}, fmt.Errorf("Environment variable SMTP_FROM must be set when SMTP_ADDR is")
+		}
+
+		cfg.SMTPFrom = from
+		cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+		cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	}
+
+	if dir, exists := os.LookupEnv("MAIL_OUTBOX"); exists {
+		cfg.MailOutbox = dir
+	}
+
+	if url, exists := os.LookupEnv("WEBHOOK_URL"); exists {
+		cfg.WebhookURL = url
+		cfg.WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+	}
+
 	// For now it's required since it's the only database supported
 	// but this config gives the option to add more databases
 	if dbUrl, exists := os.LookupEnv("DB_URL"); exists {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/jamcunha/expense-tracker/internal/handler"
 	"github.com/jamcunha/expense-tracker/internal/middleware"
+	"github.com/jamcunha/expense-tracker/internal/service"
 )
 
 func (a *App) loadRoutes(prefix string) {
@@ -21,22 +22,65 @@ func (a *App) loadRoutes(prefix string) {
 
 	a.loadUserRoutes(r, "/users")
 	a.loadTokenRoutes(r, "/token")
+	a.loadWorkspaceRoutes(r, "/workspaces")
 	a.loadCategoryRoutes(r, "/categories")
 	a.loadExpenseRoutes(r, "/expenses")
+	a.loadIncomeRoutes(r, "/incomes")
+	a.loadAccountRoutes(r, "/accounts")
+	a.loadTransferRoutes(r, "/transfers")
+	a.loadTagRoutes(r, "/tags")
 	a.loadBudgetRoutes(r, "/budgets")
+	a.loadNotificationRoutes(r, "/notifications")
+	a.loadRecurringExpenseRoutes(r, "/recurring-expenses")
+	a.loadReportRoutes(r, "/reports")
+	a.loadImportRoutes(r, "/imports")
+	a.loadExportRoutes(r, "/exports")
+
+	if a.config.AdminToken != "" {
+		a.loadAdminRoutes(r, "/admin")
+	}
 
 	a.router.Handle(prefix+"/", http.StripPrefix(prefix, r))
 }
 
 func (a *App) loadUserRoutes(r *http.ServeMux, prefix string) {
-	userHandler := handler.NewUser(a.DB, a.Queries)
+	userHandler := handler.NewUser(a.DB, a.Queries, a.newMailer(), handler.JWTParams{
+		JWTAccessSecret:  a.config.JWTAccessSecret,
+		JWTRefreshSecret: a.config.JWTRefreshSecret,
+		JWTAccessExp:     a.config.JWTAccessExp,
+		JWTRefreshExp:    a.config.JWTRefreshExp,
+	})
+	sessionHandler := handler.NewSession(a.DB, a.Queries)
+	mfaHandler := handler.NewMFA(a.DB, a.Queries)
+	apiKeyHandler := handler.NewAPIKey(a.DB, a.Queries)
 	jwtMiddleware := func(f http.HandlerFunc) http.Handler { return middleware.JWTAuth(f, a.config.JWTAccessSecret) }
 
-	// NOTE: to restore password, add a route that requests the email and sends a token to the user
-	// and another route that receives the token and the new password
 	r.Handle("GET "+prefix+"/{id}", jwtMiddleware(userHandler.GetByID))
 	r.HandleFunc("POST "+prefix, userHandler.Create)
 	r.Handle("DELETE "+prefix+"/{id}", jwtMiddleware(userHandler.DeleteByID))
+	r.Handle("PATCH "+prefix+"/me", jwtMiddleware(userHandler.UpdateMe))
+	r.Handle("POST "+prefix+"/me/password", jwtMiddleware(userHandler.ChangePassword))
+	r.Handle("GET "+prefix+"/me/settings", jwtMiddleware(userHandler.Settings))
+	r.Handle("PATCH "+prefix+"/me/settings", jwtMiddleware(userHandler.UpdateSettings))
+
+	r.HandleFunc("POST "+prefix+"/password/forgot", userHandler.ForgotPassword)
+	r.HandleFunc("POST "+prefix+"/password/reset", userHandler.ResetPassword)
+	r.HandleFunc("POST "+prefix+"/verify-email", userHandler.VerifyEmail)
+	r.Handle("POST "+prefix+"/me/verify-email/resend", jwtMiddleware(userHandler.ResendVerification))
+
+	r.Handle("GET "+prefix+"/me/mfa", jwtMiddleware(mfaHandler.Status))
+	r.Handle("POST "+prefix+"/me/mfa/totp", jwtMiddleware(mfaHandler.EnrollTOTP))
+	r.Handle("POST "+prefix+"/me/mfa/totp/confirm", jwtMiddleware(mfaHandler.ConfirmTOTP))
+	r.Handle("DELETE "+prefix+"/me/mfa/totp", jwtMiddleware(mfaHandler.DisableTOTP))
+	r.Handle("POST "+prefix+"/me/mfa/recovery-codes", jwtMiddleware(mfaHandler.RegenerateRecoveryCodes))
+
+	r.Handle("GET "+prefix+"/me/sessions", jwtMiddleware(sessionHandler.GetAll))
+	r.Handle("DELETE "+prefix+"/me/sessions/{id}", jwtMiddleware(sessionHandler.DeleteByID))
+
+	// API keys only reach the routes with a scope, so they can not make more
+	r.Handle("GET "+prefix+"/me/api-keys", jwtMiddleware(apiKeyHandler.GetAll))
+	r.Handle("POST "+prefix+"/me/api-keys", jwtMiddleware(apiKeyHandler.Create))
+	r.Handle("DELETE "+prefix+"/me/api-keys/{id}", jwtMiddleware(apiKeyHandler.DeleteByID))
 }
 
 func (a *App) loadTokenRoutes(r *http.ServeMux, prefix string) {
@@ -46,40 +90,191 @@ func (a *App) loadTokenRoutes(r *http.ServeMux, prefix string) {
 		JWTAccessExp:     a.config.JWTAccessExp,
 		JWTRefreshExp:    a.config.JWTRefreshExp,
 	})
+	jwtMiddleware := func(f http.HandlerFunc) http.Handler { return middleware.JWTAuth(f, a.config.JWTAccessSecret) }
 
 	r.HandleFunc("POST "+prefix, tokenHandler.Create)
+	r.HandleFunc("POST "+prefix+"/mfa", tokenHandler.CompleteMFA)
 	r.HandleFunc("POST "+prefix+"/refresh", tokenHandler.Refresh)
+	r.HandleFunc("POST "+prefix+"/revoke", tokenHandler.Revoke)
+	r.Handle("POST "+prefix+"/logout-all", jwtMiddleware(tokenHandler.LogoutAll))
+}
+
+// authMiddleware authenticates with a JWT or an API key with the scope of
+// the route.
+func (a *App) authMiddleware() func(scope string, f http.Handler) http.Handler {
+	apiKeys := &service.APIKey{DB: a.DB, Queries: a.Queries}
+
+	return func(scope string, f http.Handler) http.Handler {
+		return middleware.Auth(f, a.config.JWTAccessSecret, apiKeys, scope)
+	}
+}
+
+// workspaceMiddleware authenticates the ledger routes and selects the
+// workspace of the request, checking the role of the user in it, and the
+// calendar its dates are in.
+func (a *App) workspaceMiddleware() func(scope string, f http.HandlerFunc) http.Handler {
+	workspaces := &service.Workspace{DB: a.DB, Queries: a.Queries}
+	users := &service.User{DB: a.DB, Queries: a.Queries}
+	authMiddleware := a.authMiddleware()
+
+	return func(scope string, f http.HandlerFunc) http.Handler {
+		return authMiddleware(scope, middleware.Workspace(middleware.Calendar(f, users), workspaces))
+	}
+}
+
+func (a *App) loadWorkspaceRoutes(r *http.ServeMux, prefix string) {
+	workspaceHandler := handler.NewWorkspace(a.DB, a.Queries, a.newMailer())
+	jwtMiddleware := func(f http.HandlerFunc) http.Handler { return middleware.JWTAuth(f, a.config.JWTAccessSecret) }
+
+	r.Handle("GET "+prefix, jwtMiddleware(workspaceHandler.GetAll))
+	r.Handle("GET "+prefix+"/{id}", jwtMiddleware(workspaceHandler.GetByID))
+	r.Handle("POST "+prefix, jwtMiddleware(workspaceHandler.Create))
+	r.Handle("PUT "+prefix+"/{id}", jwtMiddleware(workspaceHandler.Update))
+	r.Handle("DELETE "+prefix+"/{id}", jwtMiddleware(workspaceHandler.DeleteByID))
+
+	r.Handle("GET "+prefix+"/{id}/members", jwtMiddleware(workspaceHandler.Members))
+	r.Handle("PUT "+prefix+"/{id}/members/{user_id}", jwtMiddleware(workspaceHandler.UpdateMember))
+	r.Handle("DELETE "+prefix+"/{id}/members/{user_id}", jwtMiddleware(workspaceHandler.RemoveMember))
+
+	r.Handle("GET "+prefix+"/{id}/invitations", jwtMiddleware(workspaceHandler.Invitations))
+	r.Handle("POST "+prefix+"/{id}/invitations", jwtMiddleware(workspaceHandler.Invite))
+	r.Handle("DELETE "+prefix+"/{id}/invitations/{invitation_id}", jwtMiddleware(workspaceHandler.DeleteInvitation))
+	r.Handle("POST "+prefix+"/invitations/accept", jwtMiddleware(workspaceHandler.AcceptInvitation))
 }
 
 func (a *App) loadCategoryRoutes(r *http.ServeMux, prefix string) {
 	categoryHandler := handler.NewCategory(a.DB, a.Queries)
-	jwtMiddleware := func(f http.HandlerFunc) http.Handler { return middleware.JWTAuth(f, a.config.JWTAccessSecret) }
+	workspaceMiddleware := a.workspaceMiddleware()
 
-	r.Handle("GET "+prefix, jwtMiddleware(categoryHandler.GetAll))
-	r.Handle("GET "+prefix+"/{id}", jwtMiddleware(categoryHandler.GetByID))
-	r.Handle("POST "+prefix, jwtMiddleware(categoryHandler.Create))
-	r.Handle("PUT "+prefix+"/{id}", jwtMiddleware(categoryHandler.Update))
-	r.Handle("DELETE "+prefix+"/{id}", jwtMiddleware(categoryHandler.DeleteByID))
+	r.Handle("GET "+prefix, workspaceMiddleware("categories:read", categoryHandler.GetAll))
+	r.Handle("GET "+prefix+"/{id}", workspaceMiddleware("categories:read", categoryHandler.GetByID))
+	r.Handle("POST "+prefix, workspaceMiddleware("categories:write", categoryHandler.Create))
+	r.Handle("PUT "+prefix+"/{id}", workspaceMiddleware("categories:write", categoryHandler.Update))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("categories:write", categoryHandler.DeleteByID))
 }
 
 func (a *App) loadExpenseRoutes(r *http.ServeMux, prefix string) {
 	expenseHandler := handler.NewExpense(a.DB, a.Queries)
-	jwtMiddleware := func(f http.HandlerFunc) http.Handler { return middleware.JWTAuth(f, a.config.JWTAccessSecret) }
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix, workspaceMiddleware("expenses:read", expenseHandler.GetAll))
+	r.Handle("GET "+prefix+"/search", workspaceMiddleware("expenses:read", expenseHandler.Search))
+	r.Handle("GET "+prefix+"/{id}", workspaceMiddleware("expenses:read", expenseHandler.GetByID))
+	r.Handle("GET "+prefix+"/category/{id}", workspaceMiddleware("expenses:read", expenseHandler.GetByCategory))
+	r.Handle("POST "+prefix, workspaceMiddleware("expenses:write", expenseHandler.Create))
+	r.Handle("PUT "+prefix+"/{id}", workspaceMiddleware("expenses:write", expenseHandler.Update))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("expenses:write", expenseHandler.DeleteByID))
+}
+
+func (a *App) loadIncomeRoutes(r *http.ServeMux, prefix string) {
+	incomeHandler := handler.NewIncome(a.DB, a.Queries)
+	workspaceMiddleware := a.workspaceMiddleware()
 
-	r.Handle("GET "+prefix, jwtMiddleware(expenseHandler.GetAll))
-	r.Handle("GET "+prefix+"/{id}", jwtMiddleware(expenseHandler.GetByID))
-	r.Handle("GET "+prefix+"/category/{id}", jwtMiddleware(expenseHandler.GetByCategory))
-	r.Handle("POST "+prefix, jwtMiddleware(expenseHandler.Create))
-	r.Handle("PUT "+prefix+"/{id}", jwtMiddleware(expenseHandler.Update))
-	r.Handle("DELETE "+prefix+"/{id}", jwtMiddleware(expenseHandler.DeleteByID))
+	r.Handle("GET "+prefix, workspaceMiddleware("incomes:read", incomeHandler.GetAll))
+	r.Handle("GET "+prefix+"/{id}", workspaceMiddleware("incomes:read", incomeHandler.GetByID))
+	r.Handle("POST "+prefix, workspaceMiddleware("incomes:write", incomeHandler.Create))
+	r.Handle("PUT "+prefix+"/{id}", workspaceMiddleware("incomes:write", incomeHandler.Update))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("incomes:write", incomeHandler.DeleteByID))
+}
+
+func (a *App) loadAccountRoutes(r *http.ServeMux, prefix string) {
+	accountHandler := handler.NewAccount(a.DB, a.Queries)
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix, workspaceMiddleware("accounts:read", accountHandler.GetAll))
+	r.Handle("GET "+prefix+"/{id}", workspaceMiddleware("accounts:read", accountHandler.GetByID))
+	r.Handle("GET "+prefix+"/{id}/balances", workspaceMiddleware("accounts:read", accountHandler.Balances))
+	r.Handle("POST "+prefix, workspaceMiddleware("accounts:write", accountHandler.Create))
+	r.Handle("POST "+prefix+"/{id}/reconcile", workspaceMiddleware("accounts:write", accountHandler.Reconcile))
+	r.Handle("PUT "+prefix+"/{id}", workspaceMiddleware("accounts:write", accountHandler.Update))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("accounts:write", accountHandler.DeleteByID))
+}
+
+func (a *App) loadTransferRoutes(r *http.ServeMux, prefix string) {
+	transferHandler := handler.NewTransfer(a.DB, a.Queries)
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix, workspaceMiddleware("transfers:read", transferHandler.GetAll))
+	r.Handle("GET "+prefix+"/{id}", workspaceMiddleware("transfers:read", transferHandler.GetByID))
+	r.Handle("POST "+prefix, workspaceMiddleware("transfers:write", transferHandler.Create))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("transfers:write", transferHandler.DeleteByID))
+}
+
+func (a *App) loadTagRoutes(r *http.ServeMux, prefix string) {
+	tagHandler := handler.NewTag(a.DB, a.Queries)
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix, workspaceMiddleware("tags:read", tagHandler.GetAll))
+	r.Handle("POST "+prefix, workspaceMiddleware("tags:write", tagHandler.Create))
+	r.Handle("PUT "+prefix+"/{id}", workspaceMiddleware("tags:write", tagHandler.Update))
+	r.Handle("POST "+prefix+"/{id}/merge", workspaceMiddleware("tags:write", tagHandler.Merge))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("tags:write", tagHandler.DeleteByID))
 }
 
 func (a *App) loadBudgetRoutes(r *http.ServeMux, prefix string) {
 	budgetHandler := handler.NewBudget(a.DB, a.Queries)
-	jwtMiddleware := func(f http.HandlerFunc) http.Handler { return middleware.JWTAuth(f, a.config.JWTAccessSecret) }
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix, workspaceMiddleware("budgets:read", budgetHandler.GetAll))
+	r.Handle("GET "+prefix+"/{id}", workspaceMiddleware("budgets:read", budgetHandler.GetByID))
+	r.Handle("GET "+prefix+"/{id}/periods", workspaceMiddleware("budgets:read", budgetHandler.GetPeriods))
+	r.Handle("GET "+prefix+"/{id}/alerts", workspaceMiddleware("budgets:read", budgetHandler.GetAlerts))
+	r.Handle("GET "+prefix+"/{id}/forecast", workspaceMiddleware("budgets:read", budgetHandler.Forecast))
+	r.Handle("POST "+prefix, workspaceMiddleware("budgets:write", budgetHandler.Create))
+	r.Handle("PUT "+prefix+"/{id}", workspaceMiddleware("budgets:write", budgetHandler.Update))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("budgets:write", budgetHandler.DeleteByID))
+}
+
+func (a *App) loadNotificationRoutes(r *http.ServeMux, prefix string) {
+	notificationHandler := handler.NewNotification(a.DB, a.Queries)
+	authMiddleware := a.authMiddleware()
+
+	r.Handle("GET "+prefix, authMiddleware("notifications:read", http.HandlerFunc(notificationHandler.GetAll)))
+	r.Handle("POST "+prefix+"/{id}/read", authMiddleware("notifications:write", http.HandlerFunc(notificationHandler.MarkRead)))
+}
+
+func (a *App) loadRecurringExpenseRoutes(r *http.ServeMux, prefix string) {
+	recurringExpenseHandler := handler.NewRecurringExpense(a.DB, a.Queries)
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix, workspaceMiddleware("recurring_expenses:read", recurringExpenseHandler.GetAll))
+	r.Handle("GET "+prefix+"/{id}", workspaceMiddleware("recurring_expenses:read", recurringExpenseHandler.GetByID))
+	r.Handle("GET "+prefix+"/{id}/preview", workspaceMiddleware("recurring_expenses:read", recurringExpenseHandler.Preview))
+	r.Handle("POST "+prefix, workspaceMiddleware("recurring_expenses:write", recurringExpenseHandler.Create))
+	r.Handle("PUT "+prefix+"/{id}", workspaceMiddleware("recurring_expenses:write", recurringExpenseHandler.Update))
+	r.Handle("DELETE "+prefix+"/{id}", workspaceMiddleware("recurring_expenses:write", recurringExpenseHandler.DeleteByID))
+}
+
+func (a *App) loadReportRoutes(r *http.ServeMux, prefix string) {
+	reportHandler := handler.NewReport(a.DB, a.Queries)
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix+"/categories", workspaceMiddleware("reports:read", reportHandler.CategoryTotals))
+	r.Handle("GET "+prefix+"/tags", workspaceMiddleware("reports:read", reportHandler.TagTotals))
+	r.Handle("GET "+prefix+"/timeseries", workspaceMiddleware("reports:read", reportHandler.TimeSeries))
+	r.Handle("GET "+prefix+"/compare", workspaceMiddleware("reports:read", reportHandler.Compare))
+	r.Handle("GET "+prefix+"/cashflow", workspaceMiddleware("reports:read", reportHandler.CashFlow))
+	r.Handle("GET "+prefix+"/income-vs-expenses", workspaceMiddleware("reports:read", reportHandler.IncomeVsExpenses))
+}
+
+func (a *App) loadImportRoutes(r *http.ServeMux, prefix string) {
+	importHandler := handler.NewImport(a.DB, a.Queries)
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("POST "+prefix+"/csv", workspaceMiddleware("imports:write", importHandler.CSV))
+}
+
+func (a *App) loadExportRoutes(r *http.ServeMux, prefix string) {
+	exportHandler := handler.NewExport(a.config.PostgresUrl)
+	workspaceMiddleware := a.workspaceMiddleware()
+
+	r.Handle("GET "+prefix+"/expenses", workspaceMiddleware("exports:read", exportHandler.Expenses))
+	r.Handle("GET "+prefix+"/all", workspaceMiddleware("exports:read", exportHandler.All))
+}
+
+func (a *App) loadAdminRoutes(r *http.ServeMux, prefix string) {
+	exchangeRateHandler := handler.NewExchangeRate(a.DB, a.Queries)
+	adminMiddleware := func(f http.HandlerFunc) http.Handler { return middleware.AdminAuth(f, a.config.AdminToken) }
 
-	r.Handle("GET "+prefix, jwtMiddleware(budgetHandler.GetAll))
-	r.Handle("GET "+prefix+"/{id}", jwtMiddleware(budgetHandler.GetByID))
-	r.Handle("POST "+prefix, jwtMiddleware(budgetHandler.Create))
-	r.Handle("DELETE "+prefix+"/{id}", jwtMiddleware(budgetHandler.DeleteByID))
+	r.Handle("POST "+prefix+"/exchange-rates", adminMiddleware(exchangeRateHandler.Load))
 }
@@ -0,0 +1,98 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// runScheduler adds the due recurring expenses, starts the next period of
+// the recurring budgets, sends the budget alerts fired since the last run and
+// removes the expired refresh and email tokens and MFA challenges every
+// SchedulerInterval until ctx is done. It uses its own connection since a
+// pgx.Conn is not safe to share with the request handlers.
+func (a *App) runScheduler(ctx context.Context) {
+	conn, err := pgx.Connect(ctx, a.config.PostgresUrl)
+	if err != nil {
+		fmt.Println("failed to start scheduler:", err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	queries := repository.New(conn)
+
+	recurringExpenses := service.RecurringExpense{
+		DB:      conn,
+		Queries: queries,
+	}
+
+	budgets := service.Budget{
+		DB:       conn,
+		Queries:  queries,
+		Notifier: a.newNotifier(queries),
+	}
+
+	sessions := service.Session{
+		DB:      conn,
+		Queries: repository.New(conn),
+	}
+
+	users := service.User{
+		DB:      conn,
+		Queries: repository.New(conn),
+	}
+
+	mfa := service.MFA{
+		DB:      conn,
+		Queries: repository.New(conn),
+	}
+
+	ticker := time.NewTicker(a.config.SchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		added, err := recurringExpenses.MaterializeDue(ctx, time.Now())
+		if err != nil {
+			fmt.Println("failed to add recurring expenses:", err)
+		} else if added > 0 {
+			fmt.Println("Added", added, "recurring expenses")
+		}
+
+		// After the recurring expenses, so they are in the amount of the
+		// periods being closed
+		started, err := budgets.RollDue(ctx, time.Now())
+		if err != nil {
+			fmt.Println("failed to roll budgets:", err)
+		} else if started > 0 {
+			fmt.Println("Started", started, "budget periods")
+		}
+
+		// Last, so the alerts fired by the expenses added above go out now
+		if _, err := budgets.NotifyAlerts(ctx, time.Now()); err != nil {
+			fmt.Println("failed to send budget alerts:", err)
+		}
+
+		if _, err := sessions.DeleteExpired(ctx, time.Now()); err != nil {
+			fmt.Println("failed to delete expired refresh tokens:", err)
+		}
+
+		if _, err := users.DeleteExpiredTokens(ctx, time.Now()); err != nil {
+			fmt.Println("failed to delete expired email tokens:", err)
+		}
+
+		if _, err := mfa.DeleteExpiredChallenges(ctx, time.Now()); err != nil {
+			fmt.Println("failed to delete expired MFA challenges:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"encoding/base64"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,3 +41,56 @@ func EncodeCursor(t time.Time, uuid uuid.UUID) string {
 		fmt.Sprintf("%s,%s", t.Format(time.RFC3339Nano), uuid.String()),
 	))
 }
+
+// Lists that can be sorted by different keys encode the active one with the
+// value of the last item, so a cursor is only valid for the same sort. The
+// value goes last, it may contain commas.
+
+func DecodeSortCursor(encodedCursor string) (string, string, uuid.UUID, error) {
+	byt, err := base64.StdEncoding.DecodeString(encodedCursor)
+	if err != nil {
+		return "", "", uuid.UUID{}, err
+	}
+
+	arrStr := strings.SplitN(string(byt), ",", 3)
+	if len(arrStr) != 3 {
+		return "", "", uuid.UUID{}, fmt.Errorf("invalid cursor")
+	}
+
+	id, err := uuid.Parse(arrStr[1])
+	if err != nil {
+		return "", "", uuid.UUID{}, err
+	}
+
+	return arrStr[0], arrStr[2], id, nil
+}
+
+func EncodeSortCursor(sort, value string, uuid uuid.UUID) string {
+	return base64.StdEncoding.EncodeToString([]byte(
+		fmt.Sprintf("%s,%s,%s", sort, uuid.String(), value),
+	))
+}
+
+// Search results are ordered by rank instead of a timestamp
+
+func DecodeRankCursor(encodedCursor string) (float32, uuid.UUID, error) {
+	sort, value, id, err := DecodeSortCursor(encodedCursor)
+	if err != nil {
+		return 0, uuid.UUID{}, err
+	}
+
+	if sort != "rank" {
+		return 0, uuid.UUID{}, fmt.Errorf("cursor is not sorted by rank")
+	}
+
+	rank, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return 0, uuid.UUID{}, err
+	}
+
+	return float32(rank), id, nil
+}
+
+func EncodeRankCursor(rank float32, uuid uuid.UUID) string {
+	return EncodeSortCursor("rank", strconv.FormatFloat(float64(rank), 'g', -1, 32), uuid)
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSortCursor(t *testing.T) {
+	id := uuid.MustParse("527fef18-e8f9-4899-b807-3c9c94415b31")
+
+	tests := []struct {
+		sort  string
+		value string
+	}{
+		{"amount", "45.30"},
+		{"description", "Groceries, fruit and bread"},
+		{"description", ""},
+		{"merchant", "Café ☕"},
+	}
+
+	for _, tt := range tests {
+		sort, value, gotID, err := DecodeSortCursor(EncodeSortCursor(tt.sort, tt.value, id))
+		if err != nil {
+			t.Errorf("DecodeSortCursor(EncodeSortCursor(%q, %q)) = %v", tt.sort, tt.value, err)
+			continue
+		}
+
+		if sort != tt.sort || value != tt.value || gotID != id {
+			t.Errorf("DecodeSortCursor(EncodeSortCursor(%q, %q)) = %q, %q, %s", tt.sort, tt.value, sort, value, gotID)
+		}
+	}
+
+	invalid := []string{
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("amount,45.30")),
+		base64.StdEncoding.EncodeToString([]byte("amount,not-a-uuid,45.30")),
+	}
+
+	for _, cursor := range invalid {
+		if _, _, _, err := DecodeSortCursor(cursor); err == nil {
+			t.Errorf("DecodeSortCursor(%q) = nil, want an error", cursor)
+		}
+	}
+}
+
+func TestRankCursor(t *testing.T) {
+	id := uuid.MustParse("527fef18-e8f9-4899-b807-3c9c94415b31")
+
+	for _, rank := range []float32{0, 0.0607927, 1, 1e-7, 123.456} {
+		got, gotID, err := DecodeRankCursor(EncodeRankCursor(rank, id))
+		if err != nil {
+			t.Errorf("DecodeRankCursor(EncodeRankCursor(%v)) = %v", rank, err)
+			continue
+		}
+
+		if got != rank || gotID != id {
+			t.Errorf("DecodeRankCursor(EncodeRankCursor(%v)) = %v, %s", rank, got, gotID)
+		}
+	}
+
+	invalid := []string{
+		EncodeSortCursor("amount", "0.5", id),
+		EncodeSortCursor("rank", "high", id),
+		"not base64!",
+	}
+
+	for _, cursor := range invalid {
+		if _, _, err := DecodeRankCursor(cursor); err == nil {
+			t.Errorf("DecodeRankCursor(%q) = nil, want an error", cursor)
+		}
+	}
+}
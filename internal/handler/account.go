@@ -0,0 +1,395 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+	"github.com/shopspring/decimal"
+)
+
+type Account struct {
+	service service.Account
+}
+
+func NewAccount(db *pgx.Conn, queries *repository.Queries) *Account {
+	return &Account{
+		service: service.Account{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *Account) GetAll(w http.ResponseWriter, r *http.Request) {
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	accounts, err := h.service.GetAll(r.Context(), workspaceID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Accounts []service.AccountWithBalance `json:"accounts"`
+	}{
+		Accounts: accounts,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Account) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	a, err := h.service.GetByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(a)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Account) Create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name           string  `json:"name"`
+		Type           string  `json:"type"`
+		Currency       string  `json:"currency,omitempty"`
+		OpeningBalance float64 `json:"opening_balance,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	a, err := h.service.Create(
+		r.Context(),
+		workspaceID,
+		body.Name,
+		body.Type,
+		body.Currency,
+		decimal.NewFromFloat(body.OpeningBalance),
+	)
+	if errors.Is(err, service.ErrInvalidAccount) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Account needs a name of up to 255 characters and a type: checking, savings, credit_card or cash"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountExists) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Account already exists"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(a)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	w.Write(res)
+}
+
+func (h *Account) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Name           string   `json:"name,omitempty"`
+		Type           string   `json:"type,omitempty"`
+		OpeningBalance *float64 `json:"opening_balance,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// A zero opening balance is a valid value, so it is only kept when missing
+	var openingBalance decimal.NullDecimal
+	if body.OpeningBalance != nil {
+		openingBalance = decimal.NewNullDecimal(decimal.NewFromFloat(*body.OpeningBalance))
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	a, err := h.service.Update(r.Context(), id, workspaceID, body.Name, body.Type, openingBalance)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidAccount) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Account needs a name of up to 255 characters and a type: checking, savings, credit_card or cash"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountExists) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Account already exists"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(a)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Account) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	a, err := h.service.DeleteByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(a)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+// Balances takes the same tz, from and to parameters as the reports.
+func (h *Account) Balances(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	from, to, err := reportRange(r, cal)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = service.PeriodDay
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	history, err := h.service.Balances(r.Context(), id, workspaceID, bucket, cal, from, to)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidPeriod) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid bucket. Use day, week, month or year"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		From   time.Time `json:"from"`
+		To     time.Time `json:"to"`
+		Bucket string    `json:"bucket"`
+		service.AccountHistory
+	}{
+		From:           from,
+		To:             to,
+		Bucket:         bucket,
+		AccountHistory: history,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Account) Reconcile(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		StatementDate    string   `json:"statement_date"`
+		StatementBalance *float64 `json:"statement_balance,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	statementDate, err := time.ParseInLocation(time.DateOnly, body.StatementDate, cal.Location)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid statement date. Use YYYY-MM-DD"}`))
+		return
+	}
+
+	// The statement includes every expense of its last day
+	statementDate = statementDate.AddDate(0, 0, 1).Add(-time.Microsecond)
+
+	var statementBalance decimal.NullDecimal
+	if body.StatementBalance != nil {
+		statementBalance = decimal.NewNullDecimal(decimal.NewFromFloat(*body.StatementBalance))
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	reconciliation, err := h.service.Reconcile(r.Context(), id, workspaceID, statementDate, statementBalance)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(reconciliation)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+type APIKey struct {
+	service service.APIKey
+}
+
+func NewAPIKey(db *pgx.Conn, queries *repository.Queries) *APIKey {
+	return &APIKey{
+		service: service.APIKey{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *APIKey) GetAll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	keys, err := h.service.GetAll(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(keys)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+// Create responds with the new API key, the only time the key is shown.
+func (h *APIKey) Create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if body.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *body.ExpiresAt, Valid: true}
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	key, err := h.service.Create(r.Context(), userID, body.Name, body.Scopes, expiresAt)
+	if errors.Is(err, service.ErrInvalidAPIKeyName) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "API key name must have between 1 and 255 characters"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidAPIKeyScope) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid scope. Use <resource>:read or <resource>:write, like expenses:read"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidAPIKeyExpiry) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "API key must expire in the future"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(key)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	w.Write(res)
+}
+
+func (h *APIKey) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	err = h.service.DeleteByID(r.Context(), id, userID)
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "API key does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
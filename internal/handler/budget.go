@@ -37,9 +37,9 @@ func (h *Budget) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	b, err := h.service.GetByID(r.Context(), id, userID)
+	b, err := h.service.GetByID(r.Context(), id, workspaceID)
 	if errors.Is(err, service.ErrBudgetNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -84,9 +84,9 @@ func (h *Budget) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	cur := r.URL.Query().Get("cursor")
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	budgets, err := h.service.GetAll(r.Context(), userID, limit, cur)
+	budgets, err := h.service.GetAll(r.Context(), workspaceID, limit, cur)
 	if errors.Is(err, service.ErrBudgetNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -99,8 +99,8 @@ func (h *Budget) GetAll(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var response struct {
-		Budgets []repository.Budget `json:"budgets"`
-		Next    string              `json:"next,omitempty"`
+		Budgets []service.CategorizedBudget `json:"budgets"`
+		Next    string                      `json:"next,omitempty"`
 	}
 
 	response.Budgets = budgets
@@ -126,10 +126,15 @@ func (h *Budget) GetAll(w http.ResponseWriter, r *http.Request) {
 
 func (h *Budget) Create(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		Goal       float64 `json:"goal"`
-		StartDate  string  `json:"start_date"`
-		EndDate    string  `json:"end_date"`
-		CategoryID string  `json:"category_id"`
+		Goal        float64  `json:"goal"`
+		StartDate   string   `json:"start_date,omitempty"`
+		EndDate     string   `json:"end_date,omitempty"`
+		CategoryID  string   `json:"category_id,omitempty"`
+		CategoryIDs []string `json:"category_ids,omitempty"`
+		Period      string   `json:"period,omitempty"`
+		Rollover    string   `json:"rollover,omitempty"`
+		RolloverCap float64  `json:"rollover_cap,omitempty"`
+		Thresholds  []int32  `json:"thresholds,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -137,8 +142,10 @@ func (h *Budget) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
-	categoryID, err := uuid.Parse(body.CategoryID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	// Without categories the budget is account-wide
+	categoryIDs, err := parseBudgetCategories(body.CategoryID, body.CategoryIDs)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -147,38 +154,220 @@ func (h *Budget) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startDate, err := time.Parse(time.DateOnly, body.StartDate)
-	if err != nil {
+	cal := r.Context().Value("calendar").(service.Calendar)
+
+	// Recurring budgets start with the current period by default
+	startDate := cal.BudgetPeriodStart(time.Now(), body.Period)
+	if body.StartDate != "" || body.Period == "" {
+		startDate, err = time.ParseInLocation(time.DateOnly, body.StartDate, cal.Location)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	// Recurring budgets end with their period by default
+	var endDate time.Time
+	if body.EndDate != "" || body.Period == "" {
+		endDate, err = time.ParseInLocation(time.DateOnly, body.EndDate, cal.Location)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	b, err := h.service.Create(
+		r.Context(),
+		workspaceID,
+		categoryIDs,
+		decimal.NewFromFloat(body.Goal),
+		startDate,
+		endDate,
+		service.BudgetRecurrence{
+			Period:      body.Period,
+			Rollover:    body.Rollover,
+			RolloverCap: decimal.NewFromFloat(body.RolloverCap),
+		},
+		body.Thresholds,
+	)
+	if errors.Is(err, service.ErrCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Category does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidBudgetPeriod) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 
-		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+		w.Write([]byte(`{"error": "Period must be weekly, monthly, quarterly or yearly"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidRollover) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Rollover must be none, surplus, deficit, both or capped with a positive rollover_cap"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidThresholds) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Thresholds must be between 1 and 1000 percent"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidBudgetDates) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	endDate, err := time.Parse(time.DateOnly, body.EndDate)
+	res, err := json.Marshal(b)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	w.Write(res)
+}
+
+func (h *Budget) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Goal        float64  `json:"goal,omitempty"`
+		StartDate   string   `json:"start_date,omitempty"`
+		EndDate     string   `json:"end_date,omitempty"`
+		CategoryID  string   `json:"category_id,omitempty"`
+		CategoryIDs []string `json:"category_ids,omitempty"`
+		Period      string   `json:"period,omitempty"`
+		Rollover    string   `json:"rollover,omitempty"`
+		RolloverCap float64  `json:"rollover_cap,omitempty"`
+		Thresholds  []int32  `json:"thresholds,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Empty values are kept as they are, an empty category_ids makes the
+	// budget account-wide
+	categoryIDs, err := parseBudgetCategories(body.CategoryID, body.CategoryIDs)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 
-		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+		w.Write([]byte(`{"error": "Invalid category ID"}`))
 		return
 	}
 
-	b, err := h.service.Create(
+	var startDate, endDate time.Time
+	if body.StartDate != "" {
+		startDate, err = time.ParseInLocation(time.DateOnly, body.StartDate, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	if body.EndDate != "" {
+		endDate, err = time.ParseInLocation(time.DateOnly, body.EndDate, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	b, err := h.service.Update(
 		r.Context(),
-		userID,
-		categoryID,
+		id,
+		workspaceID,
+		categoryIDs,
 		decimal.NewFromFloat(body.Goal),
 		startDate,
 		endDate,
+		service.BudgetRecurrence{
+			Period:      body.Period,
+			Rollover:    body.Rollover,
+			RolloverCap: decimal.NewFromFloat(body.RolloverCap),
+		},
+		body.Thresholds,
 	)
-	if errors.Is(err, service.ErrCategoryNotFound) {
+	if errors.Is(err, service.ErrBudgetNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Budget does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 
 		w.Write([]byte(`{"error": "Category does not exist"}`))
 		return
+	} else if errors.Is(err, service.ErrBudgetClosed) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Past budget periods can not be changed"}`))
+		return
+	} else if errors.Is(err, service.ErrBudgetPeriodExists) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Budget already has a period starting at that date"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidBudgetPeriod) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Period must be weekly, monthly, quarterly, yearly or none"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidRollover) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Rollover must be none, surplus, deficit, both or capped with a positive rollover_cap"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidThresholds) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Thresholds must be between 1 and 1000 percent"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidBudgetDates) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range"}`))
+		return
 	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -192,7 +381,120 @@ func (h *Budget) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Budget) GetPeriods(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	periods, err := h.service.GetPeriods(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrBudgetNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Budget does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Periods []service.CategorizedBudget `json:"periods"`
+	}{
+		Periods: periods,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Budget) Forecast(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	forecast, err := h.service.Forecast(r.Context(), id, workspaceID, time.Now())
+	if errors.Is(err, service.ErrBudgetNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Budget does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(forecast)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Budget) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	alerts, err := h.service.GetAlerts(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrBudgetNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Budget does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Alerts []repository.BudgetAlert `json:"alerts"`
+	}{
+		Alerts: alerts,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
 	w.Write(res)
 }
@@ -205,9 +507,9 @@ func (h *Budget) DeleteByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	b, err := h.service.DeleteByID(r.Context(), id, userID)
+	b, err := h.service.DeleteByID(r.Context(), id, workspaceID)
 	if errors.Is(err, service.ErrBudgetNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -231,3 +533,28 @@ func (h *Budget) DeleteByID(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(res)
 }
+
+// parseBudgetCategories returns the categories of a budget, from either
+// category_ids or category_id for a single one. It is nil when neither is
+// given, and empty when category_ids is.
+func parseBudgetCategories(categoryID string, categoryIDs []string) ([]uuid.UUID, error) {
+	if categoryID != "" {
+		categoryIDs = append(categoryIDs, categoryID)
+	}
+
+	if categoryIDs == nil {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(categoryIDs))
+	for i, categoryID := range categoryIDs {
+		id, err := uuid.Parse(categoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		ids[i] = id
+	}
+
+	return ids, nil
+}
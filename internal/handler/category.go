@@ -35,9 +35,9 @@ func (h *Category) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	c, err := h.service.GetByID(r.Context(), id, userID)
+	c, err := h.service.GetByID(r.Context(), id, workspaceID)
 	if errors.Is(err, service.ErrCategoryNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -62,6 +62,11 @@ func (h *Category) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Category) GetAll(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("view") == "tree" {
+		h.getTree(w, r)
+		return
+	}
+
 	// Default page limit
 	limit := int32(10)
 
@@ -70,7 +75,7 @@ func (h *Category) GetAll(w http.ResponseWriter, r *http.Request) {
 		const decimal = 10
 		const bitSize = 32
 		limitParsed, err := strconv.ParseInt(limitStr, decimal, bitSize)
-		if err != nil || limit < 1 {
+		if err != nil || limitParsed < 1 {
 			fmt.Println("Handler Error:", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -81,9 +86,9 @@ func (h *Category) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	cur := r.URL.Query().Get("cursor")
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	categories, err := h.service.GetAll(r.Context(), userID, limit, cur)
+	categories, err := h.service.GetAll(r.Context(), workspaceID, limit, cur)
 	if errors.Is(err, service.ErrCategoryNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -105,7 +110,7 @@ func (h *Category) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	if len(categories) == int(limit) {
 		lastCategory := categories[len(categories)-1]
-		cur = internal.EncodeCursor(lastCategory.CreatedAt, lastCategory.ID)
+		response.Next = internal.EncodeCursor(lastCategory.CreatedAt, lastCategory.ID)
 	}
 
 	res, err := json.Marshal(response)
@@ -121,9 +126,37 @@ func (h *Category) GetAll(w http.ResponseWriter, r *http.Request) {
 	w.Write(res)
 }
 
+// getTree responds with every category nested under its parent.
+func (h *Category) getTree(w http.ResponseWriter, r *http.Request) {
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	tree, err := h.service.GetTree(r.Context(), workspaceID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Categories []*service.CategoryNode `json:"categories"`
+	}{
+		Categories: tree,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
 func (h *Category) Create(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		Name string `json:"name"`
+		Name     string `json:"name"`
+		ParentID string `json:"parent_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -131,10 +164,29 @@ func (h *Category) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	parentID := uuid.Nil
+	if body.ParentID != "" {
+		var err error
+		parentID, err = uuid.Parse(body.ParentID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid parent category ID"}`))
+			return
+		}
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	c, err := h.service.Create(r.Context(), body.Name, userID)
-	if err != nil {
+	c, err := h.service.Create(r.Context(), body.Name, workspaceID, parentID)
+	if errors.Is(err, service.ErrParentCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Parent category does not exist"}`))
+		return
+	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -162,6 +214,8 @@ func (h *Category) Update(w http.ResponseWriter, r *http.Request) {
 
 	var body struct {
 		Name string `json:"name"`
+		// Missing keeps the parent, empty makes it a top level category
+		ParentID *string `json:"parent_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -170,15 +224,44 @@ func (h *Category) Update(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	var parentID *uuid.UUID
+	if body.ParentID != nil {
+		parsed := uuid.Nil
+		if *body.ParentID != "" {
+			parsed, err = uuid.Parse(*body.ParentID)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+
+				w.Write([]byte(`{"error": "Invalid parent category ID"}`))
+				return
+			}
+		}
 
-	c, err := h.service.Update(r.Context(), id, userID, body.Name)
+		parentID = &parsed
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	c, err := h.service.Update(r.Context(), id, workspaceID, body.Name, parentID)
 	if errors.Is(err, service.ErrCategoryNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 
 		w.Write([]byte(`{"error": "Category does not exist"}`))
 		return
+	} else if errors.Is(err, service.ErrParentCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Parent category does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryCycle) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Category can not be moved under itself or its subcategories"}`))
+		return
 	} else if err != nil {
 		fmt.Println("failed to update:", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -206,9 +289,9 @@ func (h *Category) DeleteByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	c, err := h.service.DeleteByID(r.Context(), id, userID)
+	c, err := h.service.DeleteByID(r.Context(), id, workspaceID)
 	if errors.Is(err, service.ErrCategoryNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
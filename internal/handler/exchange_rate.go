@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+// Largest exchange rates file accepted, in bytes
+const maxRatesSize = 32 << 20
+
+type ExchangeRate struct {
+	service service.ExchangeRate
+}
+
+func NewExchangeRate(db *pgx.Conn, queries *repository.Queries) *ExchangeRate {
+	return &ExchangeRate{
+		service: service.ExchangeRate{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *ExchangeRate) Load(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRatesSize)
+
+	if err := r.ParseMultipartForm(maxRatesSize); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Expected a multipart form of up to 32MB"}`))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Missing file"}`))
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue("format")
+	if format == "" {
+		format = service.RatesCSV
+	}
+
+	loaded, err := h.service.Load(r.Context(), file, format)
+	if errors.Is(err, service.ErrInvalidRatesFile) {
+		res, _ := json.Marshal(struct {
+			Error string `json:"error"`
+		}{
+			Error: err.Error(),
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write(res)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Loaded int `json:"loaded"`
+	}{
+		Loaded: loaded,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+// writeExchangeRateError responds with the currencies and day missing a rate,
+// which has to be loaded before the amount can be converted.
+func writeExchangeRateError(w http.ResponseWriter, err error) {
+	res, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+
+	w.Write(res)
+}
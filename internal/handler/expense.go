@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -36,9 +37,9 @@ func (h *Expense) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	e, err := h.service.GetByID(r.Context(), id, userID)
+	e, err := h.service.GetByID(r.Context(), id, workspaceID)
 	if errors.Is(err, service.ErrExpenseNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -64,15 +65,34 @@ func (h *Expense) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Expense) GetAll(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, nil)
+}
+
+func (h *Expense) GetByCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	h.list(w, r, []uuid.UUID{categoryID})
+}
+
+// list responds with the expenses matching every filter of the query, and
+// in the categories given, sorted by sort and order.
+func (h *Expense) list(w http.ResponseWriter, r *http.Request, categoryIDs []uuid.UUID) {
+	query := r.URL.Query()
+
 	// Default page limit
 	limit := int32(10)
 
-	limitStr := r.URL.Query().Get("limit")
+	limitStr := query.Get("limit")
 	if limitStr != "" {
 		const decimal = 10
 		const bitSize = 32
 		limitParsed, err := strconv.ParseInt(limitStr, decimal, bitSize)
-		if err != nil || limit < 1 {
+		if err != nil || limitParsed < 1 {
 			fmt.Println("Handler Error:", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -81,16 +101,151 @@ func (h *Expense) GetAll(w http.ResponseWriter, r *http.Request) {
 		limit = int32(limitParsed)
 	}
 
-	cur := r.URL.Query().Get("cursor")
+	cur := query.Get("cursor")
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	filter := service.ExpenseFilter{
+		CategoryIDs: categoryIDs,
+		Description: query.Get("description"),
+		Merchant:    query.Get("merchant"),
+		Tags:        query["tag"],
+		TagMatch:    query.Get("tag_match"),
+	}
 
-	expenses, err := h.service.GetAll(r.Context(), userID, limit, cur)
-	if errors.Is(err, service.ErrExpenseNotFound) {
+	for _, categoryStr := range query["category"] {
+		categoryID, err := uuid.Parse(categoryStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+
+		filter.CategoryIDs = append(filter.CategoryIDs, categoryID)
+	}
+
+	var err error
+	if accountStr := query.Get("account"); accountStr != "" {
+		filter.AccountID, err = uuid.Parse(accountStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid account ID"}`))
+			return
+		}
+	}
+
+	if minStr := query.Get("min_amount"); minStr != "" {
+		filter.MinAmount.Decimal, err = decimal.NewFromString(minStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid minimum amount"}`))
+			return
+		}
+
+		filter.MinAmount.Valid = true
+	}
+
+	if maxStr := query.Get("max_amount"); maxStr != "" {
+		filter.MaxAmount.Decimal, err = decimal.NewFromString(maxStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid maximum amount"}`))
+			return
+		}
+
+		filter.MaxAmount.Valid = true
+	}
+
+	if filter.MinAmount.Valid && filter.MaxAmount.Valid && filter.MinAmount.Decimal.GreaterThan(filter.MaxAmount.Decimal) {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid amount range"}`))
+		return
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		filter.StartDate, err = time.ParseInLocation(time.DateOnly, fromStr, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		filter.EndDate, err = time.ParseInLocation(time.DateOnly, toStr, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+
+		// "to" is inclusive, so include every expense spent on that day
+		filter.EndDate = filter.EndDate.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+
+	if !filter.StartDate.IsZero() && !filter.EndDate.IsZero() && filter.StartDate.After(filter.EndDate) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
 
-		w.Write([]byte(`{"error": "No expenses found"}`))
+		w.Write([]byte(`{"error": "Invalid date range"}`))
+		return
+	}
+
+	if filter.TagMatch == "" {
+		filter.TagMatch = service.TagMatchAny
+	}
+
+	if filter.TagMatch != service.TagMatchAny && filter.TagMatch != service.TagMatchAll {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Tag match must be any or all"}`))
+		return
+	}
+
+	// Newest expenses first by default
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = repository.ExpenseSortDate
+	}
+
+	order := query.Get("order")
+	if order != "" && order != "asc" && order != "desc" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Order must be asc or desc"}`))
+		return
+	}
+
+	desc := order != "asc"
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	expenses, next, err := h.service.List(r.Context(), workspaceID, filter, sort, desc, limit, cur)
+	if errors.Is(err, service.ErrInvalidExpenseSort) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Sort must be amount, date or description"}`))
+		return
+	} else if errors.Is(err, service.ErrDecodeCursor) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid cursor"}`))
 		return
 	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -98,17 +253,12 @@ func (h *Expense) GetAll(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var response struct {
-		Expenses []repository.Expense `json:"expenses"`
-		Next     string               `json:"next,omitempty"`
+		Expenses []service.TaggedExpense `json:"expenses"`
+		Next     string                  `json:"next,omitempty"`
 	}
 
 	response.Expenses = expenses
-	response.Next = ""
-
-	if len(expenses) == int(limit) {
-		lastExpense := expenses[len(expenses)-1]
-		response.Next = internal.EncodeCursor(lastExpense.CreatedAt, lastExpense.ID)
-	}
+	response.Next = next
 
 	res, err := json.Marshal(response)
 	if err != nil {
@@ -123,7 +273,7 @@ func (h *Expense) GetAll(w http.ResponseWriter, r *http.Request) {
 	w.Write(res)
 }
 
-func (h *Expense) GetByCategory(w http.ResponseWriter, r *http.Request) {
+func (h *Expense) Search(w http.ResponseWriter, r *http.Request) {
 	// Default page limit
 	limit := int32(10)
 
@@ -132,7 +282,7 @@ func (h *Expense) GetByCategory(w http.ResponseWriter, r *http.Request) {
 		const decimal = 10
 		const bitSize = 32
 		limitParsed, err := strconv.ParseInt(limitStr, decimal, bitSize)
-		if err != nil || limit < 1 {
+		if err != nil || limitParsed < 1 {
 			fmt.Println("Handler Error:", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -143,21 +293,78 @@ func (h *Expense) GetByCategory(w http.ResponseWriter, r *http.Request) {
 
 	cur := r.URL.Query().Get("cursor")
 
-	categoryID, err := uuid.Parse(r.PathValue("category_id"))
-	if err != nil {
-		fmt.Println("Handler Error:", err)
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Missing search query"}`))
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	// Every filter is optional
+	categoryID := uuid.Nil
+	var from, to time.Time
 
-	expenses, err := h.service.GetByCategory(r.Context(), categoryID, userID, limit, cur)
-	if errors.Is(err, service.ErrExpenseNotFound) {
+	var err error
+	if categoryStr := r.URL.Query().Get("category"); categoryStr != "" {
+		categoryID, err = uuid.Parse(categoryStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		from, err = time.ParseInLocation(time.DateOnly, fromStr, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err = time.ParseInLocation(time.DateOnly, toStr, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+
+		// "to" is inclusive, so include every expense spent on that day
+		to = to.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
+		w.WriteHeader(http.StatusBadRequest)
 
-		w.Write([]byte(`{"error": "No expenses found"}`))
+		w.Write([]byte(`{"error": "Invalid date range"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	results, err := h.service.Search(r.Context(), workspaceID, query, categoryID, from, to, limit, cur)
+	if errors.Is(err, service.ErrInvalidSearch) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Search query must have at least one letter or digit"}`))
+		return
+	} else if errors.Is(err, service.ErrDecodeCursor) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid cursor"}`))
 		return
 	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -165,16 +372,16 @@ func (h *Expense) GetByCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var response struct {
-		Expenses []repository.Expense `json:"expenses"`
-		Next     string               `json:"next,omitempty"`
+		Expenses []service.SearchResult `json:"expenses"`
+		Next     string                 `json:"next,omitempty"`
 	}
 
-	response.Expenses = expenses
+	response.Expenses = results
 	response.Next = ""
 
-	if len(expenses) == int(limit) {
-		lastExpenses := expenses[len(expenses)-1]
-		response.Next = internal.EncodeCursor(lastExpenses.CreatedAt, lastExpenses.ID)
+	if len(results) == int(limit) {
+		last := results[len(results)-1]
+		response.Next = internal.EncodeRankCursor(last.Rank, last.ID)
 	}
 
 	res, err := json.Marshal(response)
@@ -192,9 +399,16 @@ func (h *Expense) GetByCategory(w http.ResponseWriter, r *http.Request) {
 
 func (h *Expense) Create(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		Description string  `json:"description"`
-		Amount      float64 `json:"amount"`
-		CategoryID  string  `json:"category_id"`
+		Description string             `json:"description"`
+		Merchant    string             `json:"merchant,omitempty"`
+		Notes       string             `json:"notes,omitempty"`
+		Amount      float64            `json:"amount"`
+		Currency    string             `json:"currency,omitempty"`
+		CategoryID  string             `json:"category_id,omitempty"`
+		AccountID   string             `json:"account_id,omitempty"`
+		SpentAt     string             `json:"spent_at,omitempty"`
+		Tags        []string           `json:"tags,omitempty"`
+		Splits      []expenseSplitBody `json:"splits,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -202,22 +416,111 @@ func (h *Expense) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	categoryID, err := uuid.Parse(r.PathValue("category_id"))
+	splits, err := parseSplits(body.Splits)
 	if err != nil {
-		fmt.Println("Handler Error:", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid category ID"}`))
+		return
+	}
+
+	// A split expense takes the category of its first split
+	categoryID := uuid.Nil
+	if body.CategoryID != "" || len(splits) == 0 {
+		categoryID, err = uuid.Parse(body.CategoryID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	// The account is optional
+	accountID := uuid.Nil
+	if body.AccountID != "" {
+		accountID, err = uuid.Parse(body.AccountID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid account ID"}`))
+			return
+		}
+	}
+
+	spentAt, err := parseSpentAt(body.SpentAt, requestLocation(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD or RFC 3339"}`))
+		return
+	}
+
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
 	e, err := h.service.Create(
 		r.Context(),
-		userID,
+		workspaceID,
 		body.Description,
+		body.Merchant,
+		body.Notes,
 		decimal.NewFromFloat(body.Amount),
+		body.Currency,
 		categoryID,
+		accountID,
+		spentAt,
+		body.Tags,
+		splits,
 	)
+	if errors.Is(err, service.ErrInvalidTag) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Tag names must have between 1 and 255 characters"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidSplits) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Splits need a category and a positive amount each, adding up to the amount of the expense"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Category does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountCurrency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Amount must be in the currency of the account"}`))
+		return
+	} else if errors.Is(err, service.ErrExchangeRateNotFound) {
+		writeExchangeRateError(w, err)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
 	res, err := json.Marshal(e)
 	if err != nil {
@@ -240,9 +543,9 @@ func (h *Expense) DeleteByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
 
-	e, err := h.service.DeleteByID(r.Context(), id, userID)
+	e, err := h.service.DeleteByID(r.Context(), id, workspaceID)
 	if errors.Is(err, service.ErrExpenseNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
@@ -276,9 +579,16 @@ func (h *Expense) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		Description string  `json:"description,omitempty"`
-		Amount      float64 `json:"amount,omitempty"`
-		CategoryID  string  `json:"category_id,omitempty"`
+		Description string             `json:"description,omitempty"`
+		Merchant    string             `json:"merchant,omitempty"`
+		Notes       string             `json:"notes,omitempty"`
+		Amount      float64            `json:"amount,omitempty"`
+		Currency    string             `json:"currency,omitempty"`
+		CategoryID  string             `json:"category_id,omitempty"`
+		AccountID   string             `json:"account_id,omitempty"`
+		SpentAt     string             `json:"spent_at,omitempty"`
+		Tags        []string           `json:"tags,omitempty"`
+		Splits      []expenseSplitBody `json:"splits,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -288,7 +598,8 @@ func (h *Expense) Update(w http.ResponseWriter, r *http.Request) {
 
 	bodyAmount := decimal.NewFromFloat(body.Amount)
 
-	categoryID, err := uuid.Parse(body.CategoryID)
+	// Missing splits are kept, an empty list removes them
+	splits, err := parseSplits(body.Splits)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -297,15 +608,104 @@ func (h *Expense) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := r.Context().Value("userID").(uuid.UUID)
+	// Empty values are kept as they are
+	categoryID := uuid.Nil
+	if body.CategoryID != "" {
+		categoryID, err = uuid.Parse(body.CategoryID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
 
-	e, err := h.service.Update(r.Context(), id, categoryID, userID, body.Description, bodyAmount)
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	accountID := uuid.Nil
+	if body.AccountID != "" {
+		accountID, err = uuid.Parse(body.AccountID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid account ID"}`))
+			return
+		}
+	}
+
+	spentAt, err := parseSpentAt(body.SpentAt, requestLocation(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD or RFC 3339"}`))
+		return
+	}
+
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	e, err := h.service.Update(
+		r.Context(),
+		id,
+		categoryID,
+		accountID,
+		workspaceID,
+		body.Description,
+		body.Merchant,
+		body.Notes,
+		bodyAmount,
+		body.Currency,
+		spentAt,
+		body.Tags,
+		splits,
+	)
 	if errors.Is(err, service.ErrExpenseNotFound) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusNotFound)
 
 		w.Write([]byte(`{"error": "Expense does not exist"}`))
 		return
+	} else if errors.Is(err, service.ErrInvalidTag) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Tag names must have between 1 and 255 characters"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidSplits) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Splits need a category and a positive amount each, adding up to the amount of the expense"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Category does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountCurrency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Amount must be in the currency of the account"}`))
+		return
+	} else if errors.Is(err, service.ErrExchangeRateNotFound) {
+		writeExchangeRateError(w, err)
+		return
 	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -323,3 +723,49 @@ func (h *Expense) Update(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(res)
 }
+
+// expenseSplitBody is a split of an expense when creating or updating it
+type expenseSplitBody struct {
+	CategoryID string  `json:"category_id"`
+	Amount     float64 `json:"amount"`
+	Note       string  `json:"note,omitempty"`
+}
+
+// parseSplits keeps nil splits nil, so updating an expense without them
+// keeps the ones it has.
+func parseSplits(body []expenseSplitBody) ([]service.ExpenseSplit, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	splits := make([]service.ExpenseSplit, len(body))
+	for i, split := range body {
+		categoryID, err := uuid.Parse(split.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		splits[i] = service.ExpenseSplit{
+			CategoryID: categoryID,
+			Amount:     decimal.NewFromFloat(split.Amount),
+			Note:       split.Note,
+		}
+	}
+
+	return splits, nil
+}
+
+// parseSpentAt accepts either a full RFC 3339 timestamp or just a date, which
+// starts at midnight in loc. An empty string returns the zero time, letting
+// the service pick the default.
+func parseSpentAt(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation(time.DateOnly, s, loc)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+var exportContentTypes = map[string]string{
+	service.ExportCSV:    "text/csv; charset=utf-8",
+	service.ExportJSON:   "application/json",
+	service.ExportNDJSON: "application/x-ndjson",
+}
+
+type Export struct {
+	service service.Export
+}
+
+func NewExport(postgresUrl string) *Export {
+	return &Export{
+		service: service.Export{
+			PostgresUrl: postgresUrl,
+		},
+	}
+}
+
+func (h *Export) Expenses(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	var startDate, endDate time.Time
+	var err error
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		startDate, err = time.ParseInLocation(time.DateOnly, from, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	if to := r.URL.Query().Get("to"); to != "" {
+		endDate, err = time.ParseInLocation(time.DateOnly, to, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+
+		// Include the whole end day
+		endDate = endDate.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+
+	categoryID := uuid.Nil
+	if category := r.URL.Query().Get("category"); category != "" {
+		categoryID, err = uuid.Parse(category)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="expenses.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	err = h.service.Expenses(r.Context(), w, format, workspaceID, startDate, endDate, categoryID)
+	if err != nil {
+		// The status was already sent, abort the response so the client
+		// does not take a truncated file as complete
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func (h *Export) All(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="expense-tracker-export.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	if err := h.service.All(r.Context(), w, format, workspaceID); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportFormat returns the format query parameter, csv by default. It writes
+// the error response when the format is not supported.
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.ExportCSV
+	}
+
+	if !service.ValidExportFormat(format) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid format. Use csv, json or ndjson"}`))
+		return "", false
+	}
+
+	return format, true
+}
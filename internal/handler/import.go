@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+// Largest CSV file accepted, in bytes
+const maxImportSize = 10 << 20
+
+type Import struct {
+	service service.Import
+}
+
+func NewImport(db *pgx.Conn, queries *repository.Queries) *Import {
+	return &Import{
+		service: service.Import{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *Import) CSV(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Expected a multipart form of up to 10MB"}`))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Missing file"}`))
+		return
+	}
+	defer file.Close()
+
+	formValue := func(key, fallback string) string {
+		if v := r.FormValue(key); v != "" {
+			return v
+		}
+
+		return fallback
+	}
+
+	delimiterStr := formValue("delimiter", ",")
+	delimiter, size := utf8.DecodeRuneInString(delimiterStr)
+	if size != len(delimiterStr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "The delimiter must be a single character"}`))
+		return
+	}
+
+	mapping := service.ImportMapping{
+		DateColumn:        formValue("date_column", "date"),
+		DescriptionColumn: formValue("description_column", "description"),
+		AmountColumn:      formValue("amount_column", "amount"),
+		CategoryColumn:    r.FormValue("category_column"),
+		DefaultCategory:   r.FormValue("default_category"),
+		CreateCategories:  r.FormValue("create_categories") == "true",
+		CurrencyColumn:    r.FormValue("currency_column"),
+		DefaultCurrency:   r.FormValue("default_currency"),
+		Sign:              formValue("sign", service.SignExpensesPositive),
+		DateFormat:        formValue("date_format", "YYYY-MM-DD"),
+		DecimalSeparator:  formValue("decimal_separator", "."),
+		Delimiter:         delimiter,
+	}
+
+	var commit bool
+	switch formValue("mode", "dry_run") {
+	case "dry_run":
+	case "commit":
+		commit = true
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Mode must be dry_run or commit"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	result, err := h.service.CSV(r.Context(), workspaceID, file, mapping, requestLocation(r), commit)
+	if errors.Is(err, service.ErrInvalidCSV) || errors.Is(err, service.ErrInvalidImportMapping) {
+		res, _ := json.Marshal(struct {
+			Error string `json:"error"`
+		}{
+			Error: err.Error(),
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write(res)
+		return
+	} else if err != nil && !errors.Is(err, service.ErrInvalidImportRows) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(result)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if result.Committed {
+		status = http.StatusCreated
+	} else if commit {
+		// Nothing was inserted, the rows need to be fixed first
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	w.Write(res)
+}
@@ -0,0 +1,433 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+	"github.com/shopspring/decimal"
+)
+
+type Income struct {
+	service service.Income
+}
+
+func NewIncome(db *pgx.Conn, queries *repository.Queries) *Income {
+	return &Income{
+		service: service.Income{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *Income) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	i, err := h.service.GetByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrIncomeNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Income does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(i)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Income) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// Default page limit
+	limit := int32(10)
+
+	limitStr := query.Get("limit")
+	if limitStr != "" {
+		const decimal = 10
+		const bitSize = 32
+		limitParsed, err := strconv.ParseInt(limitStr, decimal, bitSize)
+		if err != nil || limitParsed < 1 {
+			fmt.Println("Handler Error:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		limit = int32(limitParsed)
+	}
+
+	cur := query.Get("cursor")
+
+	// Both dates are optional and inclusive
+	var from, to time.Time
+	var err error
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = time.ParseInLocation(time.DateOnly, fromStr, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = time.ParseInLocation(time.DateOnly, toStr, requestLocation(r))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+			return
+		}
+
+		to = to.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	incomes, err := h.service.GetAll(r.Context(), workspaceID, from, to, limit, cur)
+	if errors.Is(err, service.ErrDecodeCursor) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid cursor"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var response struct {
+		Incomes []repository.Income `json:"incomes"`
+		Next    string              `json:"next,omitempty"`
+	}
+
+	response.Incomes = incomes
+	response.Next = ""
+
+	if len(incomes) == int(limit) {
+		last := incomes[len(incomes)-1]
+		response.Next = internal.EncodeCursor(last.ReceivedAt, last.ID)
+	}
+
+	res, err := json.Marshal(response)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Income) Create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Source     string  `json:"source"`
+		Amount     float64 `json:"amount"`
+		Currency   string  `json:"currency,omitempty"`
+		CategoryID string  `json:"category_id,omitempty"`
+		AccountID  string  `json:"account_id,omitempty"`
+		ReceivedAt string  `json:"received_at,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// The category and account are optional
+	categoryID := uuid.Nil
+	if body.CategoryID != "" {
+		var err error
+		categoryID, err = uuid.Parse(body.CategoryID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	accountID := uuid.Nil
+	if body.AccountID != "" {
+		var err error
+		accountID, err = uuid.Parse(body.AccountID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid account ID"}`))
+			return
+		}
+	}
+
+	receivedAt, err := parseSpentAt(body.ReceivedAt, requestLocation(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD or RFC 3339"}`))
+		return
+	}
+
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	i, err := h.service.Create(
+		r.Context(),
+		workspaceID,
+		body.Source,
+		decimal.NewFromFloat(body.Amount),
+		body.Currency,
+		categoryID,
+		accountID,
+		receivedAt,
+	)
+	if errors.Is(err, service.ErrInvalidIncome) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Income needs a source of up to 255 characters and a positive amount"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Category does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountCurrency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Amount must be in the currency of the account"}`))
+		return
+	} else if errors.Is(err, service.ErrExchangeRateNotFound) {
+		writeExchangeRateError(w, err)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(i)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	w.Write(res)
+}
+
+func (h *Income) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	i, err := h.service.DeleteByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrIncomeNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Income does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(i)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Income) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Source     string  `json:"source,omitempty"`
+		Amount     float64 `json:"amount,omitempty"`
+		Currency   string  `json:"currency,omitempty"`
+		CategoryID string  `json:"category_id,omitempty"`
+		AccountID  string  `json:"account_id,omitempty"`
+		ReceivedAt string  `json:"received_at,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Empty values are kept as they are
+	categoryID := uuid.Nil
+	if body.CategoryID != "" {
+		categoryID, err = uuid.Parse(body.CategoryID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	accountID := uuid.Nil
+	if body.AccountID != "" {
+		accountID, err = uuid.Parse(body.AccountID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid account ID"}`))
+			return
+		}
+	}
+
+	receivedAt, err := parseSpentAt(body.ReceivedAt, requestLocation(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD or RFC 3339"}`))
+		return
+	}
+
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	i, err := h.service.Update(
+		r.Context(),
+		id,
+		categoryID,
+		accountID,
+		workspaceID,
+		body.Source,
+		decimal.NewFromFloat(body.Amount),
+		body.Currency,
+		receivedAt,
+	)
+	if errors.Is(err, service.ErrIncomeNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Income does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidIncome) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Income needs a source of up to 255 characters and a positive amount"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Category does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountCurrency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Amount must be in the currency of the account"}`))
+		return
+	} else if errors.Is(err, service.ErrExchangeRateNotFound) {
+		writeExchangeRateError(w, err)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(i)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+type MFA struct {
+	service service.MFA
+}
+
+func NewMFA(db *pgx.Conn, queries *repository.Queries) *MFA {
+	return &MFA{
+		service: service.MFA{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *MFA) Status(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	status, err := h.service.Status(r.Context(), userID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(status)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+// EnrollTOTP returns the secret to add to the authenticator app, which is
+// confirmed with a first code at ConfirmTOTP.
+func (h *MFA) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	enrollment, err := h.service.EnrollTOTP(r.Context(), userID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrMFAEnabled) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Two-factor authentication is already enabled"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(enrollment)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	w.Write(res)
+}
+
+func (h *MFA) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Code string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	codes, err := h.service.ConfirmTOTP(r.Context(), userID, body.Code)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrMFAEnabled) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Two-factor authentication is already enabled"}`))
+		return
+	} else if errors.Is(err, service.ErrMFANotEnrolled) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Two-factor authentication enrollment was not started"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidMFACode) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid code"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeRecoveryCodes(w, codes)
+}
+
+// DisableTOTP takes the password and a code of the authenticator app or a
+// recovery code.
+func (h *MFA) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	err := h.service.DisableTOTP(r.Context(), userID, body.Password, body.Code)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrWrongCredentials) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Password is wrong"}`))
+		return
+	} else if errors.Is(err, service.ErrMFANotEnabled) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Two-factor authentication is not enabled"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidMFACode) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Invalid or already used code"}`))
+		return
+	} else if errors.Is(err, service.ErrMFALocked) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		w.Write([]byte(`{"error": "Too many wrong passwords or codes, try again later"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes takes the password and a code of the
+// authenticator app or a recovery code, like DisableTOTP.
+func (h *MFA) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), userID, body.Password, body.Code)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrWrongCredentials) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Password is wrong"}`))
+		return
+	} else if errors.Is(err, service.ErrMFANotEnabled) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Two-factor authentication is not enabled"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidMFACode) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Invalid or already used code"}`))
+		return
+	} else if errors.Is(err, service.ErrMFALocked) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		w.Write([]byte(`{"error": "Too many wrong passwords or codes, try again later"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeRecoveryCodes(w, codes)
+}
+
+// writeRecoveryCodes responds with the recovery codes, the only time they
+// are shown.
+func writeRecoveryCodes(w http.ResponseWriter, codes []string) {
+	res, err := json.Marshal(struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: codes,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
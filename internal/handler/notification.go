@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+type Notification struct {
+	service service.Notification
+}
+
+func NewNotification(db *pgx.Conn, queries *repository.Queries) *Notification {
+	return &Notification{
+		service: service.Notification{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *Notification) GetAll(w http.ResponseWriter, r *http.Request) {
+	// Default page limit
+	limit := int32(10)
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		const decimal = 10
+		const bitSize = 32
+		limitParsed, err := strconv.ParseInt(limitStr, decimal, bitSize)
+		if err != nil || limitParsed < 1 {
+			fmt.Println("Handler Error:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		limit = int32(limitParsed)
+	}
+
+	cur := r.URL.Query().Get("cursor")
+
+	unreadOnly := false
+	if unread := r.URL.Query().Get("unread"); unread != "" {
+		var err error
+		unreadOnly, err = strconv.ParseBool(unread)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "unread must be true or false"}`))
+			return
+		}
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	notifications, err := h.service.GetAll(r.Context(), userID, unreadOnly, limit, cur)
+	if errors.Is(err, service.ErrDecodeCursor) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid cursor"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var response struct {
+		Notifications []repository.Notification `json:"notifications"`
+		Next          string                    `json:"next,omitempty"`
+	}
+
+	response.Notifications = notifications
+	response.Next = ""
+
+	if len(notifications) == int(limit) {
+		last := notifications[len(notifications)-1]
+		response.Next = internal.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	res, err := json.Marshal(response)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Notification) MarkRead(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	n, err := h.service.MarkRead(r.Context(), id, userID)
+	if errors.Is(err, service.ErrNotificationNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Notification does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(n)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
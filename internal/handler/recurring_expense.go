@@ -0,0 +1,465 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+	"github.com/shopspring/decimal"
+)
+
+type RecurringExpense struct {
+	service service.RecurringExpense
+}
+
+func NewRecurringExpense(db *pgx.Conn, queries *repository.Queries) *RecurringExpense {
+	return &RecurringExpense{
+		service: service.RecurringExpense{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+type recurringExpenseBody struct {
+	Description     string  `json:"description"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency,omitempty"`
+	CategoryID      string  `json:"category_id"`
+	Frequency       string  `json:"frequency"`
+	RepeatInterval  int32   `json:"repeat_interval"`
+	StartDate       string  `json:"start_date"`
+	EndDate         string  `json:"end_date,omitempty"`
+	OccurrenceLimit int32   `json:"occurrence_limit,omitempty"`
+}
+
+func (b recurringExpenseBody) rule(loc *time.Location) (service.RecurrenceRule, error) {
+	startDate, err := parseSpentAt(b.StartDate, loc)
+	if err != nil {
+		return service.RecurrenceRule{}, err
+	}
+
+	endDate, err := parseSpentAt(b.EndDate, loc)
+	if err != nil {
+		return service.RecurrenceRule{}, err
+	}
+
+	return service.RecurrenceRule{
+		Frequency: b.Frequency,
+		Interval:  b.RepeatInterval,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Count:     b.OccurrenceLimit,
+	}, nil
+}
+
+// recurringExpenseUpdateBody is recurringExpenseBody for updates, where
+// leaving end_date or occurrence_limit out keeps them and sending them empty
+// ("" or 0) removes them.
+type recurringExpenseUpdateBody struct {
+	Description     string  `json:"description"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency,omitempty"`
+	CategoryID      string  `json:"category_id"`
+	Frequency       string  `json:"frequency"`
+	RepeatInterval  int32   `json:"repeat_interval"`
+	StartDate       string  `json:"start_date"`
+	EndDate         *string `json:"end_date"`
+	OccurrenceLimit *int32  `json:"occurrence_limit"`
+}
+
+func (b recurringExpenseUpdateBody) update(loc *time.Location) (service.RecurrenceUpdate, error) {
+	startDate, err := parseSpentAt(b.StartDate, loc)
+	if err != nil {
+		return service.RecurrenceUpdate{}, err
+	}
+
+	update := service.RecurrenceUpdate{
+		Frequency: b.Frequency,
+		Interval:  b.RepeatInterval,
+		StartDate: startDate,
+		Count:     b.OccurrenceLimit,
+	}
+
+	if b.EndDate != nil {
+		endDate, err := parseSpentAt(*b.EndDate, loc)
+		if err != nil {
+			return service.RecurrenceUpdate{}, err
+		}
+
+		update.EndDate = &endDate
+	}
+
+	return update, nil
+}
+
+func (h *RecurringExpense) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	re, err := h.service.GetByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrRecurringExpenseNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Recurring expense does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(re)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *RecurringExpense) GetAll(w http.ResponseWriter, r *http.Request) {
+	// Default page limit
+	limit := int32(10)
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		const decimal = 10
+		const bitSize = 32
+		limitParsed, err := strconv.ParseInt(limitStr, decimal, bitSize)
+		if err != nil || limitParsed < 1 {
+			fmt.Println("Handler Error:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		limit = int32(limitParsed)
+	}
+
+	cur := r.URL.Query().Get("cursor")
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	recurringExpenses, err := h.service.GetAll(r.Context(), workspaceID, limit, cur)
+	if errors.Is(err, service.ErrRecurringExpenseNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "No recurring expenses found"}`))
+		return
+	} else if errors.Is(err, service.ErrDecodeCursor) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid cursor"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var response struct {
+		RecurringExpenses []repository.RecurringExpense `json:"recurring_expenses"`
+		Next              string                        `json:"next,omitempty"`
+	}
+
+	response.RecurringExpenses = recurringExpenses
+	response.Next = ""
+
+	if len(recurringExpenses) == int(limit) {
+		last := recurringExpenses[len(recurringExpenses)-1]
+		response.Next = internal.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	res, err := json.Marshal(response)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *RecurringExpense) Create(w http.ResponseWriter, r *http.Request) {
+	var body recurringExpenseBody
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	categoryID, err := uuid.Parse(body.CategoryID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid category ID"}`))
+		return
+	}
+
+	// A missing interval means every day/week/month/year
+	if body.RepeatInterval == 0 {
+		body.RepeatInterval = 1
+	}
+
+	rule, err := body.rule(requestLocation(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD or RFC 3339"}`))
+		return
+	}
+
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	re, err := h.service.Create(
+		r.Context(),
+		workspaceID,
+		categoryID,
+		body.Description,
+		decimal.NewFromFloat(body.Amount),
+		body.Currency,
+		rule,
+	)
+	if errors.Is(err, service.ErrInvalidRecurrence) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid recurrence rule"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Category does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(re)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	w.Write(res)
+}
+
+func (h *RecurringExpense) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var body recurringExpenseUpdateBody
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Empty values are kept as they are
+	categoryID := uuid.Nil
+	if body.CategoryID != "" {
+		categoryID, err = uuid.Parse(body.CategoryID)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	update, err := body.update(requestLocation(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD or RFC 3339"}`))
+		return
+	}
+
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	re, err := h.service.Update(
+		r.Context(),
+		id,
+		workspaceID,
+		categoryID,
+		body.Description,
+		decimal.NewFromFloat(body.Amount),
+		body.Currency,
+		update,
+	)
+	if errors.Is(err, service.ErrRecurringExpenseNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Recurring expense does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidRecurrence) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid recurrence rule"}`))
+		return
+	} else if errors.Is(err, service.ErrCategoryNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Category does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(re)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *RecurringExpense) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	re, err := h.service.DeleteByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrRecurringExpenseNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Recurring expense does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(re)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *RecurringExpense) Preview(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Default number of occurrences
+	n := 5
+
+	nStr := r.URL.Query().Get("n")
+	if nStr != "" {
+		n, err = strconv.Atoi(nStr)
+		if err != nil || n < 1 || n > 100 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "n must be between 1 and 100"}`))
+			return
+		}
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	occurrences, err := h.service.Preview(r.Context(), id, workspaceID, n)
+	if errors.Is(err, service.ErrRecurringExpenseNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Recurring expense does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Occurrences []time.Time `json:"occurrences"`
+	}{
+		Occurrences: occurrences,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
@@ -0,0 +1,431 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+type Report struct {
+	service service.Report
+}
+
+func NewReport(db *pgx.Conn, queries *repository.Queries) *Report {
+	return &Report{
+		service: service.Report{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+var errInvalidTimeZone = errors.New("invalid time zone")
+
+// reportCalendar returns the calendar of the user, in the time zone of the
+// "tz" query parameter (an IANA name like "Europe/Lisbon") when it is given.
+func reportCalendar(r *http.Request) (service.Calendar, error) {
+	cal := r.Context().Value("calendar").(service.Calendar)
+
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return cal, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return service.Calendar{}, errInvalidTimeZone
+	}
+
+	cal.Location = loc
+	return cal, nil
+}
+
+// requestLocation is the time zone of the user making the request, the one
+// dates without a time are in.
+func requestLocation(r *http.Request) *time.Location {
+	return r.Context().Value("calendar").(service.Calendar).Location
+}
+
+// reportRange returns the range given by the "from" and "to" query
+// parameters in the time zone of cal, defaulting to the current month of
+// cal up to today. Both dates are inclusive.
+func reportRange(r *http.Request, cal service.Calendar) (time.Time, time.Time, error) {
+	loc := cal.Location
+	now := time.Now().In(loc)
+	from := cal.StartOfPeriod(now, service.PeriodMonth)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var err error
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		from, err = time.ParseInLocation(time.DateOnly, fromStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err = time.ParseInLocation(time.DateOnly, toStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	// Include every expense spent on the last day
+	to = to.AddDate(0, 0, 1).Add(-time.Microsecond)
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from is after to")
+	}
+
+	return from, to, nil
+}
+
+func (h *Report) CategoryTotals(w http.ResponseWriter, r *http.Request) {
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	from, to, err := reportRange(r, cal)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	totals, err := h.service.CategoryTotals(r.Context(), workspaceID, from, to)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		From       time.Time                         `json:"from"`
+		To         time.Time                         `json:"to"`
+		Categories []repository.GetCategoryTotalsRow `json:"categories"`
+	}{
+		From:       from,
+		To:         to,
+		Categories: totals,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Report) TagTotals(w http.ResponseWriter, r *http.Request) {
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	from, to, err := reportRange(r, cal)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	totals, err := h.service.TagTotals(r.Context(), workspaceID, from, to)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		From time.Time                    `json:"from"`
+		To   time.Time                    `json:"to"`
+		Tags []repository.GetTagTotalsRow `json:"tags"`
+	}{
+		From: from,
+		To:   to,
+		Tags: totals,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Report) TimeSeries(w http.ResponseWriter, r *http.Request) {
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	from, to, err := reportRange(r, cal)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = service.PeriodMonth
+	}
+
+	categoryID := uuid.Nil
+	if categoryStr := r.URL.Query().Get("category_id"); categoryStr != "" {
+		categoryID, err = uuid.Parse(categoryStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid category ID"}`))
+			return
+		}
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	var series any
+	if r.URL.Query().Get("per_category") == "true" {
+		series, err = h.service.TimeSeriesByCategory(r.Context(), workspaceID, bucket, cal, from, to)
+	} else {
+		series, err = h.service.TimeSeries(r.Context(), workspaceID, bucket, cal, from, to, categoryID)
+	}
+
+	if errors.Is(err, service.ErrInvalidPeriod) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid bucket. Use day, week, month or year"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		From   time.Time `json:"from"`
+		To     time.Time `json:"to"`
+		Bucket string    `json:"bucket"`
+		Series any       `json:"series"`
+	}{
+		From:   from,
+		To:     to,
+		Bucket: bucket,
+		Series: series,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Report) CashFlow(w http.ResponseWriter, r *http.Request) {
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	from, to, err := reportRange(r, cal)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = service.PeriodMonth
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	flow, err := h.service.CashFlow(r.Context(), workspaceID, bucket, cal, from, to)
+	if errors.Is(err, service.ErrInvalidPeriod) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid bucket. Use day, week, month or year"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		From   time.Time `json:"from"`
+		To     time.Time `json:"to"`
+		Bucket string    `json:"bucket"`
+		service.CashFlow
+	}{
+		From:     from,
+		To:       to,
+		Bucket:   bucket,
+		CashFlow: flow,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Report) IncomeVsExpenses(w http.ResponseWriter, r *http.Request) {
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	from, to, err := reportRange(r, cal)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date range. Use YYYY-MM-DD"}`))
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	totals, err := h.service.IncomeVsExpenses(r.Context(), workspaceID, from, to)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		From       time.Time                                     `json:"from"`
+		To         time.Time                                     `json:"to"`
+		Categories []repository.GetIncomeVsExpensesByCategoryRow `json:"categories"`
+	}{
+		From:       from,
+		To:         to,
+		Categories: totals,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Report) Compare(w http.ResponseWriter, r *http.Request) {
+	cal, err := reportCalendar(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone"}`))
+		return
+	}
+
+	date := time.Now().In(cal.Location)
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		date, err = time.ParseInLocation(time.DateOnly, dateStr, cal.Location)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD"}`))
+			return
+		}
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = service.PeriodMonth
+	}
+
+	against := r.URL.Query().Get("against")
+	if against == "" {
+		against = service.CompareAgainstPrevious
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	c, err := h.service.Compare(r.Context(), workspaceID, period, against, cal, date)
+	if errors.Is(err, service.ErrInvalidPeriod) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid period. Use week, month or year against previous or last_year"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(c)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
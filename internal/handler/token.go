@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 	"github.com/jamcunha/expense-tracker/internal/service"
@@ -40,6 +41,7 @@ func (h *Token) Create(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Device   string `json:"device"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -47,7 +49,16 @@ func (h *Token) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, err := h.service.Create(r.Context(), body.Email, body.Password)
+	// Label the session with the client when no device name is given
+	if body.Device == "" {
+		body.Device = r.UserAgent()
+	}
+
+	if device := []rune(body.Device); len(device) > 255 {
+		body.Device = string(device[:255])
+	}
+
+	login, err := h.service.Create(r.Context(), body.Email, body.Password, body.Device)
 	if errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrWrongCredentials) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -60,9 +71,70 @@ func (h *Token) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var res []byte
+	if login.MFAToken != "" {
+		// The tokens are issued at /token/mfa with the second factor
+		res, err = json.Marshal(struct {
+			MFARequired  bool      `json:"mfa_required"`
+			MFAToken     string    `json:"mfa_token"`
+			MFAExpiresAt time.Time `json:"mfa_expires_at"`
+		}{
+			MFARequired:  true,
+			MFAToken:     login.MFAToken,
+			MFAExpiresAt: login.MFAExpiresAt,
+		})
+	} else {
+		res, err = json.Marshal(struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+		}{
+			AccessToken:  login.AccessToken,
+			RefreshToken: login.RefreshToken,
+		})
+	}
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
+	w.Write(res)
+}
+
+// CompleteMFA exchanges the MFA token of a login for the session tokens
+// with a code of the authenticator app or a recovery code.
+func (h *Token) CompleteMFA(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	accessToken, refreshToken, err := h.service.CompleteMFA(r.Context(), body.MFAToken, body.Code)
+	if errors.Is(err, service.ErrInvalidToken) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "MFA token is invalid, expired or out of tries. Log in again"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidMFACode) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Invalid or already used code"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	res, err := json.Marshal(struct {
 		AccessToken  string `json:"access_token"`
 		RefreshToken string `json:"refresh_token"`
@@ -76,12 +148,15 @@ func (h *Token) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
 	w.Write(res)
 }
 
 func (h *Token) Refresh(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		RefreshToken string `json:"refresh_tokend"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -89,13 +164,19 @@ func (h *Token) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, err := h.service.Refresh(r.Context(), body.RefreshToken)
+	accessToken, refreshToken, err := h.service.Refresh(r.Context(), body.RefreshToken)
 	if errors.Is(err, service.ErrInvalidToken) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
 
 		w.Write([]byte(`{"error": "Invalid token"}`))
 		return
+	} else if errors.Is(err, service.ErrTokenReused) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Token was already used, the session was signed out"}`))
+		return
 	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -105,9 +186,11 @@ func (h *Token) Refresh(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	res, err := json.Marshal(struct {
-		AccessToken string `json:"access_token"`
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
 	}{
-		AccessToken: accessToken,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
 	})
 	if err != nil {
 		fmt.Println("failed to marshal:", err)
@@ -117,3 +200,39 @@ func (h *Token) Refresh(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(res)
 }
+
+func (h *Token) Revoke(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := h.service.Revoke(r.Context(), body.RefreshToken)
+	if errors.Is(err, service.ErrInvalidToken) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Invalid token"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Token) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	if err := h.service.RevokeAll(r.Context(), userID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
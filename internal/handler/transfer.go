@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/jamcunha/expense-tracker/internal/service"
+	"github.com/shopspring/decimal"
+)
+
+type Transfer struct {
+	service service.Transfer
+}
+
+func NewTransfer(db *pgx.Conn, queries *repository.Queries) *Transfer {
+	return &Transfer{
+		service: service.Transfer{
+			DB:      db,
+			Queries: queries,
+		},
+	}
+}
+
+func (h *Transfer) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	t, err := h.service.GetByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrTransferNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Transfer does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(t)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Transfer) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// Default page limit
+	limit := int32(10)
+
+	limitStr := query.Get("limit")
+	if limitStr != "" {
+		const decimal = 10
+		const bitSize = 32
+		limitParsed, err := strconv.ParseInt(limitStr, decimal, bitSize)
+		if err != nil || limitParsed < 1 {
+			fmt.Println("Handler Error:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		limit = int32(limitParsed)
+	}
+
+	cur := query.Get("cursor")
+
+	accountID := uuid.Nil
+	if accountStr := query.Get("account"); accountStr != "" {
+		var err error
+		accountID, err = uuid.Parse(accountStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+
+			w.Write([]byte(`{"error": "Invalid account ID"}`))
+			return
+		}
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	transfers, err := h.service.GetAll(r.Context(), workspaceID, accountID, limit, cur)
+	if errors.Is(err, service.ErrDecodeCursor) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid cursor"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var response struct {
+		Transfers []repository.Transfer `json:"transfers"`
+		Next      string                `json:"next,omitempty"`
+	}
+
+	response.Transfers = transfers
+	response.Next = ""
+
+	if len(transfers) == int(limit) {
+		last := transfers[len(transfers)-1]
+		response.Next = internal.EncodeCursor(last.TransferredAt, last.ID)
+	}
+
+	res, err := json.Marshal(response)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *Transfer) Create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		FromAccountID string   `json:"from_account_id"`
+		ToAccountID   string   `json:"to_account_id"`
+		Amount        float64  `json:"amount"`
+		ToAmount      *float64 `json:"to_amount,omitempty"`
+		Description   string   `json:"description,omitempty"`
+		TransferredAt string   `json:"transferred_at,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	fromID, err := uuid.Parse(body.FromAccountID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid account ID"}`))
+		return
+	}
+
+	toID, err := uuid.Parse(body.ToAccountID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid account ID"}`))
+		return
+	}
+
+	transferredAt, err := parseSpentAt(body.TransferredAt, requestLocation(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid date format. Use YYYY-MM-DD or RFC 3339"}`))
+		return
+	}
+
+	var toAmount decimal.NullDecimal
+	if body.ToAmount != nil {
+		toAmount = decimal.NewNullDecimal(decimal.NewFromFloat(*body.ToAmount))
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	t, err := h.service.Create(
+		r.Context(),
+		workspaceID,
+		fromID,
+		toID,
+		decimal.NewFromFloat(body.Amount),
+		toAmount,
+		body.Description,
+		transferredAt,
+	)
+	if errors.Is(err, service.ErrInvalidTransfer) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Transfer needs two different accounts and a positive amount"}`))
+		return
+	} else if errors.Is(err, service.ErrAccountNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Account does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrExchangeRateNotFound) {
+		writeExchangeRateError(w, err)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(t)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	w.Write(res)
+}
+
+func (h *Transfer) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := r.Context().Value("workspaceID").(uuid.UUID)
+
+	t, err := h.service.DeleteByID(r.Context(), id, workspaceID)
+	if errors.Is(err, service.ErrTransferNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "Transfer does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(t)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
@@ -9,46 +9,89 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/mailer"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 	"github.com/jamcunha/expense-tracker/internal/service"
 )
 
 type User struct {
 	service service.User
+
+	// Signs the user in again after changing their password
+	tokens service.Token
 }
 
-func NewUser(db *pgx.Conn, queries *repository.Queries) *User {
+func NewUser(db *pgx.Conn, queries *repository.Queries, mailer mailer.Mailer, jwtParams JWTParams) *User {
 	return &User{
 		service: service.User{
 			DB:      db,
 			Queries: queries,
+			Mailer:  mailer,
+		},
+		tokens: service.Token{
+			DB:               db,
+			Queries:          queries,
+			JWTAccessSecret:  jwtParams.JWTAccessSecret,
+			JWTRefreshSecret: jwtParams.JWTRefreshSecret,
+			JWTAccessExp:     jwtParams.JWTAccessExp,
+			JWTRefreshExp:    jwtParams.JWTRefreshExp,
 		},
 	}
 }
 
+// pathUserID returns the user of the path, "me" being the one signed in.
+// Users can only see and delete themselves, so any other ID is forbidden.
+func pathUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	if r.PathValue("id") == "me" {
+		return userID, true
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		fmt.Println("Handler Error:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	if id != userID {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+
+		w.Write([]byte(`{"error": "Can not access another user"}`))
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
 type userResponse struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
+	ID              uuid.UUID          `json:"id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	Name            string             `json:"name"`
+	Email           string             `json:"email"`
+	Currency        string             `json:"currency"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
 }
 
 func newUserResponse(u repository.User) userResponse {
 	return userResponse{
-		ID:        u.ID,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
-		Name:      u.Name,
-		Email:     u.Email,
+		ID:              u.ID,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+		Name:            u.Name,
+		Email:           u.Email,
+		Currency:        u.Currency,
+		EmailVerifiedAt: u.EmailVerifiedAt,
 	}
 }
 
 func (h *User) GetByID(w http.ResponseWriter, r *http.Request) {
-	id, err := uuid.Parse(r.PathValue("id"))
-	if err != nil {
-		fmt.Println("Handle Error:", err)
-		w.WriteHeader(http.StatusBadRequest)
+	id, ok := pathUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -81,6 +124,7 @@ func (h *User) Create(w http.ResponseWriter, r *http.Request) {
 		Name     string `json:"name"`
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Currency string `json:"currency,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -88,8 +132,34 @@ func (h *User) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	u, err := h.service.Create(r.Context(), body.Name, body.Email, body.Password)
-	if err != nil {
+	if body.Currency != "" && !service.ValidCurrency(body.Currency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency. Use an ISO 4217 code like EUR"}`))
+		return
+	}
+
+	u, err := h.service.Create(r.Context(), body.Name, body.Email, body.Password, body.Currency)
+	if errors.Is(err, service.ErrInvalidEmail) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid email"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidPassword) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Password must have between 8 and 72 characters"}`))
+		return
+	} else if errors.Is(err, service.ErrEmailExists) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Email is already in use"}`))
+		return
+	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -107,14 +177,254 @@ func (h *User) Create(w http.ResponseWriter, r *http.Request) {
 	w.Write(res)
 }
 
-func (h *User) DeleteByID(w http.ResponseWriter, r *http.Request) {
-	id, err := uuid.Parse(r.PathValue("id"))
+// UpdateMe changes the name or email of the user signed in, a new email
+// must be verified again.
+func (h *User) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	u, err := h.service.UpdateProfile(r.Context(), userID, body.Name, body.Email)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidUserName) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Name must have up to 255 characters"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidEmail) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid email"}`))
+		return
+	} else if errors.Is(err, service.ErrEmailExists) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Email is already in use"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(newUserResponse(u))
 	if err != nil {
-		fmt.Println("Handler Error:", err)
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *User) Settings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	settings, err := h.service.Settings(r.Context(), userID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(settings)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+// UpdateSettings changes the settings in the body, the missing ones keep
+// their current value.
+func (h *User) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	settings, err := h.service.Settings(r.Context(), userID)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	settings, err = h.service.UpdateSettings(r.Context(), userID, settings)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidCurrency) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid currency code"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidTimeZone) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid time zone. Use an IANA name like Europe/Lisbon"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidLocale) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Invalid locale. Use a language tag like en-US"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidWeekStart) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "First day of the week must be between 0 (Sunday) and 6 (Saturday)"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidMonthStartDay) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Month start day must be between 1 and 28"}`))
+		return
+	} else if errors.Is(err, service.ErrCurrencyInUse) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Base currency can not change once the personal workspace has expenses, incomes or budgets"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(settings)
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+// ChangePassword signs out every session, so the user is signed in again
+// with new tokens for the session making the request.
+func (h *User) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+		Device          string `json:"device"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	if body.Device == "" {
+		body.Device = r.UserAgent()
+	}
+
+	if device := []rune(body.Device); len(device) > 255 {
+		body.Device = string(device[:255])
+	}
+
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	err := h.service.ChangePassword(r.Context(), userID, body.CurrentPassword, body.NewPassword)
+	if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if errors.Is(err, service.ErrWrongCredentials) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+
+		w.Write([]byte(`{"error": "Current password is wrong"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidPassword) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Password must have between 8 and 72 characters"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	accessToken, refreshToken, err := h.tokens.CreateFor(r.Context(), userID, body.Device)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *User) DeleteByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
 	u, err := h.service.DeleteByID(r.Context(), id)
 	if errors.Is(err, service.ErrUserNotFound) {
 		w.Header().Set("Content-Type", "application/json")
@@ -140,3 +450,122 @@ func (h *User) DeleteByID(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(res)
 }
+
+// VerifyEmail marks the email of the user as verified with the token sent to
+// it when they registered.
+func (h *User) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.service.VerifyEmail(r.Context(), body.Token)
+	if errors.Is(err, service.ErrInvalidUserToken) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Token is invalid, expired or was already used"}`))
+		return
+	} else if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(newUserResponse(u))
+	if err != nil {
+		fmt.Println("failed to marshal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	w.Write(res)
+}
+
+func (h *User) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(uuid.UUID)
+
+	err := h.service.ResendVerification(r.Context(), userID)
+	if errors.Is(err, service.ErrEmailVerified) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+
+		w.Write([]byte(`{"error": "Email is already verified"}`))
+		return
+	} else if errors.Is(err, service.ErrUserNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		w.Write([]byte(`{"error": "User does not exist"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword always answers the same, whether the email has an account
+// or not.
+func (h *User) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ForgotPassword(r.Context(), body.Email); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *User) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := h.service.ResetPassword(r.Context(), body.Token, body.Password)
+	if errors.Is(err, service.ErrInvalidPassword) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Password must have between 8 and 72 characters"}`))
+		return
+	} else if errors.Is(err, service.ErrInvalidUserToken) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+
+		w.Write([]byte(`{"error": "Token is invalid, expired or was already used"}`))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
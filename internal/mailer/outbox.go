@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbox keeps the messages instead of sending them, for local development
+// and tests. Each one is written to Dir as an .eml file, or printed to the
+// standard output when Dir is empty.
+type Outbox struct {
+	Dir  string
+	From string
+}
+
+func (o *Outbox) Send(ctx context.Context, m Message) error {
+	from := &mail.Address{Address: o.From}
+	if o.From == "" {
+		from.Address = "outbox@localhost"
+	}
+
+	email := m.bytes(from)
+
+	if o.Dir == "" {
+		fmt.Printf("Outbox email:\n%s\n", strings.ReplaceAll(string(email), "\r\n", "\n"))
+		return nil
+	}
+
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return err
+	}
+
+	// Named by the time they were sent so they are listed in order
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.New())
+
+	return os.WriteFile(filepath.Join(o.Dir, name), email, 0o644)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AdminAuth only lets through the requests with the admin token
+// (Authorization: Bearer <token>).
+func AdminAuth(next http.Handler, adminToken string) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+
+				w.Write([]byte(`{"error": "Invalid Token"}`))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		},
+	)
+}
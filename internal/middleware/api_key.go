@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+// Auth authenticates with a JWT, like JWTAuth, or with an API key, which
+// needs scope. Routes without a scope only take JWTs, so an API key can not
+// manage the account or make more keys.
+func Auth(next http.Handler, jwtSecret string, apiKeys *service.APIKey, scope string) http.Handler {
+	// A typo in the routes would lock every API key out of them
+	if !service.ValidScope(scope) {
+		panic(fmt.Sprintf("invalid API key scope %q", scope))
+	}
+
+	jwtAuth := JWTAuth(next, jwtSecret)
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) { // Authorization: Bearer et_<prefix>_<secret>
+			key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !service.IsAPIKey(key) {
+				jwtAuth.ServeHTTP(w, r)
+				return
+			}
+
+			k, err := apiKeys.Authenticate(r.Context(), key)
+			if errors.Is(err, service.ErrExpiredToken) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+
+				w.Write([]byte(`{"error": "API Key Expired"}`))
+				return
+			} else if errors.Is(err, service.ErrInvalidToken) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+
+				w.Write([]byte(`{"error": "Invalid API Key"}`))
+				return
+			} else if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if !service.HasScope(k.Scopes, scope) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+
+				w.Write([]byte(`{"error": "API key is missing the ` + scope + ` scope"}`))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "userID", k.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		},
+	)
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+// Calendar puts the calendar of the settings of the user in the context, so
+// the dates of the request are read in the time zone of the user and the
+// periods start on the days the user chose. It must run after JWTAuth.
+func Calendar(next http.Handler, users *service.User) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value("userID").(uuid.UUID)
+
+			settings, err := users.Settings(r.Context(), userID)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "calendar", settings.Calendar())
+			next.ServeHTTP(w, r.WithContext(ctx))
+		},
+	)
+}
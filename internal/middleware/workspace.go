@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jamcunha/expense-tracker/internal/service"
+)
+
+// WorkspaceHeader selects the workspace of the ledger requests, the personal
+// one of the user when it is missing
+const WorkspaceHeader = "X-Workspace-ID"
+
+// Workspace puts the workspace of the request in the context after checking
+// the user is a member of it. Viewers can only read it, so they are limited
+// to GET requests. It must run after JWTAuth.
+func Workspace(next http.Handler, workspaces *service.Workspace) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value("userID").(uuid.UUID)
+
+			// The personal workspace has the ID of the user
+			workspaceID := userID
+			if header := r.Header.Get(WorkspaceHeader); header != "" {
+				var err error
+				workspaceID, err = uuid.Parse(header)
+				if err != nil {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+
+					w.Write([]byte(`{"error": "Invalid workspace ID"}`))
+					return
+				}
+			}
+
+			role, err := workspaces.Role(r.Context(), workspaceID, userID)
+			if errors.Is(err, service.ErrWorkspaceNotFound) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+
+				w.Write([]byte(`{"error": "Workspace does not exist"}`))
+				return
+			} else if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if role == service.RoleViewer && r.Method != http.MethodGet {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+
+				w.Write([]byte(`{"error": "Viewers can not change the workspace"}`))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "workspaceID", workspaceID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		},
+	)
+}
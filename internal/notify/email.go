@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/jamcunha/expense-tracker/internal/mailer"
+)
+
+// Email sends the notifications with a mailer, skipping the ones without an
+// email
+type Email struct {
+	Mailer mailer.Mailer
+}
+
+func (e *Email) Notify(ctx context.Context, n Notification) error {
+	if n.Email == "" {
+		return nil
+	}
+
+	return e.Mailer.Send(ctx, mailer.Message{
+		To:      n.Email,
+		Subject: n.Title,
+		Body:    n.Message,
+	})
+}
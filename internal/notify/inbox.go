@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// Inbox keeps the notifications in the database, where the users read them
+// from the API.
+type Inbox struct {
+	Queries *repository.Queries
+}
+
+func (i *Inbox) Notify(ctx context.Context, n Notification) error {
+	data := n.Data
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+
+	_, err := i.Queries.CreateNotification(ctx, repository.CreateNotificationParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		Kind:      n.Kind,
+		Title:     n.Title,
+		Message:   n.Message,
+		Data:      data,
+		UserID:    n.UserID,
+	})
+
+	return err
+}
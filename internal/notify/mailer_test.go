@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/jamcunha/expense-tracker/internal/mailer"
+)
+
+// smtpSession is what the fake SMTP server received
+type smtpSession struct {
+	from string
+	to   []string
+	data []byte
+	err  error
+}
+
+// fakeSMTP accepts a single session on ln, without STARTTLS or AUTH, and
+// sends what it received once the client quits.
+func fakeSMTP(ln net.Listener) <-chan smtpSession {
+	done := make(chan smtpSession, 1)
+
+	go func() {
+		var s smtpSession
+		defer func() { done <- s }()
+
+		conn, err := ln.Accept()
+		if err != nil {
+			s.err = err
+			return
+		}
+		defer conn.Close()
+
+		r := textproto.NewReader(bufio.NewReader(conn))
+		w := textproto.NewWriter(bufio.NewWriter(conn))
+
+		w.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := r.ReadLine()
+			if err != nil {
+				s.err = err
+				return
+			}
+
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				w.PrintfLine("250 localhost")
+			case "MAIL":
+				s.from = arg
+				w.PrintfLine("250 OK")
+			case "RCPT":
+				s.to = append(s.to, arg)
+				w.PrintfLine("250 OK")
+			case "DATA":
+				w.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				if s.data, err = r.ReadDotBytes(); err != nil {
+					s.err = err
+					return
+				}
+				w.PrintfLine("250 OK")
+			case "QUIT":
+				w.PrintfLine("221 Bye")
+				return
+			default:
+				w.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	return done
+}
+
+func TestEmailSMTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	done := fakeSMTP(ln)
+
+	notifier := &Email{Mailer: &mailer.SMTP{
+		Addr: ln.Addr().String(),
+		From: "Expense Tracker <alerts@example.com>",
+	}}
+
+	n := Notification{
+		Email:   "ana@example.com",
+		Kind:    KindBudgetAlert,
+		Title:   "Café budget reached 80%",
+		Message: "You spent 40.00 EUR of the 50.00 EUR budgeted for Café.\n.Dotted line",
+	}
+
+	if err := notifier.Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify() = %v", err)
+	}
+
+	s := <-done
+	if s.err != nil {
+		t.Fatalf("fake SMTP server: %v", s.err)
+	}
+
+	if s.from != "FROM:<alerts@example.com>" {
+		t.Errorf("MAIL %s, want FROM:<alerts@example.com>", s.from)
+	}
+
+	if len(s.to) != 1 || s.to[0] != "TO:<ana@example.com>" {
+		t.Errorf("RCPT %v, want [TO:<ana@example.com>]", s.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(s.data)))
+	if err != nil {
+		t.Fatalf("ReadMessage() = %v", err)
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil || from.Name != "Expense Tracker" || from.Address != "alerts@example.com" {
+		t.Errorf("From: %q, want Expense Tracker <alerts@example.com>", msg.Header.Get("From"))
+	}
+
+	if to := msg.Header.Get("To"); to != "<ana@example.com>" {
+		t.Errorf("To: %q, want <ana@example.com>", to)
+	}
+
+	subject := msg.Header.Get("Subject")
+	if want := "=?utf-8?q?Caf=C3=A9_budget_reached_80%?="; subject != want {
+		t.Errorf("Subject: %q, want %q", subject, want)
+	}
+
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	if err != nil || decoded != n.Title {
+		t.Errorf("decoded Subject: %q (%v), want %q", decoded, err, n.Title)
+	}
+
+	if ct := msg.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type: %q, want text/plain; charset=utf-8", ct)
+	}
+
+	if msg.Header.Get("Date") == "" {
+		t.Error("Date header is missing")
+	}
+
+	body := new(strings.Builder)
+	if _, err := bufio.NewReader(msg.Body).WriteTo(body); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := n.Message + "\n"; body.String() != want {
+		t.Errorf("body %q, want %q", body.String(), want)
+	}
+}
+
+func TestEmailWithoutAddress(t *testing.T) {
+	// Nothing listens on the address, so sending would fail
+	notifier := &Email{Mailer: &mailer.SMTP{
+		Addr: "127.0.0.1:1",
+		From: "alerts@example.com",
+	}}
+
+	if err := notifier.Notify(context.Background(), Notification{Title: "Budget reached 80%"}); err != nil {
+		t.Errorf("Notify() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of notification
+const (
+	KindBudgetAlert = "budget_alert"
+)
+
+// Notification is a message to a user. Email is where it is sent by email
+// and Data holds the details for the clients, as a JSON object.
+type Notification struct {
+	UserID  uuid.UUID       `json:"user_id"`
+	Email   string          `json:"-"`
+	Kind    string          `json:"kind"`
+	Title   string          `json:"title"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Notifier delivers notifications to the users
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Multi delivers the notifications through every notifier, a failing one
+// does not stop the others.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
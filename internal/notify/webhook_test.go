@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWebhook(t *testing.T) {
+	n := Notification{
+		UserID:  uuid.MustParse("527fef18-e8f9-4899-b807-3c9c94415b31"),
+		Email:   "ana@example.com",
+		Kind:    KindBudgetAlert,
+		Title:   "Food budget reached 80%",
+		Message: "You spent 40.00 EUR of the 50.00 EUR budgeted for Food.",
+		Data:    json.RawMessage(`{"threshold":80}`),
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		status  int
+		wantErr bool
+	}{
+		{name: "unsigned", status: http.StatusNoContent},
+		{name: "signed", secret: "s3cret", status: http.StatusOK},
+		{name: "failing", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			var body []byte
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			wh := &Webhook{URL: srv.URL, Secret: tt.secret, Client: srv.Client()}
+
+			err := wh.Notify(context.Background(), n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() = %v, want error %v", err, tt.wantErr)
+			}
+
+			if req.Method != http.MethodPost {
+				t.Errorf("method %s, want POST", req.Method)
+			}
+
+			if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type: %q, want application/json", ct)
+			}
+
+			signature := req.Header.Get("X-Signature")
+			if tt.secret == "" {
+				if signature != "" {
+					t.Errorf("X-Signature: %q, want none", signature)
+				}
+			} else {
+				mac := hmac.New(sha256.New, []byte(tt.secret))
+				mac.Write(body)
+				if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+					t.Errorf("X-Signature: %q, want %q", signature, want)
+				}
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("body %s: %v", body, err)
+			}
+
+			if _, ok := got["email"]; ok {
+				t.Errorf("body %s has the email of the user", body)
+			}
+
+			if got["user_id"] != n.UserID.String() || got["kind"] != n.Kind || got["title"] != n.Title {
+				t.Errorf("body %s does not match the notification", body)
+			}
+		})
+	}
+}
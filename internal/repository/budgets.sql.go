@@ -10,25 +10,66 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
 )
 
+const addBudgetCategories = `-- name: AddBudgetCategories :exec
+INSERT INTO budget_categories (budget_id, category_id)
+SELECT $1::uuid, unnest($2::uuid[])
+ON CONFLICT DO NOTHING
+`
+
+type AddBudgetCategoriesParams struct {
+	BudgetID    uuid.UUID   `json:"budget_id"`
+	CategoryIds []uuid.UUID `json:"category_ids"`
+}
+
+func (q *Queries) AddBudgetCategories(ctx context.Context, arg AddBudgetCategoriesParams) error {
+	_, err := q.db.Exec(ctx, addBudgetCategories, arg.BudgetID, arg.CategoryIds)
+	return err
+}
+
+const closeBudgetPeriod = `-- name: CloseBudgetPeriod :exec
+UPDATE budgets SET closed_at = $1, updated_at = $1 WHERE id = $2
+`
+
+type CloseBudgetPeriodParams struct {
+	ClosedAt pgtype.Timestamptz `json:"closed_at"`
+	ID       uuid.UUID          `json:"id"`
+}
+
+func (q *Queries) CloseBudgetPeriod(ctx context.Context, arg CloseBudgetPeriodParams) error {
+	_, err := q.db.Exec(ctx, closeBudgetPeriod, arg.ClosedAt, arg.ID)
+	return err
+}
+
 const createBudget = `-- name: CreateBudget :one
-INSERT INTO budgets (id, created_at, updated_at, amount, goal, start_date, end_date, user_id, category_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, created_at, updated_at, amount, goal, start_date, end_date, user_id, category_id
+INSERT INTO budgets (
+    id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency,
+    period, series_id, rollover, rollover_cap, carried, thresholds, time_zone
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone
 `
 
 type CreateBudgetParams struct {
-	ID         uuid.UUID       `json:"id"`
-	CreatedAt  time.Time       `json:"created_at"`
-	UpdatedAt  time.Time       `json:"updated_at"`
-	Amount     decimal.Decimal `json:"amount"`
-	Goal       decimal.Decimal `json:"goal"`
-	StartDate  time.Time       `json:"start_date"`
-	EndDate    time.Time       `json:"end_date"`
-	UserID     uuid.UUID       `json:"user_id"`
-	CategoryID uuid.UUID       `json:"category_id"`
+	ID          uuid.UUID       `json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Amount      decimal.Decimal `json:"amount"`
+	Goal        decimal.Decimal `json:"goal"`
+	StartDate   time.Time       `json:"start_date"`
+	EndDate     time.Time       `json:"end_date"`
+	WorkspaceID uuid.UUID       `json:"workspace_id"`
+	Currency    string          `json:"currency"`
+	Period      pgtype.Text     `json:"period"`
+	SeriesID    pgtype.UUID     `json:"series_id"`
+	Rollover    string          `json:"rollover"`
+	RolloverCap decimal.Decimal `json:"rollover_cap"`
+	Carried     decimal.Decimal `json:"carried"`
+	Thresholds  []int32         `json:"thresholds"`
+	TimeZone    string          `json:"time_zone"`
 }
 
 func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error) {
@@ -40,8 +81,15 @@ func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Bud
 		arg.Goal,
 		arg.StartDate,
 		arg.EndDate,
-		arg.UserID,
-		arg.CategoryID,
+		arg.WorkspaceID,
+		arg.Currency,
+		arg.Period,
+		arg.SeriesID,
+		arg.Rollover,
+		arg.RolloverCap,
+		arg.Carried,
+		arg.Thresholds,
+		arg.TimeZone,
 	)
 	var i Budget
 	err := row.Scan(
@@ -52,23 +100,32 @@ func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Bud
 		&i.Goal,
 		&i.StartDate,
 		&i.EndDate,
-		&i.UserID,
-		&i.CategoryID,
+		&i.WorkspaceID,
+		&i.Currency,
+		&i.Period,
+		&i.SeriesID,
+		&i.ClosedAt,
+		&i.Rollover,
+		&i.RolloverCap,
+		&i.Carried,
+		&i.EffectiveGoal,
+		&i.Thresholds,
+		&i.TimeZone,
 	)
 	return i, err
 }
 
 const deleteBudget = `-- name: DeleteBudget :one
-DELETE FROM budgets WHERE id = $1 AND user_id = $2 RETURNING id, created_at, updated_at, amount, goal, start_date, end_date, user_id, category_id
+DELETE FROM budgets WHERE id = $1 AND workspace_id = $2 RETURNING id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone
 `
 
 type DeleteBudgetParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
 }
 
 func (q *Queries) DeleteBudget(ctx context.Context, arg DeleteBudgetParams) (Budget, error) {
-	row := q.db.QueryRow(ctx, deleteBudget, arg.ID, arg.UserID)
+	row := q.db.QueryRow(ctx, deleteBudget, arg.ID, arg.WorkspaceID)
 	var i Budget
 	err := row.Scan(
 		&i.ID,
@@ -78,23 +135,63 @@ func (q *Queries) DeleteBudget(ctx context.Context, arg DeleteBudgetParams) (Bud
 		&i.Goal,
 		&i.StartDate,
 		&i.EndDate,
-		&i.UserID,
-		&i.CategoryID,
+		&i.WorkspaceID,
+		&i.Currency,
+		&i.Period,
+		&i.SeriesID,
+		&i.ClosedAt,
+		&i.Rollover,
+		&i.RolloverCap,
+		&i.Carried,
+		&i.EffectiveGoal,
+		&i.Thresholds,
+		&i.TimeZone,
 	)
 	return i, err
 }
 
+const deleteBudgetCategories = `-- name: DeleteBudgetCategories :exec
+DELETE FROM budget_categories WHERE budget_id = $1
+`
+
+func (q *Queries) DeleteBudgetCategories(ctx context.Context, budgetID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBudgetCategories, budgetID)
+	return err
+}
+
+const deleteCategoryOnlyBudgets = `-- name: DeleteCategoryOnlyBudgets :exec
+DELETE FROM budgets b WHERE b.workspace_id = $1
+AND EXISTS (
+    SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id AND bc.category_id = $2
+)
+AND NOT EXISTS (
+    SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id AND bc.category_id <> $2
+)
+`
+
+type DeleteCategoryOnlyBudgetsParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	CategoryID  uuid.UUID `json:"category_id"`
+}
+
+// Budgets of a category being deleted that have no other category, they
+// would become account-wide otherwise
+func (q *Queries) DeleteCategoryOnlyBudgets(ctx context.Context, arg DeleteCategoryOnlyBudgetsParams) error {
+	_, err := q.db.Exec(ctx, deleteCategoryOnlyBudgets, arg.WorkspaceID, arg.CategoryID)
+	return err
+}
+
 const getBudgetByID = `-- name: GetBudgetByID :one
-SELECT id, created_at, updated_at, amount, goal, start_date, end_date, user_id, category_id FROM budgets WHERE id = $1 AND user_id = $2
+SELECT id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone FROM budgets WHERE id = $1 AND workspace_id = $2
 `
 
 type GetBudgetByIDParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
 }
 
 func (q *Queries) GetBudgetByID(ctx context.Context, arg GetBudgetByIDParams) (Budget, error) {
-	row := q.db.QueryRow(ctx, getBudgetByID, arg.ID, arg.UserID)
+	row := q.db.QueryRow(ctx, getBudgetByID, arg.ID, arg.WorkspaceID)
 	var i Budget
 	err := row.Scan(
 		&i.ID,
@@ -104,25 +201,170 @@ func (q *Queries) GetBudgetByID(ctx context.Context, arg GetBudgetByIDParams) (B
 		&i.Goal,
 		&i.StartDate,
 		&i.EndDate,
-		&i.UserID,
-		&i.CategoryID,
+		&i.WorkspaceID,
+		&i.Currency,
+		&i.Period,
+		&i.SeriesID,
+		&i.ClosedAt,
+		&i.Rollover,
+		&i.RolloverCap,
+		&i.Carried,
+		&i.EffectiveGoal,
+		&i.Thresholds,
+		&i.TimeZone,
 	)
 	return i, err
 }
 
+const getBudgetPeriods = `-- name: GetBudgetPeriods :many
+SELECT id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone FROM budgets WHERE series_id = $1 AND workspace_id = $2
+ORDER BY start_date DESC
+`
+
+type GetBudgetPeriodsParams struct {
+	SeriesID    pgtype.UUID `json:"series_id"`
+	WorkspaceID uuid.UUID   `json:"workspace_id"`
+}
+
+// Every period of a recurring budget, the latest first
+func (q *Queries) GetBudgetPeriods(ctx context.Context, arg GetBudgetPeriodsParams) ([]Budget, error) {
+	rows, err := q.db.Query(ctx, getBudgetPeriods, arg.SeriesID, arg.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Budget
+	for rows.Next() {
+		var i Budget
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Amount,
+			&i.Goal,
+			&i.StartDate,
+			&i.EndDate,
+			&i.WorkspaceID,
+			&i.Currency,
+			&i.Period,
+			&i.SeriesID,
+			&i.ClosedAt,
+			&i.Rollover,
+			&i.RolloverCap,
+			&i.Carried,
+			&i.EffectiveGoal,
+			&i.Thresholds,
+			&i.TimeZone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBudgetsCategories = `-- name: GetBudgetsCategories :many
+SELECT budget_categories.budget_id, categories.id, categories.name FROM budget_categories
+JOIN categories ON categories.id = budget_categories.category_id
+WHERE budget_categories.budget_id = ANY($1::uuid[])
+ORDER BY categories.name ASC
+`
+
+type GetBudgetsCategoriesRow struct {
+	BudgetID uuid.UUID `json:"budget_id"`
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+}
+
+// Categories of each budget, for the budgets in a page
+func (q *Queries) GetBudgetsCategories(ctx context.Context, budgetIds []uuid.UUID) ([]GetBudgetsCategoriesRow, error) {
+	rows, err := q.db.Query(ctx, getBudgetsCategories, budgetIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBudgetsCategoriesRow
+	for rows.Next() {
+		var i GetBudgetsCategoriesRow
+		if err := rows.Scan(&i.BudgetID, &i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueBudgetPeriods = `-- name: GetDueBudgetPeriods :many
+SELECT id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone FROM budgets
+WHERE period IS NOT NULL AND closed_at IS NULL AND end_date < $1
+ORDER BY end_date ASC
+LIMIT $2
+`
+
+type GetDueBudgetPeriodsParams struct {
+	DueAt    time.Time `json:"due_at"`
+	PageSize int32     `json:"page_size"`
+}
+
+// Used by the scheduler, so it is not scoped to a workspace
+func (q *Queries) GetDueBudgetPeriods(ctx context.Context, arg GetDueBudgetPeriodsParams) ([]Budget, error) {
+	rows, err := q.db.Query(ctx, getDueBudgetPeriods, arg.DueAt, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Budget
+	for rows.Next() {
+		var i Budget
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Amount,
+			&i.Goal,
+			&i.StartDate,
+			&i.EndDate,
+			&i.WorkspaceID,
+			&i.Currency,
+			&i.Period,
+			&i.SeriesID,
+			&i.ClosedAt,
+			&i.Rollover,
+			&i.RolloverCap,
+			&i.Carried,
+			&i.EffectiveGoal,
+			&i.Thresholds,
+			&i.TimeZone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserBudgets = `-- name: GetUserBudgets :many
-SELECT id, created_at, updated_at, amount, goal, start_date, end_date, user_id, category_id FROM budgets WHERE user_id = $1
+SELECT id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone FROM budgets WHERE workspace_id = $1
 ORDER BY created_at ASC, id DESC
 LIMIT $2
 `
 
 type GetUserBudgetsParams struct {
-	UserID uuid.UUID `json:"user_id"`
-	Limit  int32     `json:"limit"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	Limit       int32     `json:"limit"`
 }
 
 func (q *Queries) GetUserBudgets(ctx context.Context, arg GetUserBudgetsParams) ([]Budget, error) {
-	rows, err := q.db.Query(ctx, getUserBudgets, arg.UserID, arg.Limit)
+	rows, err := q.db.Query(ctx, getUserBudgets, arg.WorkspaceID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +380,17 @@ func (q *Queries) GetUserBudgets(ctx context.Context, arg GetUserBudgetsParams)
 			&i.Goal,
 			&i.StartDate,
 			&i.EndDate,
-			&i.UserID,
-			&i.CategoryID,
+			&i.WorkspaceID,
+			&i.Currency,
+			&i.Period,
+			&i.SeriesID,
+			&i.ClosedAt,
+			&i.Rollover,
+			&i.RolloverCap,
+			&i.Carried,
+			&i.EffectiveGoal,
+			&i.Thresholds,
+			&i.TimeZone,
 		); err != nil {
 			return nil, err
 		}
@@ -152,22 +403,22 @@ func (q *Queries) GetUserBudgets(ctx context.Context, arg GetUserBudgetsParams)
 }
 
 const getUserBudgetsPaged = `-- name: GetUserBudgetsPaged :many
-SELECT id, created_at, updated_at, amount, goal, start_date, end_date, user_id, category_id FROM budgets WHERE user_id = $1
+SELECT id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone FROM budgets WHERE workspace_id = $1
 AND created_at >= $2 AND id < $3
 ORDER BY created_at ASC, id DESC
 LIMIT $4
 `
 
 type GetUserBudgetsPagedParams struct {
-	UserID    uuid.UUID `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	ID        uuid.UUID `json:"id"`
-	Limit     int32     `json:"limit"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ID          uuid.UUID `json:"id"`
+	Limit       int32     `json:"limit"`
 }
 
 func (q *Queries) GetUserBudgetsPaged(ctx context.Context, arg GetUserBudgetsPagedParams) ([]Budget, error) {
 	rows, err := q.db.Query(ctx, getUserBudgetsPaged,
-		arg.UserID,
+		arg.WorkspaceID,
 		arg.CreatedAt,
 		arg.ID,
 		arg.Limit,
@@ -187,8 +438,17 @@ func (q *Queries) GetUserBudgetsPaged(ctx context.Context, arg GetUserBudgetsPag
 			&i.Goal,
 			&i.StartDate,
 			&i.EndDate,
-			&i.UserID,
-			&i.CategoryID,
+			&i.WorkspaceID,
+			&i.Currency,
+			&i.Period,
+			&i.SeriesID,
+			&i.ClosedAt,
+			&i.Rollover,
+			&i.RolloverCap,
+			&i.Carried,
+			&i.EffectiveGoal,
+			&i.Thresholds,
+			&i.TimeZone,
 		); err != nil {
 			return nil, err
 		}
@@ -200,20 +460,131 @@ func (q *Queries) GetUserBudgetsPaged(ctx context.Context, arg GetUserBudgetsPag
 	return items, nil
 }
 
+const recalculateUserBudgetAmounts = `-- name: RecalculateUserBudgetAmounts :exec
+WITH RECURSIVE tree AS (
+    SELECT categories.id AS root_id, categories.id FROM categories WHERE categories.workspace_id = $1
+    UNION ALL
+    SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+UPDATE budgets b SET amount = COALESCE((
+    SELECT SUM(COALESCE(es.base_amount, e.base_amount)) FROM expenses e
+    LEFT JOIN expense_splits es ON es.expense_id = e.id
+    WHERE e.workspace_id = b.workspace_id AND e.spent_at >= b.start_date AND e.spent_at <= b.end_date
+    AND (
+        NOT EXISTS (SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id)
+        OR COALESCE(es.category_id, e.category_id) IN (
+            SELECT t.id FROM tree t
+            JOIN budget_categories bc ON bc.category_id = t.root_id
+            WHERE bc.budget_id = b.id
+        )
+    )
+), 0)
+WHERE b.workspace_id = $1 AND b.closed_at IS NULL
+`
+
+// Used when the category tree changes, closed periods keep their final amount.
+// Split expenses count each split against its own category
+func (q *Queries) RecalculateUserBudgetAmounts(ctx context.Context, workspaceID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, recalculateUserBudgetAmounts, workspaceID)
+	return err
+}
+
+const updateBudget = `-- name: UpdateBudget :one
+UPDATE budgets SET goal = $1, start_date = $2, end_date = $3, amount = $4,
+    period = $5, series_id = $6, rollover = $7, rollover_cap = $8, thresholds = $9, updated_at = $10
+WHERE id = $11 AND workspace_id = $12 AND closed_at IS NULL RETURNING id, created_at, updated_at, amount, goal, start_date, end_date, workspace_id, currency, period, series_id, closed_at, rollover, rollover_cap, carried, effective_goal, thresholds, time_zone
+`
+
+type UpdateBudgetParams struct {
+	Goal        decimal.Decimal `json:"goal"`
+	StartDate   time.Time       `json:"start_date"`
+	EndDate     time.Time       `json:"end_date"`
+	Amount      decimal.Decimal `json:"amount"`
+	Period      pgtype.Text     `json:"period"`
+	SeriesID    pgtype.UUID     `json:"series_id"`
+	Rollover    string          `json:"rollover"`
+	RolloverCap decimal.Decimal `json:"rollover_cap"`
+	Thresholds  []int32         `json:"thresholds"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	ID          uuid.UUID       `json:"id"`
+	WorkspaceID uuid.UUID       `json:"workspace_id"`
+}
+
+// Closed periods are read-only
+func (q *Queries) UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Budget, error) {
+	row := q.db.QueryRow(ctx, updateBudget,
+		arg.Goal,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Amount,
+		arg.Period,
+		arg.SeriesID,
+		arg.Rollover,
+		arg.RolloverCap,
+		arg.Thresholds,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.WorkspaceID,
+	)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Amount,
+		&i.Goal,
+		&i.StartDate,
+		&i.EndDate,
+		&i.WorkspaceID,
+		&i.Currency,
+		&i.Period,
+		&i.SeriesID,
+		&i.ClosedAt,
+		&i.Rollover,
+		&i.RolloverCap,
+		&i.Carried,
+		&i.EffectiveGoal,
+		&i.Thresholds,
+		&i.TimeZone,
+	)
+	return i, err
+}
+
 const updateBudgetAmount = `-- name: UpdateBudgetAmount :exec
-UPDATE budgets SET amount = amount + $2
-WHERE category_id = $1 AND start_date <= $3 AND end_date >= $3
+WITH RECURSIVE ancestors AS (
+    SELECT categories.id, categories.parent_id FROM categories WHERE categories.id = $1
+    UNION
+    SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+)
+UPDATE budgets b SET amount = b.amount + $2
+WHERE b.workspace_id = $3 AND b.closed_at IS NULL
+AND b.start_date <= $4 AND b.end_date >= $4
+AND (
+    NOT EXISTS (SELECT 1 FROM budget_categories bc WHERE bc.budget_id = b.id)
+    OR EXISTS (
+        SELECT 1 FROM budget_categories bc
+        WHERE bc.budget_id = b.id AND bc.category_id IN (SELECT ancestors.id FROM ancestors)
+    )
+)
 `
 
 type UpdateBudgetAmountParams struct {
-	CategoryID uuid.UUID       `json:"category_id"`
-	Amount     decimal.Decimal `json:"amount"`
-	StartDate  time.Time       `json:"start_date"`
+	CategoryID  uuid.UUID       `json:"category_id"`
+	BaseAmount  decimal.Decimal `json:"base_amount"`
+	WorkspaceID uuid.UUID       `json:"workspace_id"`
+	SpentAt     time.Time       `json:"spent_at"`
 }
 
 // Since UpdateBudgetAmount is only called by the API, there is no need to
-// check if the user is the owner of the budget since the API already does that
+// check if the budget belongs to the workspace since the API already does that.
+// Budgets are in the base currency of the workspace. The budgets of the parent categories include the expenses of their children,
+// the account-wide ones, without categories, include every expense. A budget with several matching categories counts it once.
 func (q *Queries) UpdateBudgetAmount(ctx context.Context, arg UpdateBudgetAmountParams) error {
-	_, err := q.db.Exec(ctx, updateBudgetAmount, arg.CategoryID, arg.Amount, arg.StartDate)
+	_, err := q.db.Exec(ctx, updateBudgetAmount,
+		arg.CategoryID,
+		arg.BaseAmount,
+		arg.WorkspaceID,
+		arg.SpentAt,
+	)
 	return err
 }
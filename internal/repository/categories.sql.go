@@ -10,20 +10,39 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countUserCategories = `-- name: CountUserCategories :one
+SELECT COUNT(*) FROM categories WHERE workspace_id = $1 AND id = ANY($2::uuid[])
+`
+
+type CountUserCategoriesParams struct {
+	WorkspaceID uuid.UUID   `json:"workspace_id"`
+	Ids         []uuid.UUID `json:"ids"`
+}
+
+// Used to check that all the given categories belong to the workspace
+func (q *Queries) CountUserCategories(ctx context.Context, arg CountUserCategoriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUserCategories, arg.WorkspaceID, arg.Ids)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createCategory = `-- name: CreateCategory :one
-INSERT INTO categories (id, created_at, updated_at, name, user_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, name, user_id
+INSERT INTO categories (id, created_at, updated_at, name, workspace_id, parent_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, name, workspace_id, parent_id
 `
 
 type CreateCategoryParams struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Name      string    `json:"name"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID          uuid.UUID   `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Name        string      `json:"name"`
+	WorkspaceID uuid.UUID   `json:"workspace_id"`
+	ParentID    pgtype.UUID `json:"parent_id"`
 }
 
 func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
@@ -32,7 +51,8 @@ func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams)
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
-		arg.UserID,
+		arg.WorkspaceID,
+		arg.ParentID,
 	)
 	var i Category
 	err := row.Scan(
@@ -40,68 +60,103 @@ func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.ParentID,
 	)
 	return i, err
 }
 
 const deleteCategory = `-- name: DeleteCategory :one
-DELETE FROM categories WHERE id = $1 AND user_id = $2 RETURNING id, created_at, updated_at, name, user_id
+DELETE FROM categories WHERE id = $1 AND workspace_id = $2 RETURNING id, created_at, updated_at, name, workspace_id, parent_id
 `
 
 type DeleteCategoryParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
 }
 
 func (q *Queries) DeleteCategory(ctx context.Context, arg DeleteCategoryParams) (Category, error) {
-	row := q.db.QueryRow(ctx, deleteCategory, arg.ID, arg.UserID)
+	row := q.db.QueryRow(ctx, deleteCategory, arg.ID, arg.WorkspaceID)
 	var i Category
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.ParentID,
 	)
 	return i, err
 }
 
+const getAllUserCategories = `-- name: GetAllUserCategories :many
+SELECT id, created_at, updated_at, name, workspace_id, parent_id FROM categories WHERE workspace_id = $1
+ORDER BY name ASC
+`
+
+func (q *Queries) GetAllUserCategories(ctx context.Context, workspaceID uuid.UUID) ([]Category, error) {
+	rows, err := q.db.Query(ctx, getAllUserCategories, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.WorkspaceID,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getCategoryByID = `-- name: GetCategoryByID :one
-SELECT id, created_at, updated_at, name, user_id FROM categories WHERE id = $1 AND user_id = $2
+SELECT id, created_at, updated_at, name, workspace_id, parent_id FROM categories WHERE id = $1 AND workspace_id = $2
 `
 
 type GetCategoryByIDParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
 }
 
 func (q *Queries) GetCategoryByID(ctx context.Context, arg GetCategoryByIDParams) (Category, error) {
-	row := q.db.QueryRow(ctx, getCategoryByID, arg.ID, arg.UserID)
+	row := q.db.QueryRow(ctx, getCategoryByID, arg.ID, arg.WorkspaceID)
 	var i Category
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.ParentID,
 	)
 	return i, err
 }
 
 const getUserCategories = `-- name: GetUserCategories :many
-SELECT id, created_at, updated_at, name, user_id FROM categories WHERE user_id = $1
+SELECT id, created_at, updated_at, name, workspace_id, parent_id FROM categories WHERE workspace_id = $1
 ORDER BY created_at ASC, id DESC
 LIMIT $2
 `
 
 type GetUserCategoriesParams struct {
-	UserID uuid.UUID `json:"user_id"`
-	Limit  int32     `json:"limit"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	Limit       int32     `json:"limit"`
 }
 
 func (q *Queries) GetUserCategories(ctx context.Context, arg GetUserCategoriesParams) ([]Category, error) {
-	rows, err := q.db.Query(ctx, getUserCategories, arg.UserID, arg.Limit)
+	rows, err := q.db.Query(ctx, getUserCategories, arg.WorkspaceID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +169,8 @@ func (q *Queries) GetUserCategories(ctx context.Context, arg GetUserCategoriesPa
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
-			&i.UserID,
+			&i.WorkspaceID,
+			&i.ParentID,
 		); err != nil {
 			return nil, err
 		}
@@ -127,22 +183,22 @@ func (q *Queries) GetUserCategories(ctx context.Context, arg GetUserCategoriesPa
 }
 
 const getUserCategoriesPaged = `-- name: GetUserCategoriesPaged :many
-SELECT id, created_at, updated_at, name, user_id FROM categories WHERE user_id = $1
+SELECT id, created_at, updated_at, name, workspace_id, parent_id FROM categories WHERE workspace_id = $1
 AND created_at >= $2 AND id < $3
 ORDER BY created_at ASC, id DESC
 LIMIT $4
 `
 
 type GetUserCategoriesPagedParams struct {
-	UserID    uuid.UUID `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	ID        uuid.UUID `json:"id"`
-	Limit     int32     `json:"limit"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ID          uuid.UUID `json:"id"`
+	Limit       int32     `json:"limit"`
 }
 
 func (q *Queries) GetUserCategoriesPaged(ctx context.Context, arg GetUserCategoriesPagedParams) ([]Category, error) {
 	rows, err := q.db.Query(ctx, getUserCategoriesPaged,
-		arg.UserID,
+		arg.WorkspaceID,
 		arg.CreatedAt,
 		arg.ID,
 		arg.Limit,
@@ -159,7 +215,8 @@ func (q *Queries) GetUserCategoriesPaged(ctx context.Context, arg GetUserCategor
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
-			&i.UserID,
+			&i.WorkspaceID,
+			&i.ParentID,
 		); err != nil {
 			return nil, err
 		}
@@ -171,24 +228,48 @@ func (q *Queries) GetUserCategoriesPaged(ctx context.Context, arg GetUserCategor
 	return items, nil
 }
 
+const isCategoryInSubtree = `-- name: IsCategoryInSubtree :one
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = $1
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE subtree.id = $2::uuid) AS in_subtree
+`
+
+type IsCategoryInSubtreeParams struct {
+	RootID     uuid.UUID `json:"root_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+}
+
+// Whether category_id is root_id or one of its descendants
+func (q *Queries) IsCategoryInSubtree(ctx context.Context, arg IsCategoryInSubtreeParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isCategoryInSubtree, arg.RootID, arg.CategoryID)
+	var inSubtree bool
+	err := row.Scan(&inSubtree)
+	return inSubtree, err
+}
+
 const updateCategory = `-- name: UpdateCategory :one
-UPDATE categories SET name = $1 AND updated_at = $2
-WHERE id = $3 AND user_id = $4 RETURNING id, created_at, updated_at, name, user_id
+UPDATE categories SET name = $1, parent_id = $2, updated_at = $3
+WHERE id = $4 AND workspace_id = $5 RETURNING id, created_at, updated_at, name, workspace_id, parent_id
 `
 
 type UpdateCategoryParams struct {
-	Name      string    `json:"name"`
-	UpdatedAt time.Time `json:"updated_at"`
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
+	Name        string      `json:"name"`
+	ParentID    pgtype.UUID `json:"parent_id"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	ID          uuid.UUID   `json:"id"`
+	WorkspaceID uuid.UUID   `json:"workspace_id"`
 }
 
 func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error) {
 	row := q.db.QueryRow(ctx, updateCategory,
 		arg.Name,
+		arg.ParentID,
 		arg.UpdatedAt,
 		arg.ID,
-		arg.UserID,
+		arg.WorkspaceID,
 	)
 	var i Category
 	err := row.Scan(
@@ -196,7 +277,8 @@ func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.ParentID,
 	)
 	return i, err
 }
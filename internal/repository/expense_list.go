@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+// sqlc can not generate a query with a dynamic order, so the expenses list is
+// written by hand. The filters are static and skipped when empty, only the
+// sort column, taken from expenseSortColumns, and direction change the query.
+
+// What the expenses can be sorted by
+const (
+	ExpenseSortAmount      = "amount"
+	ExpenseSortDate        = "date"
+	ExpenseSortDescription = "description"
+)
+
+var ErrInvalidExpenseSort = errors.New("invalid expense sort")
+
+// Amounts are compared in the base currency, so expenses in different
+// currencies are sorted and filtered consistently
+var expenseSortColumns = map[string]struct{ column, dbType string }{
+	ExpenseSortAmount:      {"e.base_amount", "numeric"},
+	ExpenseSortDate:        {"e.spent_at", "timestamptz"},
+	ExpenseSortDescription: {"e.description", "text"},
+}
+
+// ListExpensesParams filters the expenses of the workspace, empty values leave
+// a filter out. The cursor is the sort value and ID of the last expense of the
+// previous page, CursorValue must match the type of the sort column.
+type ListExpensesParams struct {
+	WorkspaceID uuid.UUID
+	CategoryIDs []uuid.UUID
+	AccountID   pgtype.UUID
+	MinAmount   decimal.NullDecimal
+	MaxAmount   decimal.NullDecimal
+	StartDate   pgtype.Timestamptz
+	EndDate     pgtype.Timestamptz
+	Description string
+	Merchant    string
+	Tags        []string
+	MinMatches  int32
+	Sort        string
+	Desc        bool
+	CursorValue interface{}
+	CursorID    pgtype.UUID
+	PageSize    int32
+}
+
+// Filtering by categories includes their subcategories and the expenses with
+// a split in them, and by tags keeps the expenses with at least min_matches
+// of them
+const listExpenses = `WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = ANY($2::uuid[])
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT e.* FROM expenses e
+WHERE e.workspace_id = $1
+AND (cardinality($2::uuid[]) = 0 OR e.category_id IN (SELECT subtree.id FROM subtree) OR EXISTS (
+    SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.category_id IN (SELECT subtree.id FROM subtree)
+))
+AND ($3::numeric IS NULL OR e.base_amount >= $3)
+AND ($4::numeric IS NULL OR e.base_amount <= $4)
+AND ($5::timestamptz IS NULL OR e.spent_at >= $5)
+AND ($6::timestamptz IS NULL OR e.spent_at <= $6)
+AND ($7::text IS NULL OR e.description ILIKE $7)
+AND ($8::text IS NULL OR e.merchant ILIKE $8)
+AND (cardinality($9::text[]) = 0 OR (
+    SELECT COUNT(*) FROM expense_tags JOIN tags ON tags.id = expense_tags.tag_id
+    WHERE expense_tags.expense_id = e.id AND tags.name = ANY($9::text[])
+) >= $10::int)
+AND ($11::uuid IS NULL OR e.account_id = $11)
+`
+
+func (q *Queries) ListExpenses(ctx context.Context, arg ListExpensesParams) ([]Expense, error) {
+	sort, ok := expenseSortColumns[arg.Sort]
+	if !ok {
+		return nil, ErrInvalidExpenseSort
+	}
+
+	categoryIDs := arg.CategoryIDs
+	if categoryIDs == nil {
+		categoryIDs = []uuid.UUID{}
+	}
+
+	tags := arg.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	args := []interface{}{
+		arg.WorkspaceID,
+		categoryIDs,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.StartDate,
+		arg.EndDate,
+		containsPattern(arg.Description),
+		containsPattern(arg.Merchant),
+		tags,
+		arg.MinMatches,
+		arg.AccountID,
+	}
+
+	direction, comparison := "ASC", ">"
+	if arg.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	var query strings.Builder
+	query.WriteString(listExpenses)
+
+	if arg.CursorID.Valid {
+		args = append(args, arg.CursorValue, arg.CursorID)
+		fmt.Fprintf(&query, "AND (%s, e.id) %s ($%d::%s, $%d::uuid)\n",
+			sort.column, comparison, len(args)-1, sort.dbType, len(args))
+	}
+
+	args = append(args, arg.PageSize)
+	fmt.Fprintf(&query, "ORDER BY %s %s, e.id %s\nLIMIT $%d", sort.column, direction, direction, len(args))
+
+	rows, err := q.db.Query(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[Expense])
+}
+
+// containsPattern is an ILIKE pattern matching s anywhere, NULL when empty
+func containsPattern(s string) pgtype.Text {
+	if s == "" {
+		return pgtype.Text{}
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+
+	return pgtype.Text{String: "%" + escaped + "%", Valid: true}
+}
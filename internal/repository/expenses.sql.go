@@ -10,27 +10,36 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
 )
 
 const createExpense = `-- name: CreateExpense :one
-
-INSERT INTO expenses (id, created_at, updated_at, description, amount, category_id, user_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, created_at, updated_at, description, amount, category_id, user_id
+INSERT INTO expenses (
+    id, created_at, updated_at, description, amount, category_id, workspace_id, spent_at,
+    recurring_expense_id, currency, base_amount, merchant, notes, account_id
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, created_at, updated_at, description, amount, category_id, workspace_id, spent_at, recurring_expense_id, currency, base_amount, merchant, notes, search_vector, account_id, cleared_at
 `
 
 type CreateExpenseParams struct {
-	ID          uuid.UUID       `json:"id"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	Description string          `json:"description"`
-	Amount      decimal.Decimal `json:"amount"`
-	CategoryID  uuid.UUID       `json:"category_id"`
-	UserID      uuid.UUID       `json:"user_id"`
+	ID                 uuid.UUID       `json:"id"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	Description        string          `json:"description"`
+	Amount             decimal.Decimal `json:"amount"`
+	CategoryID         uuid.UUID       `json:"category_id"`
+	WorkspaceID        uuid.UUID       `json:"workspace_id"`
+	SpentAt            time.Time       `json:"spent_at"`
+	RecurringExpenseID pgtype.UUID     `json:"recurring_expense_id"`
+	Currency           string          `json:"currency"`
+	BaseAmount         decimal.Decimal `json:"base_amount"`
+	Merchant           pgtype.Text     `json:"merchant"`
+	Notes              pgtype.Text     `json:"notes"`
+	AccountID          pgtype.UUID     `json:"account_id"`
 }
 
-// TODO: add query (and route) to get all expenses in a time interval
 func (q *Queries) CreateExpense(ctx context.Context, arg CreateExpenseParams) (Expense, error) {
 	row := q.db.QueryRow(ctx, createExpense,
 		arg.ID,
@@ -39,7 +48,14 @@ func (q *Queries) CreateExpense(ctx context.Context, arg CreateExpenseParams) (E
 		arg.Description,
 		arg.Amount,
 		arg.CategoryID,
-		arg.UserID,
+		arg.WorkspaceID,
+		arg.SpentAt,
+		arg.RecurringExpenseID,
+		arg.Currency,
+		arg.BaseAmount,
+		arg.Merchant,
+		arg.Notes,
+		arg.AccountID,
 	)
 	var i Expense
 	err := row.Scan(
@@ -49,22 +65,31 @@ func (q *Queries) CreateExpense(ctx context.Context, arg CreateExpenseParams) (E
 		&i.Description,
 		&i.Amount,
 		&i.CategoryID,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.SpentAt,
+		&i.RecurringExpenseID,
+		&i.Currency,
+		&i.BaseAmount,
+		&i.Merchant,
+		&i.Notes,
+		&i.SearchVector,
+		&i.AccountID,
+		&i.ClearedAt,
 	)
 	return i, err
 }
 
 const deleteExpense = `-- name: DeleteExpense :one
-DELETE FROM expenses WHERE id = $1 AND user_id = $2 RETURNING id, created_at, updated_at, description, amount, category_id, user_id
+DELETE FROM expenses WHERE id = $1 AND workspace_id = $2 RETURNING id, created_at, updated_at, description, amount, category_id, workspace_id, spent_at, recurring_expense_id, currency, base_amount, merchant, notes, search_vector, account_id, cleared_at
 `
 
 type DeleteExpenseParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
 }
 
 func (q *Queries) DeleteExpense(ctx context.Context, arg DeleteExpenseParams) (Expense, error) {
-	row := q.db.QueryRow(ctx, deleteExpense, arg.ID, arg.UserID)
+	row := q.db.QueryRow(ctx, deleteExpense, arg.ID, arg.WorkspaceID)
 	var i Expense
 	err := row.Scan(
 		&i.ID,
@@ -73,111 +98,31 @@ func (q *Queries) DeleteExpense(ctx context.Context, arg DeleteExpenseParams) (E
 		&i.Description,
 		&i.Amount,
 		&i.CategoryID,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.SpentAt,
+		&i.RecurringExpenseID,
+		&i.Currency,
+		&i.BaseAmount,
+		&i.Merchant,
+		&i.Notes,
+		&i.SearchVector,
+		&i.AccountID,
+		&i.ClearedAt,
 	)
 	return i, err
 }
 
-const getCategoryExpenses = `-- name: GetCategoryExpenses :many
-SELECT id, created_at, updated_at, description, amount, category_id, user_id FROM expenses WHERE category_id = $1 AND user_id = $2
-ORDER BY created_at DESC, id DESC
-LIMIT $3
-`
-
-type GetCategoryExpensesParams struct {
-	CategoryID uuid.UUID `json:"category_id"`
-	UserID     uuid.UUID `json:"user_id"`
-	Limit      int32     `json:"limit"`
-}
-
-func (q *Queries) GetCategoryExpenses(ctx context.Context, arg GetCategoryExpensesParams) ([]Expense, error) {
-	rows, err := q.db.Query(ctx, getCategoryExpenses, arg.CategoryID, arg.UserID, arg.Limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Expense
-	for rows.Next() {
-		var i Expense
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Description,
-			&i.Amount,
-			&i.CategoryID,
-			&i.UserID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getCategoryExpensesPaged = `-- name: GetCategoryExpensesPaged :many
-SELECT id, created_at, updated_at, description, amount, category_id, user_id FROM expenses WHERE category_id = $1 AND user_id = $2
-AND created_at <= $3 AND id < $4
-ORDER BY created_at DESC, id DESC
-LIMIT $5
-`
-
-type GetCategoryExpensesPagedParams struct {
-	CategoryID uuid.UUID `json:"category_id"`
-	UserID     uuid.UUID `json:"user_id"`
-	CreatedAt  time.Time `json:"created_at"`
-	ID         uuid.UUID `json:"id"`
-	Limit      int32     `json:"limit"`
-}
-
-func (q *Queries) GetCategoryExpensesPaged(ctx context.Context, arg GetCategoryExpensesPagedParams) ([]Expense, error) {
-	rows, err := q.db.Query(ctx, getCategoryExpensesPaged,
-		arg.CategoryID,
-		arg.UserID,
-		arg.CreatedAt,
-		arg.ID,
-		arg.Limit,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Expense
-	for rows.Next() {
-		var i Expense
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Description,
-			&i.Amount,
-			&i.CategoryID,
-			&i.UserID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const getExpenseByID = `-- name: GetExpenseByID :one
-SELECT id, created_at, updated_at, description, amount, category_id, user_id FROM expenses WHERE id = $1 AND user_id = $2
+SELECT id, created_at, updated_at, description, amount, category_id, workspace_id, spent_at, recurring_expense_id, currency, base_amount, merchant, notes, search_vector, account_id, cleared_at FROM expenses WHERE id = $1 AND workspace_id = $2
 `
 
 type GetExpenseByIDParams struct {
-	ID     uuid.UUID `json:"id"`
-	UserID uuid.UUID `json:"user_id"`
+	ID          uuid.UUID `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
 }
 
 func (q *Queries) GetExpenseByID(ctx context.Context, arg GetExpenseByIDParams) (Expense, error) {
-	row := q.db.QueryRow(ctx, getExpenseByID, arg.ID, arg.UserID)
+	row := q.db.QueryRow(ctx, getExpenseByID, arg.ID, arg.WorkspaceID)
 	var i Expense
 	err := row.Scan(
 		&i.ID,
@@ -186,81 +131,106 @@ func (q *Queries) GetExpenseByID(ctx context.Context, arg GetExpenseByIDParams)
 		&i.Description,
 		&i.Amount,
 		&i.CategoryID,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.SpentAt,
+		&i.RecurringExpenseID,
+		&i.Currency,
+		&i.BaseAmount,
+		&i.Merchant,
+		&i.Notes,
+		&i.SearchVector,
+		&i.AccountID,
+		&i.ClearedAt,
 	)
 	return i, err
 }
 
 const getTotalSpent = `-- name: GetTotalSpent :one
-SELECT CAST(SUM(amount) AS NUMERIC(10, 4)) FROM expenses
-WHERE user_id = $1 AND created_at >= $2 AND created_at <= $3
+SELECT CAST(COALESCE(SUM(base_amount), 0) AS NUMERIC(10, 4)) AS total FROM expenses
+WHERE workspace_id = $1 AND spent_at >= $2 AND spent_at <= $3
 `
 
 type GetTotalSpentParams struct {
-	UserID    uuid.UUID `json:"user_id"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
 }
 
 func (q *Queries) GetTotalSpent(ctx context.Context, arg GetTotalSpentParams) (decimal.Decimal, error) {
-	row := q.db.QueryRow(ctx, getTotalSpent, arg.UserID, arg.StartDate, arg.EndDate)
-	var column_1 decimal.Decimal
-	err := row.Scan(&column_1)
-	return column_1, err
+	row := q.db.QueryRow(ctx, getTotalSpent, arg.WorkspaceID, arg.StartDate, arg.EndDate)
+	var total decimal.Decimal
+	err := row.Scan(&total)
+	return total, err
 }
 
-const getTotalSpentInCategory = `-- name: GetTotalSpentInCategory :one
-SELECT CAST(SUM(amount) AS NUMERIC(10, 4)) FROM expenses
-WHERE user_id = $1 AND category_id = $2 AND created_at >= $3 AND created_at <= $4
+const getTotalSpentInCategories = `-- name: GetTotalSpentInCategories :one
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = ANY($1::uuid[])
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT CAST(COALESCE(SUM(COALESCE(es.base_amount, e.base_amount)), 0) AS NUMERIC(10, 4)) AS total
+FROM expenses e
+LEFT JOIN expense_splits es ON es.expense_id = e.id
+WHERE e.workspace_id = $2
+AND (cardinality($1::uuid[]) = 0 OR COALESCE(es.category_id, e.category_id) IN (SELECT subtree.id FROM subtree))
+AND e.spent_at >= $3 AND e.spent_at <= $4
 `
 
-type GetTotalSpentInCategoryParams struct {
-	UserID     uuid.UUID `json:"user_id"`
-	CategoryID uuid.UUID `json:"category_id"`
-	StartDate  time.Time `json:"start_date"`
-	EndDate    time.Time `json:"end_date"`
+type GetTotalSpentInCategoriesParams struct {
+	CategoryIds []uuid.UUID `json:"category_ids"`
+	WorkspaceID uuid.UUID   `json:"workspace_id"`
+	StartDate   time.Time   `json:"start_date"`
+	EndDate     time.Time   `json:"end_date"`
 }
 
-func (q *Queries) GetTotalSpentInCategory(ctx context.Context, arg GetTotalSpentInCategoryParams) (decimal.Decimal, error) {
-	row := q.db.QueryRow(ctx, getTotalSpentInCategory,
-		arg.UserID,
-		arg.CategoryID,
+// Includes the expenses of the subcategories, or every expense without categories.
+// Only the splits of split expenses in the categories count
+func (q *Queries) GetTotalSpentInCategories(ctx context.Context, arg GetTotalSpentInCategoriesParams) (decimal.Decimal, error) {
+	row := q.db.QueryRow(ctx, getTotalSpentInCategories,
+		arg.CategoryIds,
+		arg.WorkspaceID,
 		arg.StartDate,
 		arg.EndDate,
 	)
-	var column_1 decimal.Decimal
-	err := row.Scan(&column_1)
-	return column_1, err
+	var total decimal.Decimal
+	err := row.Scan(&total)
+	return total, err
 }
 
-const getUserExpenses = `-- name: GetUserExpenses :many
-SELECT id, created_at, updated_at, description, amount, category_id, user_id FROM expenses WHERE user_id = $1
-ORDER BY created_at DESC, id DESC
-LIMIT $2
+const getUserExpenseKeysInRange = `-- name: GetUserExpenseKeysInRange :many
+SELECT spent_at, amount, currency, description FROM expenses
+WHERE workspace_id = $1 AND spent_at >= $2 AND spent_at <= $3
 `
 
-type GetUserExpensesParams struct {
-	UserID uuid.UUID `json:"user_id"`
-	Limit  int32     `json:"limit"`
+type GetUserExpenseKeysInRangeParams struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
 }
 
-func (q *Queries) GetUserExpenses(ctx context.Context, arg GetUserExpensesParams) ([]Expense, error) {
-	rows, err := q.db.Query(ctx, getUserExpenses, arg.UserID, arg.Limit)
+type GetUserExpenseKeysInRangeRow struct {
+	SpentAt     time.Time       `json:"spent_at"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description"`
+}
+
+// Used to find duplicates when importing expenses
+func (q *Queries) GetUserExpenseKeysInRange(ctx context.Context, arg GetUserExpenseKeysInRangeParams) ([]GetUserExpenseKeysInRangeRow, error) {
+	rows, err := q.db.Query(ctx, getUserExpenseKeysInRange, arg.WorkspaceID, arg.StartDate, arg.EndDate)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Expense
+	var items []GetUserExpenseKeysInRangeRow
 	for rows.Next() {
-		var i Expense
+		var i GetUserExpenseKeysInRangeRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Description,
+			&i.SpentAt,
 			&i.Amount,
-			&i.CategoryID,
-			&i.UserID,
+			&i.Currency,
+			&i.Description,
 		); err != nil {
 			return nil, err
 		}
@@ -272,42 +242,93 @@ func (q *Queries) GetUserExpenses(ctx context.Context, arg GetUserExpensesParams
 	return items, nil
 }
 
-const getUserExpensesPaged = `-- name: GetUserExpensesPaged :many
-SELECT id, created_at, updated_at, description, amount, category_id, user_id FROM expenses WHERE user_id = $1
-AND created_at <= $2 AND id < $3
-ORDER BY created_at DESC, id DESC
-LIMIT $4
+const searchExpenses = `-- name: SearchExpenses :many
+WITH RECURSIVE subtree AS (
+    SELECT categories.id FROM categories WHERE categories.id = $1::uuid
+    UNION
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+),
+search AS (
+    SELECT to_tsquery('simple', $2::text) AS query
+)
+SELECT e.id, e.created_at, e.updated_at, e.description, e.amount, e.category_id, e.workspace_id, e.spent_at, e.recurring_expense_id, e.currency, e.base_amount, e.merchant, e.notes, e.search_vector, e.account_id, e.cleared_at,
+    CAST(ts_rank(e.search_vector, search.query) AS REAL) AS rank,
+    CAST(ts_headline(
+        'simple', concat_ws(' | ', e.description, e.merchant, e.notes), search.query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5'
+    ) AS TEXT) AS snippet
+FROM expenses e, search
+WHERE e.workspace_id = $3 AND e.search_vector @@ search.query
+AND ($4::timestamptz IS NULL OR e.spent_at >= $4)
+AND ($5::timestamptz IS NULL OR e.spent_at <= $5)
+AND ($1::uuid IS NULL OR e.category_id IN (SELECT subtree.id FROM subtree) OR EXISTS (
+    SELECT 1 FROM expense_splits es WHERE es.expense_id = e.id AND es.category_id IN (SELECT subtree.id FROM subtree)
+))
+AND (
+    $6::real IS NULL
+    OR ts_rank(e.search_vector, search.query) < $6
+    OR (ts_rank(e.search_vector, search.query) = $6 AND e.id < $7)
+)
+ORDER BY rank DESC, e.id DESC
+LIMIT $8
 `
 
-type GetUserExpensesPagedParams struct {
-	UserID    uuid.UUID `json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	ID        uuid.UUID `json:"id"`
-	Limit     int32     `json:"limit"`
+type SearchExpensesParams struct {
+	CategoryID  pgtype.UUID        `json:"category_id"`
+	Query       string             `json:"query"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	StartDate   pgtype.Timestamptz `json:"start_date"`
+	EndDate     pgtype.Timestamptz `json:"end_date"`
+	CursorRank  pgtype.Float4      `json:"cursor_rank"`
+	CursorID    uuid.UUID          `json:"cursor_id"`
+	PageSize    int32              `json:"page_size"`
 }
 
-func (q *Queries) GetUserExpensesPaged(ctx context.Context, arg GetUserExpensesPagedParams) ([]Expense, error) {
-	rows, err := q.db.Query(ctx, getUserExpensesPaged,
-		arg.UserID,
-		arg.CreatedAt,
-		arg.ID,
-		arg.Limit,
+type SearchExpensesRow struct {
+	Expense Expense `json:"expense"`
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// Ordered by relevance, the cursor is the rank and id of the last expense.
+// The date range and category (with its subcategories and splits) are optional
+func (q *Queries) SearchExpenses(ctx context.Context, arg SearchExpensesParams) ([]SearchExpensesRow, error) {
+	rows, err := q.db.Query(ctx, searchExpenses,
+		arg.CategoryID,
+		arg.Query,
+		arg.WorkspaceID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.CursorRank,
+		arg.CursorID,
+		arg.PageSize,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Expense
+	var items []SearchExpensesRow
 	for rows.Next() {
-		var i Expense
+		var i SearchExpensesRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Description,
-			&i.Amount,
-			&i.CategoryID,
-			&i.UserID,
+			&i.Expense.ID,
+			&i.Expense.CreatedAt,
+			&i.Expense.UpdatedAt,
+			&i.Expense.Description,
+			&i.Expense.Amount,
+			&i.Expense.CategoryID,
+			&i.Expense.WorkspaceID,
+			&i.Expense.SpentAt,
+			&i.Expense.RecurringExpenseID,
+			&i.Expense.Currency,
+			&i.Expense.BaseAmount,
+			&i.Expense.Merchant,
+			&i.Expense.Notes,
+			&i.Expense.SearchVector,
+			&i.Expense.AccountID,
+			&i.Expense.ClearedAt,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -320,17 +341,25 @@ func (q *Queries) GetUserExpensesPaged(ctx context.Context, arg GetUserExpensesP
 }
 
 const updateExpense = `-- name: UpdateExpense :one
-UPDATE expenses SET description = $1, amount = $2, category_id = $3, updated_at = $4
-WHERE id = $5 AND user_id = $6 RETURNING id, created_at, updated_at, description, amount, category_id, user_id
+UPDATE expenses SET description = $1, amount = $2, category_id = $3, spent_at = $4, currency = $5,
+    base_amount = $6, merchant = $7, notes = $8, account_id = $9, cleared_at = $10, updated_at = $11
+WHERE id = $12 AND workspace_id = $13 RETURNING id, created_at, updated_at, description, amount, category_id, workspace_id, spent_at, recurring_expense_id, currency, base_amount, merchant, notes, search_vector, account_id, cleared_at
 `
 
 type UpdateExpenseParams struct {
-	Description string          `json:"description"`
-	Amount      decimal.Decimal `json:"amount"`
-	CategoryID  uuid.UUID       `json:"category_id"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	ID          uuid.UUID       `json:"id"`
-	UserID      uuid.UUID       `json:"user_id"`
+	Description string             `json:"description"`
+	Amount      decimal.Decimal    `json:"amount"`
+	CategoryID  uuid.UUID          `json:"category_id"`
+	SpentAt     time.Time          `json:"spent_at"`
+	Currency    string             `json:"currency"`
+	BaseAmount  decimal.Decimal    `json:"base_amount"`
+	Merchant    pgtype.Text        `json:"merchant"`
+	Notes       pgtype.Text        `json:"notes"`
+	AccountID   pgtype.UUID        `json:"account_id"`
+	ClearedAt   pgtype.Timestamptz `json:"cleared_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	ID          uuid.UUID          `json:"id"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
 }
 
 // No need to get nullable params since when using update it need to get the
@@ -340,9 +369,16 @@ func (q *Queries) UpdateExpense(ctx context.Context, arg UpdateExpenseParams) (E
 		arg.Description,
 		arg.Amount,
 		arg.CategoryID,
+		arg.SpentAt,
+		arg.Currency,
+		arg.BaseAmount,
+		arg.Merchant,
+		arg.Notes,
+		arg.AccountID,
+		arg.ClearedAt,
 		arg.UpdatedAt,
 		arg.ID,
-		arg.UserID,
+		arg.WorkspaceID,
 	)
 	var i Expense
 	err := row.Scan(
@@ -352,7 +388,16 @@ func (q *Queries) UpdateExpense(ctx context.Context, arg UpdateExpenseParams) (E
 		&i.Description,
 		&i.Amount,
 		&i.CategoryID,
-		&i.UserID,
+		&i.WorkspaceID,
+		&i.SpentAt,
+		&i.RecurringExpenseID,
+		&i.Currency,
+		&i.BaseAmount,
+		&i.Merchant,
+		&i.Notes,
+		&i.SearchVector,
+		&i.AccountID,
+		&i.ClearedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// The :many queries generated by sqlc load every row in memory, these stream
+// the export queries to fn one row at a time as they are read from the
+// connection. Rows are scanned by position, so the row types must keep the
+// column order of the queries.
+
+func (q *Queries) ExportExpensesEach(ctx context.Context, arg ExportExpensesParams, fn func(ExportExpensesRow) error) error {
+	return each(ctx, q.db, fn, exportExpenses, arg.CategoryID, arg.WorkspaceID, arg.StartDate, arg.EndDate)
+}
+
+func (q *Queries) ExportCategoriesEach(ctx context.Context, workspaceID uuid.UUID, fn func(Category) error) error {
+	return each(ctx, q.db, fn, exportCategories, workspaceID)
+}
+
+func (q *Queries) ExportBudgetsEach(ctx context.Context, workspaceID uuid.UUID, fn func(ExportBudgetsRow) error) error {
+	return each(ctx, q.db, fn, exportBudgets, workspaceID)
+}
+
+func (q *Queries) ExportRecurringExpensesEach(ctx context.Context, workspaceID uuid.UUID, fn func(ExportRecurringExpensesRow) error) error {
+	return each(ctx, q.db, fn, exportRecurringExpenses, workspaceID)
+}
+
+func (q *Queries) ExportIncomesEach(ctx context.Context, workspaceID uuid.UUID, fn func(ExportIncomesRow) error) error {
+	return each(ctx, q.db, fn, exportIncomes, workspaceID)
+}
+
+func (q *Queries) ExportAccountsEach(ctx context.Context, workspaceID uuid.UUID, fn func(Account) error) error {
+	return each(ctx, q.db, fn, exportAccounts, workspaceID)
+}
+
+func (q *Queries) ExportTransfersEach(ctx context.Context, workspaceID uuid.UUID, fn func(Transfer) error) error {
+	return each(ctx, q.db, fn, exportTransfers, workspaceID)
+}
+
+func (q *Queries) ExportExpenseSplitsEach(ctx context.Context, workspaceID uuid.UUID, fn func(ExportExpenseSplitsRow) error) error {
+	return each(ctx, q.db, fn, exportExpenseSplits, workspaceID)
+}
+
+func each[T any](ctx context.Context, db DBTX, fn func(T) error, query string, args ...interface{}) error {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := pgx.RowToStructByPos[T](rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -5,47 +5,273 @@
 package repository
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
 )
 
+type Account struct {
+	ID             uuid.UUID          `json:"id"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Name           string             `json:"name"`
+	Type           string             `json:"type"`
+	Currency       string             `json:"currency"`
+	OpeningBalance decimal.Decimal    `json:"opening_balance"`
+	ReconciledAt   pgtype.Timestamptz `json:"reconciled_at"`
+	WorkspaceID    uuid.UUID          `json:"workspace_id"`
+}
+
+type ApiKey struct {
+	ID         uuid.UUID          `json:"id"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	SecretHash string             `json:"-"`
+	Scopes     []string           `json:"scopes"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	UserID     uuid.UUID          `json:"user_id"`
+}
+
 type Budget struct {
+	ID            uuid.UUID          `json:"id"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	Amount        decimal.Decimal    `json:"amount"`
+	Goal          decimal.Decimal    `json:"goal"`
+	StartDate     time.Time          `json:"start_date"`
+	EndDate       time.Time          `json:"end_date"`
+	WorkspaceID   uuid.UUID          `json:"workspace_id"`
+	Currency      string             `json:"currency"`
+	Period        pgtype.Text        `json:"period"`
+	SeriesID      pgtype.UUID        `json:"series_id"`
+	ClosedAt      pgtype.Timestamptz `json:"closed_at"`
+	Rollover      string             `json:"rollover"`
+	RolloverCap   decimal.Decimal    `json:"rollover_cap"`
+	Carried       decimal.Decimal    `json:"carried"`
+	EffectiveGoal decimal.Decimal    `json:"effective_goal"`
+	Thresholds    []int32            `json:"thresholds"`
+	TimeZone      string             `json:"time_zone"`
+}
+
+type BudgetAlert struct {
+	ID          uuid.UUID          `json:"id"`
+	CreatedAt   time.Time          `json:"created_at"`
+	Threshold   int32              `json:"threshold"`
+	Amount      decimal.Decimal    `json:"amount"`
+	Goal        decimal.Decimal    `json:"goal"`
+	BudgetID    uuid.UUID          `json:"budget_id"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	NotifiedAt  pgtype.Timestamptz `json:"-"`
+}
+
+type BudgetCategory struct {
+	BudgetID   uuid.UUID `json:"budget_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+}
+
+type Category struct {
+	ID          uuid.UUID   `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Name        string      `json:"name"`
+	WorkspaceID uuid.UUID   `json:"workspace_id"`
+	ParentID    pgtype.UUID `json:"parent_id"`
+}
+
+type ExchangeRate struct {
+	Date     pgtype.Date     `json:"date"`
+	Base     string          `json:"base"`
+	Currency string          `json:"currency"`
+	Rate     decimal.Decimal `json:"rate"`
+}
+
+type Expense struct {
+	ID                 uuid.UUID          `json:"id"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+	Description        string             `json:"description"`
+	Amount             decimal.Decimal    `json:"amount"`
+	CategoryID         uuid.UUID          `json:"category_id"`
+	WorkspaceID        uuid.UUID          `json:"workspace_id"`
+	SpentAt            time.Time          `json:"spent_at"`
+	RecurringExpenseID pgtype.UUID        `json:"recurring_expense_id"`
+	Currency           string             `json:"currency"`
+	BaseAmount         decimal.Decimal    `json:"base_amount"`
+	Merchant           pgtype.Text        `json:"merchant"`
+	Notes              pgtype.Text        `json:"notes"`
+	SearchVector       string             `json:"-"`
+	AccountID          pgtype.UUID        `json:"account_id"`
+	ClearedAt          pgtype.Timestamptz `json:"cleared_at"`
+}
+
+type ExpenseSplit struct {
 	ID         uuid.UUID       `json:"id"`
-	CreatedAt  time.Time       `json:"created_at"`
-	UpdatedAt  time.Time       `json:"updated_at"`
+	Position   int32           `json:"position"`
 	Amount     decimal.Decimal `json:"amount"`
-	Goal       decimal.Decimal `json:"goal"`
-	StartDate  time.Time       `json:"start_date"`
-	EndDate    time.Time       `json:"end_date"`
-	UserID     uuid.UUID       `json:"user_id"`
+	BaseAmount decimal.Decimal `json:"base_amount"`
+	Note       pgtype.Text     `json:"note"`
 	CategoryID uuid.UUID       `json:"category_id"`
+	ExpenseID  uuid.UUID       `json:"expense_id"`
 }
 
-type Category struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Name      string    `json:"name"`
-	UserID    uuid.UUID `json:"user_id"`
+type ExpenseTag struct {
+	ExpenseID uuid.UUID `json:"expense_id"`
+	TagID     uuid.UUID `json:"tag_id"`
 }
 
-type Expense struct {
+type Income struct {
 	ID          uuid.UUID       `json:"id"`
 	CreatedAt   time.Time       `json:"created_at"`
 	UpdatedAt   time.Time       `json:"updated_at"`
-	Description string          `json:"description"`
+	Source      string          `json:"source"`
 	Amount      decimal.Decimal `json:"amount"`
-	CategoryID  uuid.UUID       `json:"category_id"`
-	UserID      uuid.UUID       `json:"user_id"`
+	Currency    string          `json:"currency"`
+	BaseAmount  decimal.Decimal `json:"base_amount"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	CategoryID  pgtype.UUID     `json:"category_id"`
+	WorkspaceID uuid.UUID       `json:"workspace_id"`
+	AccountID   pgtype.UUID     `json:"account_id"`
+}
+
+type MfaChallenge struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	TokenHash string    `json:"-"`
+	Device    string    `json:"device"`
+	Attempts  int32     `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    uuid.UUID `json:"user_id"`
+}
+
+type MfaRecoveryCode struct {
+	ID        uuid.UUID          `json:"id"`
+	CreatedAt time.Time          `json:"created_at"`
+	CodeHash  string             `json:"-"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	UserID    uuid.UUID          `json:"user_id"`
+}
+
+type Notification struct {
+	ID        uuid.UUID          `json:"id"`
+	CreatedAt time.Time          `json:"created_at"`
+	Kind      string             `json:"kind"`
+	Title     string             `json:"title"`
+	Message   string             `json:"message"`
+	Data      json.RawMessage    `json:"data"`
+	ReadAt    pgtype.Timestamptz `json:"read_at"`
+	UserID    uuid.UUID          `json:"user_id"`
+}
+
+type RecurringExpense struct {
+	ID              uuid.UUID          `json:"id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	Description     string             `json:"description"`
+	Amount          decimal.Decimal    `json:"amount"`
+	Frequency       string             `json:"frequency"`
+	RepeatInterval  int32              `json:"repeat_interval"`
+	StartDate       time.Time          `json:"start_date"`
+	EndDate         pgtype.Timestamptz `json:"end_date"`
+	OccurrenceLimit pgtype.Int4        `json:"occurrence_limit"`
+	Occurrences     int32              `json:"occurrences"`
+	NextOccurrence  pgtype.Timestamptz `json:"next_occurrence"`
+	CategoryID      uuid.UUID          `json:"category_id"`
+	WorkspaceID     uuid.UUID          `json:"workspace_id"`
+	Currency        string             `json:"currency"`
+}
+
+type RefreshToken struct {
+	ID         uuid.UUID          `json:"id"`
+	FamilyID   uuid.UUID          `json:"family_id"`
+	UserID     uuid.UUID          `json:"user_id"`
+	TokenHash  string             `json:"token_hash"`
+	Device     string             `json:"device"`
+	IssuedAt   time.Time          `json:"issued_at"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	ReplacedBy pgtype.UUID        `json:"replaced_by"`
+}
+
+type Tag struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Name        string    `json:"name"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+}
+
+type Transfer struct {
+	ID            uuid.UUID       `json:"id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	FromAccountID uuid.UUID       `json:"from_account_id"`
+	ToAccountID   uuid.UUID       `json:"to_account_id"`
+	Amount        decimal.Decimal `json:"amount"`
+	ToAmount      decimal.Decimal `json:"to_amount"`
+	Description   string          `json:"description"`
+	TransferredAt time.Time       `json:"transferred_at"`
+	WorkspaceID   uuid.UUID       `json:"workspace_id"`
 }
 
 type User struct {
+	ID                uuid.UUID          `json:"id"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	Name              string             `json:"name"`
+	Email             string             `json:"email"`
+	Password          string             `json:"password"`
+	Currency          string             `json:"currency"`
+	EmailVerifiedAt   pgtype.Timestamptz `json:"email_verified_at"`
+	TimeZone          string             `json:"time_zone"`
+	Locale            string             `json:"locale"`
+	WeekStart         int16              `json:"week_start"`
+	MonthStartDay     int16              `json:"month_start_day"`
+	TotpSecret        pgtype.Text        `json:"-"`
+	TotpEnabledAt     pgtype.Timestamptz `json:"totp_enabled_at"`
+	TotpLastStep      int64              `json:"totp_last_step"`
+	MfaFailedAttempts int32              `json:"-"`
+	MfaLockedUntil    pgtype.Timestamptz `json:"-"`
+}
+
+type UserToken struct {
+	ID        uuid.UUID          `json:"id"`
+	CreatedAt time.Time          `json:"created_at"`
+	Kind      string             `json:"kind"`
+	TokenHash string             `json:"-"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	UserID    uuid.UUID          `json:"user_id"`
+}
+
+type Workspace struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
+	Currency  string    `json:"currency"`
+	Personal  bool      `json:"personal"`
+}
+
+type WorkspaceInvitation struct {
+	ID          uuid.UUID          `json:"id"`
+	CreatedAt   time.Time          `json:"created_at"`
+	Email       string             `json:"email"`
+	Role        string             `json:"role"`
+	TokenHash   string             `json:"-"`
+	ExpiresAt   time.Time          `json:"expires_at"`
+	AcceptedAt  pgtype.Timestamptz `json:"accepted_at"`
+	WorkspaceID uuid.UUID          `json:"workspace_id"`
+	InvitedBy   pgtype.UUID        `json:"invited_by"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
 }
@@ -10,12 +10,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name, email, password)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, email, password
+INSERT INTO users (id, created_at, updated_at, name, email, password, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, updated_at, name, email, password, currency, email_verified_at, time_zone, locale, week_start, month_start_day, totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until
 `
 
 type CreateUserParams struct {
@@ -25,6 +26,7 @@ type CreateUserParams struct {
 	Name      string    `json:"name"`
 	Email     string    `json:"email"`
 	Password  string    `json:"password"`
+	Currency  string    `json:"currency"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -35,6 +37,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.Name,
 		arg.Email,
 		arg.Password,
+		arg.Currency,
 	)
 	var i User
 	err := row.Scan(
@@ -44,12 +47,23 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.Currency,
+		&i.EmailVerifiedAt,
+		&i.TimeZone,
+		&i.Locale,
+		&i.WeekStart,
+		&i.MonthStartDay,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaFailedAttempts,
+		&i.MfaLockedUntil,
 	)
 	return i, err
 }
 
 const deleteUser = `-- name: DeleteUser :one
-DELETE FROM users WHERE id = $1 RETURNING id, created_at, updated_at, name, email, password
+DELETE FROM users WHERE id = $1 RETURNING id, created_at, updated_at, name, email, password, currency, email_verified_at, time_zone, locale, week_start, month_start_day, totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until
 `
 
 func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -62,12 +76,23 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.Currency,
+		&i.EmailVerifiedAt,
+		&i.TimeZone,
+		&i.Locale,
+		&i.WeekStart,
+		&i.MonthStartDay,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaFailedAttempts,
+		&i.MfaLockedUntil,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, name, email, password FROM users WHERE email = $1
+SELECT id, created_at, updated_at, name, email, password, currency, email_verified_at, time_zone, locale, week_start, month_start_day, totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until FROM users WHERE email = $1
 `
 
 // NOTE: Use this in login only to get the user and then
@@ -82,12 +107,23 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.Currency,
+		&i.EmailVerifiedAt,
+		&i.TimeZone,
+		&i.Locale,
+		&i.WeekStart,
+		&i.MonthStartDay,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaFailedAttempts,
+		&i.MfaLockedUntil,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, name, email, password FROM users WHERE id = $1
+SELECT id, created_at, updated_at, name, email, password, currency, email_verified_at, time_zone, locale, week_start, month_start_day, totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -100,6 +136,161 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Name,
 		&i.Email,
 		&i.Password,
+		&i.Currency,
+		&i.EmailVerifiedAt,
+		&i.TimeZone,
+		&i.Locale,
+		&i.WeekStart,
+		&i.MonthStartDay,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaFailedAttempts,
+		&i.MfaLockedUntil,
+	)
+	return i, err
+}
+
+const setUserEmailVerified = `-- name: SetUserEmailVerified :one
+UPDATE users SET email_verified_at = $1, updated_at = $2 WHERE id = $3
+RETURNING id, created_at, updated_at, name, email, password, currency, email_verified_at, time_zone, locale, week_start, month_start_day, totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until
+`
+
+type SetUserEmailVerifiedParams struct {
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	ID              uuid.UUID          `json:"id"`
+}
+
+func (q *Queries) SetUserEmailVerified(ctx context.Context, arg SetUserEmailVerifiedParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserEmailVerified, arg.EmailVerifiedAt, arg.UpdatedAt, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Currency,
+		&i.EmailVerifiedAt,
+		&i.TimeZone,
+		&i.Locale,
+		&i.WeekStart,
+		&i.MonthStartDay,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaFailedAttempts,
+		&i.MfaLockedUntil,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users SET password = $1, updated_at = $2 WHERE id = $3
+`
+
+type UpdateUserPasswordParams struct {
+	Password  string    `json:"password"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.Password, arg.UpdatedAt, arg.ID)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users SET name = $1, email = $2, email_verified_at = $3, updated_at = $4 WHERE id = $5
+RETURNING id, created_at, updated_at, name, email, password, currency, email_verified_at, time_zone, locale, week_start, month_start_day, totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until
+`
+
+type UpdateUserProfileParams struct {
+	Name            string             `json:"name"`
+	Email           string             `json:"email"`
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	ID              uuid.UUID          `json:"id"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserProfile,
+		arg.Name,
+		arg.Email,
+		arg.EmailVerifiedAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Currency,
+		&i.EmailVerifiedAt,
+		&i.TimeZone,
+		&i.Locale,
+		&i.WeekStart,
+		&i.MonthStartDay,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaFailedAttempts,
+		&i.MfaLockedUntil,
+	)
+	return i, err
+}
+
+const updateUserSettings = `-- name: UpdateUserSettings :one
+UPDATE users SET currency = $1, time_zone = $2, locale = $3, week_start = $4, month_start_day = $5, updated_at = $6
+WHERE id = $7
+RETURNING id, created_at, updated_at, name, email, password, currency, email_verified_at, time_zone, locale, week_start, month_start_day, totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until
+`
+
+type UpdateUserSettingsParams struct {
+	Currency      string    `json:"currency"`
+	TimeZone      string    `json:"time_zone"`
+	Locale        string    `json:"locale"`
+	WeekStart     int16     `json:"week_start"`
+	MonthStartDay int16     `json:"month_start_day"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserSettings(ctx context.Context, arg UpdateUserSettingsParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserSettings,
+		arg.Currency,
+		arg.TimeZone,
+		arg.Locale,
+		arg.WeekStart,
+		arg.MonthStartDay,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Currency,
+		&i.EmailVerifiedAt,
+		&i.TimeZone,
+		&i.Locale,
+		&i.WeekStart,
+		&i.MonthStartDay,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.MfaFailedAttempts,
+		&i.MfaLockedUntil,
 	)
 	return i, err
 }
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Types of accounts, matching the check of the accounts table
+const (
+	AccountChecking   = "checking"
+	AccountSavings    = "savings"
+	AccountCreditCard = "credit_card"
+	AccountCash       = "cash"
+)
+
+const maxAccountNameLength = 255
+
+// Account is where the expenses are paid from and the incomes are received
+// in. Balances are in the currency of the account
+type Account struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+// AccountWithBalance is an account with its current balance
+type AccountWithBalance struct {
+	repository.Account
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// AccountBalance is the balance of an account at the end of a bucket
+type AccountBalance struct {
+	Period  time.Time       `json:"period"`
+	Change  decimal.Decimal `json:"change"`
+	Balance decimal.Decimal `json:"balance"`
+}
+
+type AccountHistory struct {
+	// Balance before the first bucket
+	OpeningBalance decimal.Decimal  `json:"opening_balance"`
+	ClosingBalance decimal.Decimal  `json:"closing_balance"`
+	Balances       []AccountBalance `json:"balances"`
+}
+
+type Reconciliation struct {
+	Account       repository.Account `json:"account"`
+	StatementDate time.Time          `json:"statement_date"`
+	// Number of expenses marked as cleared
+	Cleared int64 `json:"cleared"`
+	// Balance of the account at the statement date
+	Balance decimal.Decimal `json:"balance"`
+	// Only set when the balance of the statement is given, the difference
+	// is what the statement has that the account does not
+	StatementBalance *decimal.Decimal `json:"statement_balance"`
+	Difference       *decimal.Decimal `json:"difference"`
+}
+
+func (s *Account) GetAll(ctx context.Context, workspaceID uuid.UUID) ([]AccountWithBalance, error) {
+	rows, err := s.Queries.GetUserAccounts(ctx, repository.GetUserAccountsParams{
+		AsOf:        time.Now(),
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []AccountWithBalance{}, err
+	}
+
+	accounts := make([]AccountWithBalance, len(rows))
+	for i, row := range rows {
+		accounts[i] = AccountWithBalance{Account: row.Account, Balance: row.Balance}
+	}
+
+	return accounts, nil
+}
+
+func (s *Account) GetByID(ctx context.Context, id, workspaceID uuid.UUID) (AccountWithBalance, error) {
+	a, err := s.Queries.GetAccountByID(ctx, repository.GetAccountByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return AccountWithBalance{}, ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return AccountWithBalance{}, err
+	}
+
+	balance, err := s.balance(ctx, s.Queries, id, workspaceID, time.Now())
+	if err != nil {
+		return AccountWithBalance{}, err
+	}
+
+	return AccountWithBalance{Account: a, Balance: balance}, nil
+}
+
+// Create adds an account in currency, the base currency of the workspace when
+// empty. The currency of an account can not be changed.
+func (s *Account) Create(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	name, accountType, currency string,
+	openingBalance decimal.Decimal,
+) (AccountWithBalance, error) {
+	if !validAccount(name, accountType) {
+		return AccountWithBalance{}, ErrInvalidAccount
+	}
+
+	if currency == "" {
+		var err error
+		currency, err = baseCurrency(ctx, s.Queries, workspaceID)
+		if err != nil {
+			return AccountWithBalance{}, err
+		}
+	}
+
+	now := time.Now()
+	a, err := s.Queries.CreateAccount(ctx, repository.CreateAccountParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Name:           name,
+		Type:           accountType,
+		Currency:       currency,
+		OpeningBalance: openingBalance,
+		WorkspaceID:    workspaceID,
+	})
+	if isUniqueViolation(err) {
+		return AccountWithBalance{}, ErrAccountExists
+	} else if err != nil {
+		fmt.Println("failed to insert:", err)
+		return AccountWithBalance{}, err
+	}
+
+	return AccountWithBalance{Account: a, Balance: a.OpeningBalance}, nil
+}
+
+// Update changes the given fields of the account, empty values are kept as
+// they are.
+func (s *Account) Update(
+	ctx context.Context,
+	id, workspaceID uuid.UUID,
+	name, accountType string,
+	openingBalance decimal.NullDecimal,
+) (AccountWithBalance, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return AccountWithBalance{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	a, err := qtx.GetAccountByID(ctx, repository.GetAccountByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return AccountWithBalance{}, ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return AccountWithBalance{}, err
+	}
+
+	if name == "" {
+		name = a.Name
+	}
+
+	if accountType == "" {
+		accountType = a.Type
+	}
+
+	if !validAccount(name, accountType) {
+		return AccountWithBalance{}, ErrInvalidAccount
+	}
+
+	if !openingBalance.Valid {
+		openingBalance.Decimal = a.OpeningBalance
+	}
+
+	a, err = qtx.UpdateAccount(ctx, repository.UpdateAccountParams{
+		ID:             id,
+		WorkspaceID:    workspaceID,
+		Name:           name,
+		Type:           accountType,
+		OpeningBalance: openingBalance.Decimal,
+		UpdatedAt:      time.Now(),
+	})
+	if isUniqueViolation(err) {
+		return AccountWithBalance{}, ErrAccountExists
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return AccountWithBalance{}, err
+	}
+
+	balance, err := s.balance(ctx, qtx, id, workspaceID, time.Now())
+	if err != nil {
+		return AccountWithBalance{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return AccountWithBalance{}, err
+	}
+
+	return AccountWithBalance{Account: a, Balance: balance}, nil
+}
+
+// DeleteByID deletes the account with its transfers, the expenses and
+// incomes are kept without an account.
+func (s *Account) DeleteByID(ctx context.Context, id, workspaceID uuid.UUID) (repository.Account, error) {
+	a, err := s.Queries.DeleteAccount(ctx, repository.DeleteAccountParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Account{}, ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Account{}, err
+	}
+
+	return a, nil
+}
+
+// Balances returns the balance of the account at the end of each bucket
+// (day, week, month or year) of cal in the date range with movements.
+func (s *Account) Balances(
+	ctx context.Context,
+	id, workspaceID uuid.UUID,
+	bucket string,
+	cal Calendar,
+	startDate, endDate time.Time,
+) (AccountHistory, error) {
+	if !validPeriod(bucket) {
+		return AccountHistory{}, ErrInvalidPeriod
+	}
+
+	balance, err := s.balance(ctx, s.Queries, id, workspaceID, startDate.Add(-time.Microsecond))
+	if err != nil {
+		return AccountHistory{}, err
+	}
+
+	changes, err := s.Queries.GetAccountChanges(ctx, repository.GetAccountChangesParams{
+		AccountID: id,
+		Bucket:    bucket,
+		TimeZone:  cal.Location.String(),
+		ShiftDays: cal.shiftDays(bucket),
+		StartDate: startDate,
+		EndDate:   endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return AccountHistory{}, err
+	}
+
+	history := AccountHistory{
+		OpeningBalance: balance,
+		Balances:       make([]AccountBalance, len(changes)),
+	}
+
+	for i, c := range changes {
+		balance = balance.Add(c.Change)
+		history.Balances[i] = AccountBalance{Period: c.Period, Change: c.Change, Balance: balance}
+	}
+
+	history.ClosingBalance = balance
+
+	return history, nil
+}
+
+// Reconcile marks the expenses of the account up to the end of the
+// statement date as cleared, and compares the balance of the account then
+// with the one of the statement when it is given.
+func (s *Account) Reconcile(
+	ctx context.Context,
+	id, workspaceID uuid.UUID,
+	statementDate time.Time,
+	statementBalance decimal.NullDecimal,
+) (Reconciliation, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	now := time.Now()
+	a, err := qtx.SetAccountReconciled(ctx, repository.SetAccountReconciledParams{
+		ID:            id,
+		WorkspaceID:   workspaceID,
+		StatementDate: statementDate,
+		UpdatedAt:     now,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Reconciliation{}, ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return Reconciliation{}, err
+	}
+
+	cleared, err := qtx.ClearAccountExpenses(ctx, repository.ClearAccountExpensesParams{
+		ClearedAt:     now,
+		AccountID:     id,
+		StatementDate: statementDate,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return Reconciliation{}, err
+	}
+
+	balance, err := s.balance(ctx, qtx, id, workspaceID, statementDate)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Reconciliation{}, err
+	}
+
+	r := Reconciliation{
+		Account:       a,
+		StatementDate: statementDate,
+		Cleared:       cleared,
+		Balance:       balance,
+	}
+
+	if statementBalance.Valid {
+		difference := statementBalance.Decimal.Sub(balance)
+		r.StatementBalance = &statementBalance.Decimal
+		r.Difference = &difference
+	}
+
+	return r, nil
+}
+
+func (s *Account) balance(
+	ctx context.Context,
+	queries *repository.Queries,
+	id, workspaceID uuid.UUID,
+	asOf time.Time,
+) (decimal.Decimal, error) {
+	balance, err := queries.GetAccountBalance(ctx, repository.GetAccountBalanceParams{
+		AsOf:        asOf,
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return decimal.Zero, ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return decimal.Zero, err
+	}
+
+	return balance, nil
+}
+
+func validAccount(name, accountType string) bool {
+	if name == "" || utf8.RuneCountInString(name) > maxAccountNameLength {
+		return false
+	}
+
+	switch accountType {
+	case AccountChecking, AccountSavings, AccountCreditCard, AccountCash:
+		return true
+	}
+
+	return false
+}
+
+// findAccount returns the account of the workspace, uuid.Nil being no account.
+// Amounts in an account must be in its currency, which is also the currency
+// when none is given.
+func findAccount(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID, accountID uuid.UUID,
+	currency string,
+) (pgtype.UUID, string, error) {
+	if accountID == uuid.Nil {
+		return pgtype.UUID{}, currency, nil
+	}
+
+	a, err := queries.GetAccountByID(ctx, repository.GetAccountByIDParams{
+		ID:          accountID,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, "", ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return pgtype.UUID{}, "", err
+	}
+
+	if currency == "" {
+		currency = a.Currency
+	} else if currency != a.Currency {
+		return pgtype.UUID{}, "", ErrAccountCurrency
+	}
+
+	return pgtype.UUID{Bytes: a.ID, Valid: true}, currency, nil
+}
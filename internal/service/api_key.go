@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// API keys look like et_<prefix>_<secret>. The prefix finds the key and is
+// kept to tell the keys apart, only the hash of the secret is stored
+const (
+	apiKeyStart      = "et_"
+	apiKeyPrefixSize = 6 // bytes, hex encoded
+)
+
+// Resources an API key can be scoped to, as <resource>:read or
+// <resource>:write. Writing a resource includes reading it
+var apiKeyResources = []string{
+	"accounts",
+	"budgets",
+	"categories",
+	"expenses",
+	"exports",
+	"imports",
+	"incomes",
+	"notifications",
+	"recurring_expenses",
+	"reports",
+	"tags",
+	"transfers",
+}
+
+type APIKey struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+// CreatedAPIKey is a new API key with the key itself, which is only shown
+// once.
+type CreatedAPIKey struct {
+	repository.ApiKey
+	Key string `json:"key"`
+}
+
+func (s *APIKey) GetAll(ctx context.Context, userID uuid.UUID) ([]repository.ApiKey, error) {
+	keys, err := s.Queries.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Create makes an API key of the user with the scopes given, which expires
+// at expiresAt or never when it is not valid.
+func (s *APIKey) Create(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+	scopes []string,
+	expiresAt pgtype.Timestamptz,
+) (CreatedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 255 {
+		return CreatedAPIKey{}, ErrInvalidAPIKeyName
+	}
+
+	if len(scopes) == 0 {
+		return CreatedAPIKey{}, ErrInvalidAPIKeyScope
+	}
+
+	for _, scope := range scopes {
+		if !ValidScope(scope) {
+			return CreatedAPIKey{}, ErrInvalidAPIKeyScope
+		}
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	now := time.Now()
+
+	if expiresAt.Valid && !expiresAt.Time.After(now) {
+		return CreatedAPIKey{}, ErrInvalidAPIKeyExpiry
+	}
+
+	b := make([]byte, apiKeyPrefixSize)
+	if _, err := rand.Read(b); err != nil {
+		return CreatedAPIKey{}, err
+	}
+	prefix := hex.EncodeToString(b)
+
+	secret, err := randomToken()
+	if err != nil {
+		return CreatedAPIKey{}, err
+	}
+
+	key, err := s.Queries.CreateAPIKey(ctx, repository.CreateAPIKeyParams{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hashToken(secret),
+		Scopes:     scopes,
+		ExpiresAt:  expiresAt,
+		UserID:     userID,
+	})
+	if err != nil {
+		fmt.Println("failed to create:", err)
+		return CreatedAPIKey{}, err
+	}
+
+	return CreatedAPIKey{
+		ApiKey: key,
+		Key:    apiKeyStart + prefix + "_" + secret,
+	}, nil
+}
+
+// DeleteByID revokes an API key of the user, which stops working at once.
+func (s *APIKey) DeleteByID(ctx context.Context, id, userID uuid.UUID) error {
+	_, err := s.Queries.DeleteAPIKey(ctx, repository.DeleteAPIKeyParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrAPIKeyNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return err
+	}
+
+	return nil
+}
+
+// Authenticate returns the API key of key when it exists and has not
+// expired, keeping when it was last used.
+func (s *APIKey) Authenticate(ctx context.Context, key string) (repository.ApiKey, error) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, apiKeyStart), "_")
+	if !IsAPIKey(key) || !ok || len(prefix) != hex.EncodedLen(apiKeyPrefixSize) {
+		return repository.ApiKey{}, ErrInvalidToken
+	}
+
+	k, err := s.Queries.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.ApiKey{}, ErrInvalidToken
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.ApiKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(k.SecretHash)) != 1 {
+		return repository.ApiKey{}, ErrInvalidToken
+	}
+
+	now := time.Now()
+
+	if k.ExpiresAt.Valid && !k.ExpiresAt.Time.After(now) {
+		return repository.ApiKey{}, ErrExpiredToken
+	}
+
+	// Not knowing when the key was last used is no reason to refuse it
+	err = s.Queries.TouchAPIKey(ctx, repository.TouchAPIKeyParams{
+		UsedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		ID:     k.ID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+	}
+
+	return k, nil
+}
+
+// IsAPIKey tells whether a bearer token is an API key rather than a JWT.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyStart)
+}
+
+// ValidScope tells whether scope is <resource>:read or <resource>:write of
+// a resource API keys can be scoped to.
+func ValidScope(scope string) bool {
+	resource, access, ok := strings.Cut(scope, ":")
+	return ok && (access == "read" || access == "write") && slices.Contains(apiKeyResources, resource)
+}
+
+// HasScope tells whether scopes grant scope, a write scope granting the
+// read one of the same resource.
+func HasScope(scopes []string, scope string) bool {
+	if slices.Contains(scopes, scope) {
+		return true
+	}
+
+	resource, access, _ := strings.Cut(scope, ":")
+	return access == "read" && slices.Contains(scopes, resource+":write")
+}
@@ -8,138 +8,745 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/notify"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 	"github.com/shopspring/decimal"
 )
 
+// Periods of a recurring budget, an empty period is a one-off budget and
+// BudgetPeriodNone stops a recurring one when updating it
+const (
+	BudgetPeriodNone      = "none"
+	BudgetPeriodWeekly    = "weekly"
+	BudgetPeriodMonthly   = "monthly"
+	BudgetPeriodQuarterly = "quarterly"
+	BudgetPeriodYearly    = "yearly"
+)
+
+// What is carried from a period of a recurring budget into the next one: the
+// surplus, the deficit, both or both up to a cap
+const (
+	RolloverNone    = "none"
+	RolloverSurplus = "surplus"
+	RolloverDeficit = "deficit"
+	RolloverBoth    = "both"
+	RolloverCapped  = "capped"
+)
+
+// Number of ended budget periods handled in each scheduler run
+const rollBatchSize = 100
+
+// BudgetRecurrence makes a budget recurring, starting a new period every
+// Period. RolloverCap is only used by the capped rollover.
+type BudgetRecurrence struct {
+	Period      string
+	Rollover    string
+	RolloverCap decimal.Decimal
+}
+
+func (r BudgetRecurrence) validate() error {
+	if r.Period != "" && !validBudgetPeriod(r.Period) {
+		return ErrInvalidBudgetPeriod
+	}
+
+	switch r.Rollover {
+	case RolloverNone, RolloverSurplus, RolloverDeficit, RolloverBoth:
+	case RolloverCapped:
+		if !r.RolloverCap.IsPositive() {
+			return ErrInvalidRollover
+		}
+	default:
+		return ErrInvalidRollover
+	}
+
+	return nil
+}
+
+// carryOver returns what is left of the budget period to carry into the
+// next one. What is left includes what was carried into it, so unspent money
+// keeps adding up like in an envelope.
+func carryOver(b repository.Budget) decimal.Decimal {
+	left := b.EffectiveGoal.Sub(b.Amount)
+
+	switch b.Rollover {
+	case RolloverSurplus:
+		return decimal.Max(left, decimal.Zero)
+	case RolloverDeficit:
+		return decimal.Min(left, decimal.Zero)
+	case RolloverBoth:
+		return left
+	case RolloverCapped:
+		return decimal.Max(decimal.Min(left, b.RolloverCap), b.RolloverCap.Neg())
+	default:
+		return decimal.Zero
+	}
+}
+
+func validBudgetPeriod(period string) bool {
+	switch period {
+	case BudgetPeriodWeekly, BudgetPeriodMonthly, BudgetPeriodQuarterly, BudgetPeriodYearly:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextPeriodStart returns when the period starting at start ends and the
+// next one starts. Months are clamped like the recurring expenses.
+func nextPeriodStart(start time.Time, period string) time.Time {
+	switch period {
+	case BudgetPeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	case BudgetPeriodMonthly:
+		return addMonths(start, 1)
+	case BudgetPeriodQuarterly:
+		return addMonths(start, 3)
+	default:
+		return addMonths(start, 12)
+	}
+}
+
+// periodEnd is the last instant of the period starting at start, which is
+// stored with microsecond precision.
+func periodEnd(start time.Time, period string) time.Time {
+	return nextPeriodStart(start, period).Add(-time.Microsecond)
+}
+
+// budgetLocation is the time zone the periods of the budget start in, so
+// they start at midnight of its user whatever the time zone of the server.
+func budgetLocation(b repository.Budget) *time.Location {
+	loc, err := loadLocation(b.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
 type Budget struct {
 	DB      *pgx.Conn
 	Queries *repository.Queries
+
+	// Sends the budget alerts, only needed by NotifyAlerts
+	Notifier notify.Notifier
+}
+
+// CategorizedBudget is a budget with the categories whose expenses, and the
+// ones of their subcategories, it counts. An account-wide budget has none and
+// counts every expense.
+type CategorizedBudget struct {
+	repository.Budget
+	CategoryIDs []uuid.UUID `json:"category_ids"`
 }
 
-func (s *Budget) GetByID(ctx context.Context, id, userID uuid.UUID) (repository.Budget, error) {
+func (s *Budget) GetByID(ctx context.Context, id, workspaceID uuid.UUID) (CategorizedBudget, error) {
 	b, err := s.Queries.GetBudgetByID(ctx, repository.GetBudgetByIDParams{
-		ID:     id,
-		UserID: userID,
+		ID:          id,
+		WorkspaceID: workspaceID,
 	})
 	if errors.Is(err, pgx.ErrNoRows) {
-		return repository.Budget{}, ErrBudgetNotFound
+		return CategorizedBudget{}, ErrBudgetNotFound
 	} else if err != nil {
 		fmt.Print("failed to insert:", err)
-		return repository.Budget{}, err
+		return CategorizedBudget{}, err
+	}
+
+	budgets, err := withCategories(ctx, s.Queries, []repository.Budget{b})
+	if err != nil {
+		return CategorizedBudget{}, err
 	}
 
-	return b, nil
+	return budgets[0], nil
 }
 
 func (s *Budget) GetAll(
 	ctx context.Context,
-	userID uuid.UUID,
+	workspaceID uuid.UUID,
 	limit int32,
 	cur string,
-) ([]repository.Budget, error) {
+) ([]CategorizedBudget, error) {
 	var budgets []repository.Budget
 	var err error
 
 	if cur == "" {
 		budgets, err = s.Queries.GetUserBudgets(ctx, repository.GetUserBudgetsParams{
-			UserID: userID,
-			Limit:  int32(limit),
+			WorkspaceID: workspaceID,
+			Limit:       int32(limit),
 		})
 	} else {
-		t, id, err := internal.DecodeCursor(cur)
+		var t time.Time
+		var id uuid.UUID
+		t, id, err = internal.DecodeCursor(cur)
 		if err != nil {
-			return []repository.Budget{}, ErrDecodeCursor
+			return []CategorizedBudget{}, ErrDecodeCursor
 		}
 
 		budgets, err = s.Queries.GetUserBudgetsPaged(ctx, repository.GetUserBudgetsPagedParams{
-			UserID:    userID,
-			CreatedAt: t,
-			ID:        id,
-			Limit:     int32(limit),
+			WorkspaceID: workspaceID,
+			CreatedAt:   t,
+			ID:          id,
+			Limit:       int32(limit),
 		})
 	}
 
 	if errors.Is(err, pgx.ErrNoRows) {
-		return []repository.Budget{}, ErrBudgetNotFound
+		return []CategorizedBudget{}, ErrBudgetNotFound
 	} else if err != nil {
 		fmt.Print("failed to find:", err)
-		return []repository.Budget{}, err
+		return []CategorizedBudget{}, err
 	}
 
-	return budgets, nil
+	return withCategories(ctx, s.Queries, budgets)
 }
 
+// Create adds a budget for the expenses of the categories, or of the whole
+// account without categories, between startDate and endDate or, with a
+// period, a recurring budget whose first period starts at startDate. The end
+// date of a recurring budget is the end of the period when it is zero, and
+// its next periods start in the location of startDate. The
+// members are alerted when the amount spent reaches each of the thresholds,
+// in percent of the goal.
 func (s *Budget) Create(
 	ctx context.Context,
-	userID, categoryID uuid.UUID,
+	workspaceID uuid.UUID,
+	categoryIDs []uuid.UUID,
 	goal decimal.Decimal,
 	startDate, endDate time.Time,
-) (repository.Budget, error) {
+	recurrence BudgetRecurrence,
+	thresholds []int32,
+) (CategorizedBudget, error) {
+	if recurrence.Rollover == "" {
+		recurrence.Rollover = RolloverNone
+	}
+
+	if err := recurrence.validate(); err != nil {
+		return CategorizedBudget{}, err
+	}
+
+	thresholds, err := normalizeThresholds(thresholds)
+	if err != nil {
+		return CategorizedBudget{}, err
+	}
+
+	if thresholds == nil {
+		thresholds = []int32{}
+	}
+
+	period := recurrence.Period
+	if period != "" && endDate.IsZero() {
+		endDate = periodEnd(startDate, period)
+	}
+
+	if !startDate.Before(endDate) {
+		return CategorizedBudget{}, ErrInvalidBudgetDates
+	}
+
 	now := time.Now()
 	budgetParams := repository.CreateBudgetParams{
 		ID:        uuid.New(),
 		CreatedAt: now,
 		UpdatedAt: now,
 
-		Amount:     decimal.Zero,
-		Goal:       goal,
-		StartDate:  startDate,
-		EndDate:    endDate,
-		UserID:     userID,
-		CategoryID: categoryID,
+		Amount:      decimal.Zero,
+		Goal:        goal,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		WorkspaceID: workspaceID,
+		Rollover:    recurrence.Rollover,
+		Thresholds:  thresholds,
+		TimeZone:    startDate.Location().String(),
+	}
+
+	if recurrence.Rollover == RolloverCapped {
+		budgetParams.RolloverCap = recurrence.RolloverCap
+	}
+
+	// The first period starts the series
+	if period != "" {
+		budgetParams.Period = pgtype.Text{String: period, Valid: true}
+		budgetParams.SeriesID = pgtype.UUID{Bytes: budgetParams.ID, Valid: true}
 	}
 
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
-		return repository.Budget{}, err
+		return CategorizedBudget{}, err
 	}
 	defer tx.Rollback(ctx)
 
 	qtx := s.Queries.WithTx(tx)
 
-	amount, err := qtx.GetTotalSpentInCategory(
-		ctx,
-		repository.GetTotalSpentInCategoryParams{
-			UserID:     userID,
-			CategoryID: categoryID,
-			StartDate:  startDate,
-			EndDate:    endDate,
-		},
-	)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return repository.Budget{}, ErrCategoryNotFound
-	} else if err != nil {
-		return repository.Budget{}, err
+	categoryIDs, err = checkBudgetCategories(ctx, qtx, workspaceID, categoryIDs)
+	if err != nil {
+		return CategorizedBudget{}, err
+	}
+
+	amount, err := qtx.GetTotalSpentInCategories(ctx, repository.GetTotalSpentInCategoriesParams{
+		WorkspaceID: workspaceID,
+		CategoryIds: categoryIDs,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return CategorizedBudget{}, err
 	}
 
 	budgetParams.Amount = amount
 
+	// Expenses are added to the budget in the base currency of the workspace
+	budgetParams.Currency, err = baseCurrency(ctx, qtx, workspaceID)
+	if err != nil {
+		return CategorizedBudget{}, err
+	}
+
 	b, err := qtx.CreateBudget(ctx, budgetParams)
 	if err != nil {
 		fmt.Println("failed to insert:", err)
-		return repository.Budget{}, err
+		return CategorizedBudget{}, err
+	}
+
+	err = qtx.AddBudgetCategories(ctx, repository.AddBudgetCategoriesParams{
+		BudgetID:    b.ID,
+		CategoryIds: categoryIDs,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return CategorizedBudget{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return CategorizedBudget{}, err
+	}
+
+	return CategorizedBudget{Budget: b, CategoryIDs: categoryIDs}, nil
+}
+
+// Update changes the budget and recalculates the amount spent in it. Empty
+// values keep the old ones and BudgetPeriodNone stops a recurring budget
+// after the current period. Changing the start date or period of a
+// recurring budget moves its end date to the end of the period, unless one
+// is given. A new rollover policy is applied when the period ends. The
+// categories and thresholds are replaced unless they are nil, no categories
+// make the budget account-wide and the thresholds that already fired in the
+// period are not fired again. Closed periods can not be changed.
+func (s *Budget) Update(
+	ctx context.Context,
+	id, workspaceID uuid.UUID,
+	categoryIDs []uuid.UUID,
+	goal decimal.Decimal,
+	startDate, endDate time.Time,
+	recurrence BudgetRecurrence,
+	thresholds []int32,
+) (CategorizedBudget, error) {
+	period := recurrence.Period
+	if period != "" && period != BudgetPeriodNone && !validBudgetPeriod(period) {
+		return CategorizedBudget{}, ErrInvalidBudgetPeriod
+	}
+
+	thresholds, err := normalizeThresholds(thresholds)
+	if err != nil {
+		return CategorizedBudget{}, err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return CategorizedBudget{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	b, err := qtx.GetBudgetByID(ctx, repository.GetBudgetByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CategorizedBudget{}, ErrBudgetNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return CategorizedBudget{}, err
+	}
+
+	if b.ClosedAt.Valid {
+		return CategorizedBudget{}, ErrBudgetClosed
+	}
+
+	if goal.IsZero() {
+		goal = b.Goal
+	}
+
+	recategorized := categoryIDs != nil
+	if recategorized {
+		categoryIDs, err = checkBudgetCategories(ctx, qtx, workspaceID, categoryIDs)
+	} else {
+		categoryIDs, err = budgetCategories(ctx, qtx, b.ID)
+	}
+	if err != nil {
+		return CategorizedBudget{}, err
+	}
+
+	periodText, seriesID := b.Period, b.SeriesID
+	switch period {
+	case "":
+	case BudgetPeriodNone:
+		periodText = pgtype.Text{}
+	default:
+		periodText = pgtype.Text{String: period, Valid: true}
+		if !seriesID.Valid {
+			seriesID = pgtype.UUID{Bytes: b.ID, Valid: true}
+		}
+	}
+
+	rescheduled := !startDate.IsZero() || periodText != b.Period
+
+	if recurrence.Rollover == "" {
+		recurrence.Rollover = b.Rollover
+	}
+
+	if recurrence.RolloverCap.IsZero() {
+		recurrence.RolloverCap = b.RolloverCap
+	}
+
+	// Only the rollover is left to check
+	recurrence.Period = ""
+	if err := recurrence.validate(); err != nil {
+		return CategorizedBudget{}, err
+	}
+
+	if recurrence.Rollover != RolloverCapped {
+		recurrence.RolloverCap = decimal.Zero
+	}
+
+	if thresholds == nil {
+		thresholds = b.Thresholds
+	}
+
+	if startDate.IsZero() {
+		startDate = b.StartDate.In(budgetLocation(b))
+	}
+
+	if endDate.IsZero() {
+		endDate = b.EndDate
+		if periodText.Valid && rescheduled {
+			endDate = periodEnd(startDate, periodText.String)
+		}
+	}
+
+	if !startDate.Before(endDate) {
+		return CategorizedBudget{}, ErrInvalidBudgetDates
+	}
+
+	amount, err := qtx.GetTotalSpentInCategories(ctx, repository.GetTotalSpentInCategoriesParams{
+		WorkspaceID: workspaceID,
+		CategoryIds: categoryIDs,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return CategorizedBudget{}, err
+	}
+
+	b, err = qtx.UpdateBudget(ctx, repository.UpdateBudgetParams{
+		Goal:        goal,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Amount:      amount,
+		Period:      periodText,
+		SeriesID:    seriesID,
+		Rollover:    recurrence.Rollover,
+		RolloverCap: recurrence.RolloverCap,
+		Thresholds:  thresholds,
+		UpdatedAt:   time.Now(),
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CategorizedBudget{}, ErrBudgetClosed
+	} else if isUniqueViolation(err) {
+		return CategorizedBudget{}, ErrBudgetPeriodExists
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return CategorizedBudget{}, err
+	}
+
+	if recategorized {
+		if err := qtx.DeleteBudgetCategories(ctx, b.ID); err != nil {
+			fmt.Println("failed to delete:", err)
+			return CategorizedBudget{}, err
+		}
+
+		err = qtx.AddBudgetCategories(ctx, repository.AddBudgetCategoriesParams{
+			BudgetID:    b.ID,
+			CategoryIds: categoryIDs,
+		})
+		if err != nil {
+			fmt.Println("failed to insert:", err)
+			return CategorizedBudget{}, err
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return repository.Budget{}, err
+		return CategorizedBudget{}, err
 	}
 
-	return b, nil
+	return CategorizedBudget{Budget: b, CategoryIDs: categoryIDs}, nil
 }
 
-func (s *Budget) DeleteByID(ctx context.Context, id, userID uuid.UUID) (repository.Budget, error) {
+// GetPeriods returns every period of the recurring budget the budget belongs
+// to, the latest first, or just the budget when it never recurred.
+func (s *Budget) GetPeriods(ctx context.Context, id, workspaceID uuid.UUID) ([]CategorizedBudget, error) {
+	b, err := s.GetByID(ctx, id, workspaceID)
+	if err != nil {
+		return []CategorizedBudget{}, err
+	}
+
+	if !b.SeriesID.Valid {
+		return []CategorizedBudget{b}, nil
+	}
+
+	periods, err := s.Queries.GetBudgetPeriods(ctx, repository.GetBudgetPeriodsParams{
+		SeriesID:    b.SeriesID,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []CategorizedBudget{}, err
+	}
+
+	return withCategories(ctx, s.Queries, periods)
+}
+
+// RollDue closes the periods of the recurring budgets that ended before now,
+// keeping the amount spent in them, and starts the next ones, returning how
+// many were started. A budget that was not rolled for a while catches up on
+// every missed period.
+func (s *Budget) RollDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.Queries.GetDueBudgetPeriods(ctx, repository.GetDueBudgetPeriodsParams{
+		DueAt:    now,
+		PageSize: rollBatchSize,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return 0, err
+	}
+
+	started := 0
+	for _, b := range due {
+		for b.Period.Valid && b.EndDate.Before(now) {
+			b, err = s.roll(ctx, b, now)
+			if err != nil {
+				// Keep going, the failed one is retried on the next run
+				fmt.Printf("failed to roll budget %s: %v\n", b.ID, err)
+				break
+			}
+			started++
+		}
+	}
+
+	return started, nil
+}
+
+// roll closes the period and starts the next one, with the same goal,
+// categories and thresholds and what the rollover policy carries over.
+func (s *Budget) roll(ctx context.Context, b repository.Budget, now time.Time) (repository.Budget, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return b, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	err = qtx.CloseBudgetPeriod(ctx, repository.CloseBudgetPeriodParams{
+		ClosedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		ID:       b.ID,
+	})
+	if err != nil {
+		return b, err
+	}
+
+	startDate := nextPeriodStart(b.StartDate.In(budgetLocation(b)), b.Period.String)
+	endDate := periodEnd(startDate, b.Period.String)
+
+	categoryIDs, err := budgetCategories(ctx, qtx, b.ID)
+	if err != nil {
+		return b, err
+	}
+
+	amount, err := qtx.GetTotalSpentInCategories(ctx, repository.GetTotalSpentInCategoriesParams{
+		WorkspaceID: b.WorkspaceID,
+		CategoryIds: categoryIDs,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		return b, err
+	}
+
+	next, err := qtx.CreateBudget(ctx, repository.CreateBudgetParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+
+		Amount:      amount,
+		Goal:        b.Goal,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		WorkspaceID: b.WorkspaceID,
+		Currency:    b.Currency,
+		Period:      b.Period,
+		SeriesID:    b.SeriesID,
+		Rollover:    b.Rollover,
+		RolloverCap: b.RolloverCap,
+		Carried:     carryOver(b),
+		Thresholds:  b.Thresholds,
+		TimeZone:    b.TimeZone,
+	})
+	if err != nil {
+		return b, err
+	}
+
+	err = qtx.AddBudgetCategories(ctx, repository.AddBudgetCategoriesParams{
+		BudgetID:    next.ID,
+		CategoryIds: categoryIDs,
+	})
+	if err != nil {
+		return b, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return b, err
+	}
+
+	return next, nil
+}
+
+// GetAlerts returns the thresholds fired by the budget, the lowest first.
+func (s *Budget) GetAlerts(ctx context.Context, id, workspaceID uuid.UUID) ([]repository.BudgetAlert, error) {
+	if _, err := s.GetByID(ctx, id, workspaceID); err != nil {
+		return []repository.BudgetAlert{}, err
+	}
+
+	alerts, err := s.Queries.GetBudgetAlerts(ctx, repository.GetBudgetAlertsParams{
+		BudgetID:    id,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.BudgetAlert{}, err
+	}
+
+	return alerts, nil
+}
+
+func (s *Budget) DeleteByID(ctx context.Context, id, workspaceID uuid.UUID) (CategorizedBudget, error) {
+	// The categories are deleted with the budget
+	categoryIDs, err := budgetCategories(ctx, s.Queries, id)
+	if err != nil {
+		return CategorizedBudget{}, err
+	}
+
 	b, err := s.Queries.DeleteBudget(ctx, repository.DeleteBudgetParams{
-		ID:     id,
-		UserID: userID,
+		ID:          id,
+		WorkspaceID: workspaceID,
 	})
 	if errors.Is(err, pgx.ErrNoRows) {
-		return repository.Budget{}, ErrBudgetNotFound
+		return CategorizedBudget{}, ErrBudgetNotFound
 	} else if err != nil {
 		fmt.Println("failed to delete:", err)
-		return repository.Budget{}, err
+		return CategorizedBudget{}, err
+	}
+
+	return CategorizedBudget{Budget: b, CategoryIDs: categoryIDs}, nil
+}
+
+// checkBudgetCategories removes the repeated categories and checks they all
+// belong to the workspace. It never returns nil, which the queries would take
+// as NULL instead of no categories.
+func checkBudgetCategories(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID uuid.UUID,
+	categoryIDs []uuid.UUID,
+) ([]uuid.UUID, error) {
+	unique := make([]uuid.UUID, 0, len(categoryIDs))
+	seen := make(map[uuid.UUID]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	if len(unique) == 0 {
+		return unique, nil
+	}
+
+	count, err := queries.CountUserCategories(ctx, repository.CountUserCategoriesParams{
+		WorkspaceID: workspaceID,
+		Ids:         unique,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	if count != int64(len(unique)) {
+		return nil, ErrCategoryNotFound
+	}
+
+	return unique, nil
+}
+
+// budgetCategories returns the IDs of the categories of the budget, empty
+// when it is account-wide.
+func budgetCategories(ctx context.Context, queries *repository.Queries, id uuid.UUID) ([]uuid.UUID, error) {
+	budgets, err := withCategories(ctx, queries, []repository.Budget{{ID: id}})
+	if err != nil {
+		return nil, err
+	}
+
+	return budgets[0].CategoryIDs, nil
+}
+
+// withCategories adds the IDs of their categories to the budgets.
+func withCategories(
+	ctx context.Context,
+	queries *repository.Queries,
+	budgets []repository.Budget,
+) ([]CategorizedBudget, error) {
+	categorized := make([]CategorizedBudget, len(budgets))
+	if len(budgets) == 0 {
+		return categorized, nil
+	}
+
+	ids := make([]uuid.UUID, len(budgets))
+	for i, b := range budgets {
+		ids[i] = b.ID
+	}
+
+	rows, err := queries.GetBudgetsCategories(ctx, ids)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	categories := make(map[uuid.UUID][]uuid.UUID, len(budgets))
+	for _, row := range rows {
+		categories[row.BudgetID] = append(categories[row.BudgetID], row.ID)
+	}
+
+	for i, b := range budgets {
+		categoryIDs := categories[b.ID]
+		if categoryIDs == nil {
+			categoryIDs = []uuid.UUID{}
+		}
+
+		categorized[i] = CategorizedBudget{Budget: b, CategoryIDs: categoryIDs}
 	}
 
-	return b, nil
+	return categorized, nil
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/notify"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Thresholds are percentages of the effective goal, above 100 to alert
+// when a budget is exceeded by some margin
+const maxBudgetThreshold = 1000
+
+// normalizeThresholds checks the thresholds of a budget and sorts them
+// without duplicates. A nil slice stays nil, which keeps the current ones
+// when updating a budget.
+func normalizeThresholds(thresholds []int32) ([]int32, error) {
+	if thresholds == nil {
+		return nil, nil
+	}
+
+	for _, t := range thresholds {
+		if t < 1 || t > maxBudgetThreshold {
+			return nil, ErrInvalidThresholds
+		}
+	}
+
+	normalized := slices.Clone(thresholds)
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized), nil
+}
+
+// updateBudgetAmount adds the base amount of an expense to the budgets of
+// the workspace containing spentAt that include its category, and records the
+// thresholds they reached. queries should be bound to a transaction, the
+// alerts are sent later by the scheduler with Budget.NotifyAlerts.
+func updateBudgetAmount(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID, categoryID uuid.UUID,
+	baseAmount decimal.Decimal,
+	spentAt time.Time,
+) error {
+	err := queries.UpdateBudgetAmount(ctx, repository.UpdateBudgetAmountParams{
+		WorkspaceID: workspaceID,
+		CategoryID:  categoryID,
+		BaseAmount:  baseAmount,
+		SpentAt:     spentAt,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	err = queries.FireBudgetAlerts(ctx, repository.FireBudgetAlertsParams{
+		WorkspaceID: workspaceID,
+		CategoryID:  categoryID,
+		CreatedAt:   time.Now(),
+		SpentAt:     spentAt,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return err
+	}
+
+	return nil
+}
+
+// NotifyAlerts sends the budget alerts that were fired since the last call
+// through Notifier and returns how many were sent. The expenses that fired
+// them are already saved, so failing to deliver one is only logged and the
+// alert is not sent again.
+func (s *Budget) NotifyAlerts(ctx context.Context, now time.Time) (int, error) {
+	alerts, err := s.Queries.GetPendingBudgetAlerts(ctx)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return 0, err
+	}
+
+	for i, alert := range alerts {
+		notifications, err := budgetAlertNotifications(ctx, s.Queries, alert)
+		if err != nil {
+			fmt.Println("failed to find:", err)
+			return i, err
+		}
+
+		for _, n := range notifications {
+			if err := s.Notifier.Notify(ctx, n); err != nil {
+				fmt.Printf("failed to notify budget alert %s: %v\n", alert.ID, err)
+			}
+		}
+
+		err = s.Queries.MarkBudgetAlertNotified(ctx, repository.MarkBudgetAlertNotifiedParams{
+			ID:         alert.ID,
+			NotifiedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		})
+		if err != nil {
+			fmt.Println("failed to update:", err)
+			return i, err
+		}
+	}
+
+	return len(alerts), nil
+}
+
+// budgetAlertNotifications returns the notification of the alert for each
+// member of the workspace of the budget.
+func budgetAlertNotifications(
+	ctx context.Context,
+	queries *repository.Queries,
+	alert repository.BudgetAlert,
+) ([]notify.Notification, error) {
+	members, err := queries.GetWorkspaceMembers(ctx, alert.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := queries.GetBudgetByID(ctx, repository.GetBudgetByIDParams{
+		ID:          alert.BudgetID,
+		WorkspaceID: alert.WorkspaceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := queries.GetBudgetsCategories(ctx, []uuid.UUID{b.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Account-wide budgets have no categories
+	name := "Total"
+	categoryIDs := make([]uuid.UUID, len(categories))
+	if len(categories) > 0 {
+		names := make([]string, len(categories))
+		for i, c := range categories {
+			categoryIDs[i] = c.ID
+			names[i] = c.Name
+		}
+
+		name = strings.Join(names, " + ")
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"budget_id":    b.ID,
+		"category_ids": categoryIDs,
+		"threshold":    alert.Threshold,
+		"amount":       alert.Amount,
+		"goal":         alert.Goal,
+		"currency":     b.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]notify.Notification, len(members))
+	for i, member := range members {
+		notifications[i] = notify.Notification{
+			UserID: member.UserID,
+			Email:  member.Email,
+			Kind:   notify.KindBudgetAlert,
+			Title:  fmt.Sprintf("%s budget reached %d%%", name, alert.Threshold),
+			Message: fmt.Sprintf(
+				"You spent %s %s of the %s %s budgeted for %s between %s and %s.",
+				alert.Amount.StringFixed(2), b.Currency,
+				alert.Goal.StringFixed(2), b.Currency,
+				name,
+				b.StartDate.Format(time.DateOnly), b.EndDate.Format(time.DateOnly),
+			),
+			Data: data,
+		}
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// BudgetForecast projects the spending of a budget to the end of its period.
+// Days are counted from the start of the budget and include the current one,
+// which is already spent in. Amounts are in the currency of the budget and
+// compared with its effective goal.
+type BudgetForecast struct {
+	BudgetID  uuid.UUID       `json:"budget_id"`
+	AsOf      time.Time       `json:"as_of"`
+	Currency  string          `json:"currency"`
+	Amount    decimal.Decimal `json:"amount"`
+	Goal      decimal.Decimal `json:"goal"`
+	Remaining decimal.Decimal `json:"remaining"`
+
+	DaysTotal     int `json:"days_total"`
+	DaysElapsed   int `json:"days_elapsed"`
+	DaysRemaining int `json:"days_remaining"` // after the current one
+
+	DailyBurnRate  decimal.Decimal `json:"daily_burn_rate"`
+	ProjectedSpend decimal.Decimal `json:"projected_spend"`
+	// What is spent so far plus what was spent in the rest of the same
+	// period last year, nil when nothing was spent in it
+	ProjectedSpendLastYear *decimal.Decimal `json:"projected_spend_last_year"`
+	// When the goal is reached at the daily burn rate, nil when it is not
+	// before the end of the period. It is in the past once it was exceeded.
+	ExceedsOn *time.Time `json:"exceeds_on"`
+	// What can be spent each of the remaining days without exceeding the
+	// goal, or on the current one when it is the last
+	SafeToSpendPerDay decimal.Decimal `json:"safe_to_spend_per_day"`
+}
+
+// Forecast projects the spending of the budget as of now, or as of its end
+// once the period is over.
+func (s *Budget) Forecast(ctx context.Context, id, workspaceID uuid.UUID, now time.Time) (BudgetForecast, error) {
+	b, err := s.GetByID(ctx, id, workspaceID)
+	if err != nil {
+		return BudgetForecast{}, err
+	}
+
+	// Days start at midnight in the time zone of the budget
+	loc := budgetLocation(b.Budget)
+	b.StartDate, b.EndDate = b.StartDate.In(loc), b.EndDate.In(loc)
+
+	asOf := now.In(loc)
+	if asOf.After(b.EndDate) {
+		asOf = b.EndDate
+	}
+
+	daysTotal := daysIn(b.StartDate, b.EndDate)
+	daysElapsed := 0
+	if !asOf.Before(b.StartDate) {
+		daysElapsed = min(dayNumber(asOf)-dayNumber(b.StartDate)+1, daysTotal)
+	}
+	daysRemaining := daysTotal - daysElapsed
+
+	f := BudgetForecast{
+		BudgetID:      b.ID,
+		AsOf:          asOf,
+		Currency:      b.Currency,
+		Amount:        b.Amount,
+		Goal:          b.EffectiveGoal,
+		Remaining:     b.EffectiveGoal.Sub(b.Amount),
+		DaysTotal:     daysTotal,
+		DaysElapsed:   daysElapsed,
+		DaysRemaining: daysRemaining,
+	}
+
+	f.DailyBurnRate = decimal.Zero
+	if daysElapsed > 0 {
+		f.DailyBurnRate = b.Amount.Div(decimal.NewFromInt(int64(daysElapsed)))
+	}
+
+	f.ProjectedSpend = b.Amount.Add(f.DailyBurnRate.Mul(decimal.NewFromInt(int64(daysRemaining))))
+
+	if f.DailyBurnRate.IsPositive() {
+		// Days of spending at the burn rate the goal lasts for
+		days := b.EffectiveGoal.Div(f.DailyBurnRate).Ceil().IntPart()
+		if days <= int64(daysTotal) {
+			exceedsOn := b.StartDate.AddDate(0, 0, int(max(days-1, 0)))
+			f.ExceedsOn = &exceedsOn
+		}
+	}
+
+	left := decimal.Max(f.Remaining, decimal.Zero)
+	f.SafeToSpendPerDay = left
+	if daysRemaining > 0 {
+		f.SafeToSpendPerDay = left.Div(decimal.NewFromInt(int64(daysRemaining)))
+	}
+
+	f.ProjectedSpendLastYear, err = s.projectFromLastYear(ctx, b, asOf)
+	if err != nil {
+		return BudgetForecast{}, err
+	}
+
+	f.DailyBurnRate = f.DailyBurnRate.Round(2)
+	f.ProjectedSpend = f.ProjectedSpend.Round(2)
+	f.SafeToSpendPerDay = f.SafeToSpendPerDay.Round(2)
+
+	return f, nil
+}
+
+// projectFromLastYear adds what was spent in the rest of the same period
+// last year to the amount of the budget.
+func (s *Budget) projectFromLastYear(
+	ctx context.Context,
+	b CategorizedBudget,
+	asOf time.Time,
+) (*decimal.Decimal, error) {
+	startDate := b.StartDate.AddDate(-1, 0, 0)
+	endDate := b.EndDate.AddDate(-1, 0, 0)
+
+	total, err := s.Queries.GetTotalSpentInCategories(ctx, repository.GetTotalSpentInCategoriesParams{
+		WorkspaceID: b.WorkspaceID,
+		CategoryIds: b.CategoryIDs,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	if total.IsZero() {
+		return nil, nil
+	}
+
+	// Before the period starts the whole of last year is still ahead
+	spentToDate := decimal.Zero
+	if asOf.After(b.StartDate) {
+		spentToDate, err = s.Queries.GetTotalSpentInCategories(ctx, repository.GetTotalSpentInCategoriesParams{
+			WorkspaceID: b.WorkspaceID,
+			CategoryIds: b.CategoryIDs,
+			StartDate:   startDate,
+			EndDate:     asOf.AddDate(-1, 0, 0),
+		})
+		if err != nil {
+			fmt.Println("failed to find:", err)
+			return nil, err
+		}
+	}
+
+	projected := b.Amount.Add(total.Sub(spentToDate)).Round(2)
+
+	return &projected, nil
+}
+
+// daysIn counts the days from start to end, including the one end is in
+// unless end is its midnight, so a budget of a whole month has as many days
+// as the month
+func daysIn(start, end time.Time) int {
+	days := dayNumber(end) - dayNumber(start)
+
+	if y, m, d := end.Date(); !end.Equal(time.Date(y, m, d, 0, 0, 0, 0, end.Location())) {
+		days++
+	}
+
+	return days
+}
+
+// dayNumber numbers the calendar days in the location of t, so the days the
+// clocks change, 23 or 25 hours long, still count as one.
+func dayNumber(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+}
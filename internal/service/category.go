@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jamcunha/expense-tracker/internal"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 )
@@ -17,10 +18,10 @@ type Category struct {
 	Queries *repository.Queries
 }
 
-func (s *Category) GetByID(ctx context.Context, id, userID uuid.UUID) (repository.Category, error) {
+func (s *Category) GetByID(ctx context.Context, id, workspaceID uuid.UUID) (repository.Category, error) {
 	c, err := s.Queries.GetCategoryByID(ctx, repository.GetCategoryByIDParams{
-		ID:     id,
-		UserID: userID,
+		ID:          id,
+		WorkspaceID: workspaceID,
 	})
 	if errors.Is(err, pgx.ErrNoRows) {
 		return repository.Category{}, ErrCategoryNotFound
@@ -34,7 +35,7 @@ func (s *Category) GetByID(ctx context.Context, id, userID uuid.UUID) (repositor
 
 func (s *Category) GetAll(
 	ctx context.Context,
-	userID uuid.UUID,
+	workspaceID uuid.UUID,
 	limit int32,
 	cur string,
 ) ([]repository.Category, error) {
@@ -43,20 +44,22 @@ func (s *Category) GetAll(
 
 	if cur == "" {
 		categories, err = s.Queries.GetUserCategories(ctx, repository.GetUserCategoriesParams{
-			UserID: userID,
-			Limit:  int32(limit),
+			WorkspaceID: workspaceID,
+			Limit:       int32(limit),
 		})
 	} else {
-		t, id, err := internal.DecodeCursor(cur)
+		var t time.Time
+		var id uuid.UUID
+		t, id, err = internal.DecodeCursor(cur)
 		if err != nil {
 			return []repository.Category{}, err
 		}
 
 		categories, err = s.Queries.GetUserCategoriesPaged(ctx, repository.GetUserCategoriesPagedParams{
-			UserID:    userID,
-			CreatedAt: t,
-			ID:        id,
-			Limit:     int32(limit),
+			WorkspaceID: workspaceID,
+			CreatedAt:   t,
+			ID:          id,
+			Limit:       int32(limit),
 		})
 	}
 
@@ -70,19 +73,72 @@ func (s *Category) GetAll(
 	return categories, nil
 }
 
+// CategoryNode is a category with its subcategories
+type CategoryNode struct {
+	repository.Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// GetTree returns the top level categories with their subcategories, sorted
+// by name.
+func (s *Category) GetTree(ctx context.Context, workspaceID uuid.UUID) ([]*CategoryNode, error) {
+	categories, err := s.Queries.GetAllUserCategories(ctx, workspaceID)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []*CategoryNode{}, err
+	}
+
+	nodes := make(map[uuid.UUID]*CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &CategoryNode{Category: c, Children: []*CategoryNode{}}
+	}
+
+	roots := []*CategoryNode{}
+	for _, c := range categories {
+		parent, ok := nodes[c.ParentID.Bytes]
+		if !c.ParentID.Valid || !ok {
+			roots = append(roots, nodes[c.ID])
+			continue
+		}
+
+		parent.Children = append(parent.Children, nodes[c.ID])
+	}
+
+	return roots, nil
+}
+
 func (s *Category) Create(
 	ctx context.Context,
 	name string,
-	userID uuid.UUID,
+	workspaceID uuid.UUID,
+	parentID uuid.UUID,
 ) (repository.Category, error) {
+	params := repository.CreateCategoryParams{
+		ID:          uuid.New(),
+		Name:        name,
+		WorkspaceID: workspaceID,
+	}
+
+	if parentID != uuid.Nil {
+		_, err := s.Queries.GetCategoryByID(ctx, repository.GetCategoryByIDParams{
+			ID:          parentID,
+			WorkspaceID: workspaceID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Category{}, ErrParentCategoryNotFound
+		} else if err != nil {
+			fmt.Println("failed to find:", err)
+			return repository.Category{}, err
+		}
+
+		params.ParentID = pgtype.UUID{Bytes: parentID, Valid: true}
+	}
+
 	now := time.Now()
-	c, err := s.Queries.CreateCategory(ctx, repository.CreateCategoryParams{
-		ID:        uuid.New(),
-		CreatedAt: now,
-		UpdatedAt: now,
-		Name:      name,
-		UserID:    userID,
-	})
+	params.CreatedAt = now
+	params.UpdatedAt = now
+
+	c, err := s.Queries.CreateCategory(ctx, params)
 	if err != nil {
 		fmt.Println("failed to insert:", err)
 		return repository.Category{}, err
@@ -91,34 +147,135 @@ func (s *Category) Create(
 	return c, nil
 }
 
+// Update renames the category and moves it under parentID. An empty name
+// and a nil parentID are kept as they are, a parentID of uuid.Nil makes it a
+// top level category.
 func (s *Category) Update(
 	ctx context.Context,
-	id, userID uuid.UUID,
+	id, workspaceID uuid.UUID,
 	name string,
+	parentID *uuid.UUID,
 ) (repository.Category, error) {
-	c, err := s.Queries.UpdateCategory(ctx, repository.UpdateCategoryParams{
-		Name:      name,
-		UpdatedAt: time.Now(),
-		ID:        id,
-		UserID:    userID,
-	})
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.Category{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
 
+	c, err := qtx.GetCategoryByID(ctx, repository.GetCategoryByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
 	if errors.Is(err, pgx.ErrNoRows) {
 		return repository.Category{}, ErrCategoryNotFound
 	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Category{}, err
+	}
+
+	if name == "" {
+		name = c.Name
+	}
+
+	parent := c.ParentID
+	if parentID != nil && *parentID == uuid.Nil {
+		parent = pgtype.UUID{}
+	} else if parentID != nil {
+		_, err := qtx.GetCategoryByID(ctx, repository.GetCategoryByIDParams{
+			ID:          *parentID,
+			WorkspaceID: workspaceID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Category{}, ErrParentCategoryNotFound
+		} else if err != nil {
+			fmt.Println("failed to find:", err)
+			return repository.Category{}, err
+		}
+
+		cycle, err := qtx.IsCategoryInSubtree(ctx, repository.IsCategoryInSubtreeParams{
+			RootID:     id,
+			CategoryID: *parentID,
+		})
+		if err != nil {
+			fmt.Println("failed to find:", err)
+			return repository.Category{}, err
+		}
+
+		if cycle {
+			return repository.Category{}, ErrCategoryCycle
+		}
+
+		parent = pgtype.UUID{Bytes: *parentID, Valid: true}
+	}
+
+	moved := parent != c.ParentID
+
+	c, err = qtx.UpdateCategory(ctx, repository.UpdateCategoryParams{
+		Name:        name,
+		ParentID:    parent,
+		UpdatedAt:   time.Now(),
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Category{}, err
+	}
+
+	// The budgets of the old and new parents include the moved expenses
+	if moved {
+		if err := qtx.RecalculateUserBudgetAmounts(ctx, workspaceID); err != nil {
+			fmt.Println("failed to update:", err)
+			return repository.Category{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
 		return repository.Category{}, err
 	}
 
 	return c, nil
 }
 
+// DeleteByID deletes the category with its expenses, including the ones with
+// a split in it, and the budgets of only this category, its subcategories
+// become top level categories. The budgets of other categories too just stop
+// including it.
 func (s *Category) DeleteByID(
 	ctx context.Context,
-	id, userID uuid.UUID,
+	id, workspaceID uuid.UUID,
 ) (repository.Category, error) {
-	c, err := s.Queries.DeleteCategory(ctx, repository.DeleteCategoryParams{
-		ID:     id,
-		UserID: userID,
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.Category{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	err = qtx.DeleteCategoryOnlyBudgets(ctx, repository.DeleteCategoryOnlyBudgetsParams{
+		WorkspaceID: workspaceID,
+		CategoryID:  id,
+	})
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Category{}, err
+	}
+
+	err = qtx.DeleteCategorySplitExpenses(ctx, repository.DeleteCategorySplitExpensesParams{
+		WorkspaceID: workspaceID,
+		CategoryID:  id,
+	})
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Category{}, err
+	}
+
+	c, err := qtx.DeleteCategory(ctx, repository.DeleteCategoryParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
 	})
 	if errors.Is(err, pgx.ErrNoRows) {
 		return repository.Category{}, ErrCategoryNotFound
@@ -127,5 +284,37 @@ func (s *Category) DeleteByID(
 		return repository.Category{}, err
 	}
 
+	// The budgets of the parent categories no longer include the deleted
+	// expenses nor the subcategories
+	if err := qtx.RecalculateUserBudgetAmounts(ctx, workspaceID); err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Category{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.Category{}, err
+	}
+
 	return c, nil
 }
+
+// checkCategory checks the category belongs to the workspace, so nothing
+// can be filed under the category of another one.
+func checkCategory(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID, categoryID uuid.UUID,
+) error {
+	_, err := queries.GetCategoryByID(ctx, repository.GetCategoryByIDParams{
+		ID:          categoryID,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCategoryNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return err
+	}
+
+	return nil
+}
@@ -5,13 +5,99 @@ import (
 )
 
 var (
-	ErrUserNotFound     = errors.New("User not found")
-	ErrCategoryNotFound = errors.New("Category not found")
-	ErrExpenseNotFound  = errors.New("Expense not found")
-	ErrBudgetNotFound   = errors.New("Budget not found")
+	ErrUserNotFound      = errors.New("User not found")
+	ErrCategoryNotFound  = errors.New("Category not found")
+	ErrExpenseNotFound   = errors.New("Expense not found")
+	ErrBudgetNotFound    = errors.New("Budget not found")
+	ErrSessionNotFound   = errors.New("Session not found")
+	ErrTagNotFound       = errors.New("Tag not found")
+	ErrIncomeNotFound    = errors.New("Income not found")
+	ErrAccountNotFound   = errors.New("Account not found")
+	ErrTransferNotFound  = errors.New("Transfer not found")
+	ErrWorkspaceNotFound = errors.New("Workspace not found")
+
+	ErrNotificationNotFound    = errors.New("Notification not found")
+	ErrWorkspaceMemberNotFound = errors.New("Workspace member not found")
+	ErrInvitationNotFound      = errors.New("Invitation not found")
+
+	ErrParentCategoryNotFound = errors.New("Parent category not found")
+	ErrCategoryCycle          = errors.New("Category can not be a subcategory of itself")
+
+	ErrInvalidTag   = errors.New("Tag names must have between 1 and 255 characters")
+	ErrTagExists    = errors.New("Tag already exists")
+	ErrMergeSameTag = errors.New("Can not merge a tag into itself")
+
+	ErrRecurringExpenseNotFound = errors.New("Recurring expense not found")
+	ErrInvalidRecurrence        = errors.New("Invalid recurrence rule")
+
+	ErrInvalidPeriod = errors.New("Invalid report period")
+
+	ErrInvalidBudgetPeriod = errors.New("Invalid budget period")
+	ErrInvalidBudgetDates  = errors.New("Budget must start before it ends")
+	ErrBudgetClosed        = errors.New("Budget period is closed")
+	ErrBudgetPeriodExists  = errors.New("Budget already has a period starting at that date")
+	ErrInvalidRollover     = errors.New("Invalid budget rollover")
+	ErrInvalidThresholds   = errors.New("Budget thresholds must be between 1 and 1000 percent")
+
+	ErrInvalidIncome = errors.New("Income needs a source of up to 255 characters and a positive amount")
+
+	ErrInvalidAccount  = errors.New("Account needs a name of up to 255 characters and a type: checking, savings, credit_card or cash")
+	ErrAccountExists   = errors.New("Account already exists")
+	ErrAccountCurrency = errors.New("Amount must be in the currency of the account")
+	ErrInvalidTransfer = errors.New("Transfer needs two different accounts and a positive amount")
+
+	ErrInvalidSplits = errors.New("Splits need a category and a positive amount each, adding up to the amount of the expense")
+
+	ErrInvalidWorkspace       = errors.New("Workspace needs a name of up to 255 characters")
+	ErrInvalidRole            = errors.New("Role must be owner, editor or viewer")
+	ErrWorkspaceForbidden     = errors.New("Only the owners can manage the workspace")
+	ErrPersonalWorkspace      = errors.New("Personal workspaces can not be shared or deleted")
+	ErrLastOwner              = errors.New("Workspace must keep an owner")
+	ErrInvalidInvitationEmail = errors.New("Invitation needs a valid email")
+	ErrInvalidInvitation      = errors.New("Invitation is invalid or expired")
+	ErrInvitationEmail        = errors.New("Invitation was sent to another email")
+
+	ErrInvalidSearch      = errors.New("Search query has no words")
+	ErrInvalidExpenseSort = errors.New("Invalid expense sort")
+
+	ErrInvalidCSV           = errors.New("Invalid CSV file")
+	ErrInvalidImportMapping = errors.New("Invalid column mapping")
+	ErrInvalidImportRows    = errors.New("Some rows are invalid")
+
+	ErrInvalidExportFormat = errors.New("Invalid export format")
+
+	ErrInvalidCurrency      = errors.New("Invalid currency code")
+	ErrExchangeRateNotFound = errors.New("No exchange rate")
+	ErrInvalidRatesFile     = errors.New("Invalid exchange rates file")
+
+	ErrInvalidUserName  = errors.New("Name must have up to 255 characters")
+	ErrInvalidEmail     = errors.New("Invalid email")
+	ErrEmailExists      = errors.New("Email is already in use")
+	ErrInvalidPassword  = errors.New("Password must have between 8 and 72 characters")
+	ErrInvalidUserToken = errors.New("Token is invalid, expired or was already used")
+	ErrEmailVerified    = errors.New("Email is already verified")
+	ErrEmailNotVerified = errors.New("Email must be verified first")
+
+	ErrInvalidTimeZone      = errors.New("Invalid time zone. Use an IANA name like Europe/Lisbon")
+	ErrInvalidLocale        = errors.New("Invalid locale. Use a language tag like en-US")
+	ErrInvalidWeekStart     = errors.New("First day of the week must be between 0 (Sunday) and 6 (Saturday)")
+	ErrInvalidMonthStartDay = errors.New("Month start day must be between 1 and 28")
+	ErrCurrencyInUse        = errors.New("Base currency can not change once the personal workspace has expenses, incomes or budgets")
+
+	ErrMFAEnabled     = errors.New("Two-factor authentication is already enabled")
+	ErrMFANotEnabled  = errors.New("Two-factor authentication is not enabled")
+	ErrMFANotEnrolled = errors.New("Two-factor authentication enrollment was not started")
+	ErrInvalidMFACode = errors.New("Invalid or already used code")
+	ErrMFALocked      = errors.New("Too many wrong passwords or codes, try again later")
+
+	ErrAPIKeyNotFound      = errors.New("API key not found")
+	ErrInvalidAPIKeyName   = errors.New("API key name must have between 1 and 255 characters")
+	ErrInvalidAPIKeyScope  = errors.New("Invalid scope. Use <resource>:read or <resource>:write, like expenses:read")
+	ErrInvalidAPIKeyExpiry = errors.New("API key must expire in the future")
 
 	ErrWrongCredentials = errors.New("Wrong Credentials")
 	ErrExpiredToken     = errors.New("Token is expired")
 	ErrInvalidToken     = errors.New("Token is invalid")
+	ErrTokenReused      = errors.New("Refresh token was already used")
 	ErrDecodeCursor     = errors.New("Error decoding page cursor")
 )
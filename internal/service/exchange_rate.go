@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Formats of the exchange rate files
+const (
+	// Header date,base,currency,rate, e.g. 2024-01-02,EUR,USD,1.0956
+	RatesCSV = "csv"
+	// The eurofxref XML files published by the European Central Bank
+	RatesECB = "ecb"
+)
+
+// Rates between two currencies without a direct rate are derived from their
+// rates against the pivot, the base of the ECB rates.
+const pivotCurrency = "EUR"
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code.
+// Whether it can be used depends on the exchange rates that were loaded.
+func ValidCurrency(code string) bool {
+	return currencyCode.MatchString(code)
+}
+
+type ExchangeRate struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+// Load stores the rates in r, replacing the ones of the same day, and
+// returns how many were read.
+func (s *ExchangeRate) Load(ctx context.Context, r io.Reader, format string) (int, error) {
+	var rates []repository.UpsertExchangeRateParams
+	var err error
+
+	switch format {
+	case RatesCSV:
+		rates, err = parseRatesCSV(r)
+	case RatesECB:
+		rates, err = parseRatesECB(r)
+	default:
+		return 0, ErrInvalidRatesFile
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	for _, rate := range rates {
+		if err := qtx.UpsertExchangeRate(ctx, rate); err != nil {
+			fmt.Println("failed to insert:", err)
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+func parseRatesCSV(r io.Reader) ([]repository.UpsertExchangeRateParams, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRatesFile, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidRatesFile)
+	}
+
+	header := strings.ToLower(strings.Join(records[0], ","))
+	if header != "date,base,currency,rate" {
+		return nil, fmt.Errorf("%w: the header must be date,base,currency,rate", ErrInvalidRatesFile)
+	}
+
+	rates := make([]repository.UpsertExchangeRateParams, 0, len(records)-1)
+	for i, record := range records[1:] {
+		rate, err := newRate(record[0], record[1], record[2], record[3])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRatesFile, i+2, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func parseRatesECB(r io.Reader) ([]repository.UpsertExchangeRateParams, error) {
+	var envelope struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube>Cube"`
+	}
+
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRatesFile, err)
+	}
+
+	var rates []repository.UpsertExchangeRateParams
+	for _, day := range envelope.Days {
+		for _, r := range day.Rates {
+			rate, err := newRate(day.Time, pivotCurrency, r.Currency, r.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidRatesFile, err)
+			}
+
+			rates = append(rates, rate)
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: no rates found", ErrInvalidRatesFile)
+	}
+
+	return rates, nil
+}
+
+func newRate(date, base, currency, rate string) (repository.UpsertExchangeRateParams, error) {
+	day, err := time.Parse(time.DateOnly, strings.TrimSpace(date))
+	if err != nil {
+		return repository.UpsertExchangeRateParams{}, fmt.Errorf("invalid date %q", date)
+	}
+
+	base = strings.ToUpper(strings.TrimSpace(base))
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !ValidCurrency(base) || !ValidCurrency(currency) {
+		return repository.UpsertExchangeRateParams{}, fmt.Errorf("invalid currency %q or %q", base, currency)
+	}
+
+	value, err := decimal.NewFromString(strings.TrimSpace(rate))
+	if err != nil || !value.IsPositive() {
+		return repository.UpsertExchangeRateParams{}, fmt.Errorf("invalid rate %q", rate)
+	}
+
+	return repository.UpsertExchangeRateParams{
+		Date:     pgtype.Date{Time: day, Valid: true},
+		Base:     base,
+		Currency: currency,
+		Rate:     value,
+	}, nil
+}
+
+// currencyConverter converts amounts to the base currency of a workspace,
+// caching the rates it looks up.
+type currencyConverter struct {
+	queries *repository.Queries
+	base    string
+	loc     *time.Location
+	rates   map[string]decimal.Decimal
+}
+
+func newCurrencyConverter(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID uuid.UUID,
+) (*currencyConverter, error) {
+	base, err := baseCurrency(ctx, queries, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := workspaceLocation(ctx, queries, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &currencyConverter{
+		queries: queries,
+		base:    base,
+		loc:     loc,
+		rates:   map[string]decimal.Decimal{},
+	}, nil
+}
+
+// baseCurrency returns the currency budgets and reports of the workspace are
+// in.
+func baseCurrency(ctx context.Context, queries *repository.Queries, workspaceID uuid.UUID) (string, error) {
+	w, err := queries.GetWorkspaceByID(ctx, workspaceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrWorkspaceNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return "", err
+	}
+
+	return w.Currency, nil
+}
+
+// workspaceLocation is the time zone the days of the workspace are in, the
+// one of its first owner.
+func workspaceLocation(ctx context.Context, queries *repository.Queries, workspaceID uuid.UUID) (*time.Location, error) {
+	name, err := queries.GetWorkspaceTimeZone(ctx, workspaceID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.UTC, nil
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	loc, err := loadLocation(name)
+	if err != nil {
+		return time.UTC, nil
+	}
+
+	return loc, nil
+}
+
+// rateDay is the day of the rates used at t, the day t is in at loc. Rates
+// are stored by date, so it is returned at midnight UTC.
+func rateDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// toBase converts amount to the base currency with the rate of the day it
+// was spent at in the time zone of the workspace.
+func (c *currencyConverter) toBase(
+	ctx context.Context,
+	amount decimal.Decimal,
+	currency string,
+	spentAt time.Time,
+) (decimal.Decimal, error) {
+	if currency == c.base {
+		return amount, nil
+	}
+
+	day := rateDay(spentAt, c.loc)
+
+	key := currency + day.Format(time.DateOnly)
+	rate, ok := c.rates[key]
+	if !ok {
+		var err error
+		rate, err = exchangeRate(ctx, c.queries, currency, c.base, day)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		c.rates[key] = rate
+	}
+
+	return amount.Mul(rate).Round(2), nil
+}
+
+// exchangeRate returns how much 1 from is worth in to on day, using the
+// direct rate, the inverse one or both rates against the pivot currency.
+func exchangeRate(
+	ctx context.Context,
+	queries *repository.Queries,
+	from, to string,
+	day time.Time,
+) (decimal.Decimal, error) {
+	rate, _, err := datedExchangeRate(ctx, queries, from, to, day)
+	return rate, err
+}
+
+// datedExchangeRate is exchangeRate with the date of the rate used. Of the
+// rates known on or before day the most recent wins, so an old rate entered
+// by hand does not hide newer ones through the pivot currency. A rate through
+// the pivot is as old as the older of its two rates.
+func datedExchangeRate(
+	ctx context.Context,
+	queries *repository.Queries,
+	from, to string,
+	day time.Time,
+) (decimal.Decimal, time.Time, error) {
+	var rate decimal.Decimal
+	var date time.Time
+	found := false
+
+	// On the same date the direct rate is preferred, then the inverse one
+	candidate := func(r decimal.Decimal, d time.Time) {
+		if !found || d.After(date) {
+			rate, date, found = r, d, true
+		}
+	}
+
+	direct, err := queries.GetExchangeRate(ctx, repository.GetExchangeRateParams{
+		Base:     from,
+		Currency: to,
+		OnDate:   pgtype.Date{Time: day, Valid: true},
+	})
+	if err == nil {
+		candidate(direct.Rate, direct.Date.Time)
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Println("failed to find:", err)
+		return decimal.Zero, time.Time{}, err
+	}
+
+	inverse, err := queries.GetExchangeRate(ctx, repository.GetExchangeRateParams{
+		Base:     to,
+		Currency: from,
+		OnDate:   pgtype.Date{Time: day, Valid: true},
+	})
+	if err == nil {
+		candidate(decimal.NewFromInt(1).DivRound(inverse.Rate, 16), inverse.Date.Time)
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Println("failed to find:", err)
+		return decimal.Zero, time.Time{}, err
+	}
+
+	if from != pivotCurrency && to != pivotCurrency {
+		fromRate, fromDate, err := datedExchangeRate(ctx, queries, pivotCurrency, from, day)
+		if err != nil && !errors.Is(err, ErrExchangeRateNotFound) {
+			return decimal.Zero, time.Time{}, err
+		}
+
+		if err == nil {
+			toRate, toDate, err := datedExchangeRate(ctx, queries, pivotCurrency, to, day)
+			if err != nil && !errors.Is(err, ErrExchangeRateNotFound) {
+				return decimal.Zero, time.Time{}, err
+			}
+
+			if err == nil {
+				older := fromDate
+				if toDate.Before(older) {
+					older = toDate
+				}
+
+				candidate(toRate.DivRound(fromRate, 16), older)
+			}
+		}
+	}
+
+	if !found {
+		return decimal.Zero, time.Time{}, fmt.Errorf("%w from %s to %s on %s", ErrExchangeRateNotFound, from, to, day.Format(time.DateOnly))
+	}
+
+	return rate, date, nil
+}
@@ -4,145 +4,415 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jamcunha/expense-tracker/internal"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 	"github.com/shopspring/decimal"
 )
 
+// Whether an expense needs any or all of the tags to be listed
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
 type Expense struct {
 	DB      *pgx.Conn
 	Queries *repository.Queries
 }
 
-func (s *Expense) GetByID(ctx context.Context, id, userID uuid.UUID) (repository.Expense, error) {
+// TaggedExpense is an expense with the names of its tags and its splits,
+// empty when it is not split
+type TaggedExpense struct {
+	repository.Expense
+	Tags   []string                  `json:"tags"`
+	Splits []repository.ExpenseSplit `json:"splits"`
+}
+
+// SearchResult is an expense matching a search, with its relevance and the
+// matching words highlighted in the snippet
+type SearchResult struct {
+	TaggedExpense
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+func (s *Expense) GetByID(ctx context.Context, id, workspaceID uuid.UUID) (TaggedExpense, error) {
 	e, err := s.Queries.GetExpenseByID(ctx, repository.GetExpenseByIDParams{
-		ID:     id,
-		UserID: userID,
+		ID:          id,
+		WorkspaceID: workspaceID,
 	})
 	if errors.Is(err, pgx.ErrNoRows) {
-		return repository.Expense{}, ErrExpenseNotFound
+		return TaggedExpense{}, ErrExpenseNotFound
 	} else if err != nil {
 		fmt.Print("failed to insert:", err)
-		return repository.Expense{}, err
+		return TaggedExpense{}, err
 	}
 
-	return e, nil
+	expenses, err := withDetails(ctx, s.Queries, []repository.Expense{e})
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
+	return expenses[0], nil
 }
 
-func (s *Expense) GetAll(
+// ExpenseFilter narrows down the listed expenses, empty values leave a filter
+// out. Amounts are in the base currency of the workspace, Description and
+// Merchant match anywhere ignoring case and TagMatch is TagMatchAny or
+// TagMatchAll.
+type ExpenseFilter struct {
+	CategoryIDs []uuid.UUID
+	AccountID   uuid.UUID
+	MinAmount   decimal.NullDecimal
+	MaxAmount   decimal.NullDecimal
+	StartDate   time.Time
+	EndDate     time.Time
+	Description string
+	Merchant    string
+	Tags        []string
+	TagMatch    string
+}
+
+// List returns a page of the expenses matching the filter sorted by one of
+// the repository.ExpenseSort keys, and the cursor of the next page, empty on
+// the last one.
+func (s *Expense) List(
 	ctx context.Context,
-	userID uuid.UUID,
+	workspaceID uuid.UUID,
+	filter ExpenseFilter,
+	sort string,
+	desc bool,
 	limit int32,
 	cur string,
-) ([]repository.Expense, error) {
-	var expenses []repository.Expense
-	var err error
-
-	if cur == "" {
-		expenses, err = s.Queries.GetUserExpenses(ctx, repository.GetUserExpensesParams{
-			UserID: userID,
-			Limit:  int32(limit),
-		})
-	} else {
-		t, id, err := internal.DecodeCursor(cur)
+) ([]TaggedExpense, string, error) {
+	minMatches := int32(1)
+	if filter.TagMatch == TagMatchAll {
+		unique := map[string]bool{}
+		for _, tag := range filter.Tags {
+			unique[tag] = true
+		}
+
+		minMatches = int32(len(unique))
+	}
+
+	params := repository.ListExpensesParams{
+		WorkspaceID: workspaceID,
+		CategoryIDs: filter.CategoryIDs,
+		AccountID:   pgtype.UUID{Bytes: filter.AccountID, Valid: filter.AccountID != uuid.Nil},
+		MinAmount:   filter.MinAmount,
+		MaxAmount:   filter.MaxAmount,
+		StartDate:   pgtype.Timestamptz{Time: filter.StartDate, Valid: !filter.StartDate.IsZero()},
+		EndDate:     pgtype.Timestamptz{Time: filter.EndDate, Valid: !filter.EndDate.IsZero()},
+		Description: filter.Description,
+		Merchant:    filter.Merchant,
+		Tags:        filter.Tags,
+		MinMatches:  minMatches,
+		Sort:        sort,
+		Desc:        desc,
+		PageSize:    limit,
+	}
+
+	if cur != "" {
+		value, id, err := decodeExpenseCursor(cur, sort, desc)
 		if err != nil {
-			return []repository.Expense{}, ErrDecodeCursor
+			return []TaggedExpense{}, "", ErrDecodeCursor
 		}
 
-		expenses, err = s.Queries.GetUserExpensesPaged(ctx, repository.GetUserExpensesPagedParams{
-			UserID:    userID,
-			CreatedAt: t,
-			ID:        id,
-			Limit:     int32(limit),
-		})
+		params.CursorValue = value
+		params.CursorID = pgtype.UUID{Bytes: id, Valid: true}
 	}
 
-	if errors.Is(err, pgx.ErrNoRows) {
-		return []repository.Expense{}, ErrExpenseNotFound
+	expenses, err := s.Queries.ListExpenses(ctx, params)
+	if errors.Is(err, repository.ErrInvalidExpenseSort) {
+		return []TaggedExpense{}, "", ErrInvalidExpenseSort
 	} else if err != nil {
 		fmt.Println("failed to find:", err)
-		return []repository.Expense{}, err
+		return []TaggedExpense{}, "", err
+	}
+
+	tagged, err := withDetails(ctx, s.Queries, expenses)
+	if err != nil {
+		return []TaggedExpense{}, "", err
 	}
 
-	return expenses, nil
+	next := ""
+	if len(expenses) == int(limit) {
+		next = encodeExpenseCursor(expenses[len(expenses)-1], sort, desc)
+	}
+
+	return tagged, next, nil
 }
 
-func (s *Expense) GetByCategory(
+// The cursor sort key includes the direction, so a cursor of an ascending
+// list can not be used to page a descending one
+func expenseCursorSort(sort string, desc bool) string {
+	if desc {
+		return sort + "_desc"
+	}
+
+	return sort + "_asc"
+}
+
+func encodeExpenseCursor(e repository.Expense, sort string, desc bool) string {
+	var value string
+	switch sort {
+	case repository.ExpenseSortAmount:
+		value = e.BaseAmount.String()
+	case repository.ExpenseSortDate:
+		value = e.SpentAt.Format(time.RFC3339Nano)
+	case repository.ExpenseSortDescription:
+		value = e.Description
+	}
+
+	return internal.EncodeSortCursor(expenseCursorSort(sort, desc), value, e.ID)
+}
+
+// decodeExpenseCursor returns the value of the cursor with the type of the
+// sort column.
+func decodeExpenseCursor(cur, sort string, desc bool) (interface{}, uuid.UUID, error) {
+	cursorSort, value, id, err := internal.DecodeSortCursor(cur)
+	if err != nil {
+		return nil, uuid.UUID{}, err
+	}
+
+	if cursorSort != expenseCursorSort(sort, desc) {
+		return nil, uuid.UUID{}, ErrDecodeCursor
+	}
+
+	switch sort {
+	case repository.ExpenseSortAmount:
+		amount, err := decimal.NewFromString(value)
+		return amount, id, err
+	case repository.ExpenseSortDate:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		return t, id, err
+	default:
+		return value, id, nil
+	}
+}
+
+// Search returns the expenses whose description, merchant or notes contain
+// words starting with every word of the query, most relevant first. A nil
+// category or zero dates leave that filter out.
+func (s *Expense) Search(
 	ctx context.Context,
-	categoryID, userID uuid.UUID,
+	workspaceID uuid.UUID,
+	query string,
+	categoryID uuid.UUID,
+	startDate, endDate time.Time,
 	limit int32,
 	cur string,
-) ([]repository.Expense, error) {
-	var expenses []repository.Expense
-	var err error
-
-	if cur == "" {
-		expenses, err = s.Queries.GetCategoryExpenses(
-			ctx,
-			repository.GetCategoryExpensesParams{
-				CategoryID: categoryID,
-				UserID:     userID,
-				Limit:      int32(limit),
-			},
-		)
-	} else {
-		t, id, err := internal.DecodeCursor(cur)
+) ([]SearchResult, error) {
+	tsquery := prefixQuery(query)
+	if tsquery == "" {
+		return []SearchResult{}, ErrInvalidSearch
+	}
+
+	params := repository.SearchExpensesParams{
+		WorkspaceID: workspaceID,
+		Query:       tsquery,
+		CategoryID:  pgtype.UUID{Bytes: categoryID, Valid: categoryID != uuid.Nil},
+		StartDate:   pgtype.Timestamptz{Time: startDate, Valid: !startDate.IsZero()},
+		EndDate:     pgtype.Timestamptz{Time: endDate, Valid: !endDate.IsZero()},
+		PageSize:    limit,
+	}
+
+	if cur != "" {
+		rank, id, err := internal.DecodeRankCursor(cur)
 		if err != nil {
-			return []repository.Expense{}, ErrDecodeCursor
+			return []SearchResult{}, ErrDecodeCursor
 		}
 
-		expenses, err = s.Queries.GetCategoryExpensesPaged(ctx, repository.GetCategoryExpensesPagedParams{
-			CategoryID: categoryID,
-			UserID:     userID,
-			CreatedAt:  t,
-			ID:         id,
-			Limit:      int32(limit),
-		})
+		params.CursorRank = pgtype.Float4{Float32: rank, Valid: true}
+		params.CursorID = id
 	}
 
-	if errors.Is(err, pgx.ErrNoRows) {
-		return []repository.Expense{}, ErrExpenseNotFound
-	} else if err != nil {
+	rows, err := s.Queries.SearchExpenses(ctx, params)
+	if err != nil {
 		fmt.Println("failed to find:", err)
-		return []repository.Expense{}, err
+		return []SearchResult{}, err
+	}
+
+	expenses := make([]repository.Expense, len(rows))
+	for i, row := range rows {
+		expenses[i] = row.Expense
+	}
+
+	tagged, err := withDetails(ctx, s.Queries, expenses)
+	if err != nil {
+		return []SearchResult{}, err
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			TaggedExpense: tagged[i],
+			Rank:          row.Rank,
+			Snippet:       row.Snippet,
+		}
+	}
+
+	return results, nil
+}
+
+// prefixQuery turns the words of a search into a tsquery matching the
+// expenses with words starting with all of them, e.g. "coff sh" into
+// "coff:* & sh:*". Anything but letters and digits separates words, so no
+// tsquery operators get through.
+func prefixQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
 	}
 
-	return expenses, nil
+	return strings.Join(words, " & ")
 }
 
+// Create adds an expense, split across the categories of the splits when
+// there are any. The category of a split expense is the one of its first
+// split.
 func (s *Expense) Create(
 	ctx context.Context,
-	userID uuid.UUID,
-	description string,
+	workspaceID uuid.UUID,
+	description, merchant, notes string,
 	amount decimal.Decimal,
-	categoryID uuid.UUID,
-) (repository.Expense, error) {
+	currency string,
+	categoryID, accountID uuid.UUID,
+	spentAt time.Time,
+	tags []string,
+	splits []ExpenseSplit,
+) (TaggedExpense, error) {
 	now := time.Now()
-	e, err := s.Queries.CreateExpense(ctx, repository.CreateExpenseParams{
+	if spentAt.IsZero() {
+		spentAt = now
+	}
+
+	return s.create(ctx, tags, splits, repository.CreateExpenseParams{
 		ID:        uuid.New(),
 		CreatedAt: now,
 		UpdatedAt: now,
 
 		Description: description,
+		Merchant:    optionalText(merchant),
+		Notes:       optionalText(notes),
 		Amount:      amount,
+		Currency:    currency,
 		CategoryID:  categoryID,
-		UserID:      userID,
+		AccountID:   pgtype.UUID{Bytes: accountID, Valid: accountID != uuid.Nil},
+		WorkspaceID: workspaceID,
+		SpentAt:     spentAt,
 	})
+}
+
+// create converts the amount to the base currency of the workspace, which is
+// also the currency when none is given unless the expense is in an account,
+// inserts the expense with its tags and splits and adds it to the matching
+// budgets, recording the alerts it fires. It is shared with the recurring
+// expenses scheduler.
+func (s *Expense) create(
+	ctx context.Context,
+	tags []string,
+	splits []ExpenseSplit,
+	params repository.CreateExpenseParams,
+) (TaggedExpense, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	if len(splits) > 0 {
+		if err := checkSplits(ctx, qtx, params.WorkspaceID, params.Amount, splits); err != nil {
+			return TaggedExpense{}, err
+		}
+
+		params.CategoryID = splits[0].CategoryID
+	} else if err := checkCategory(ctx, qtx, params.WorkspaceID, params.CategoryID); err != nil {
+		return TaggedExpense{}, err
+	}
+
+	if params.AccountID.Valid {
+		params.AccountID, params.Currency, err = findAccount(
+			ctx, qtx, params.WorkspaceID, params.AccountID.Bytes, params.Currency,
+		)
+		if err != nil {
+			return TaggedExpense{}, err
+		}
+	}
+
+	converter, err := newCurrencyConverter(ctx, qtx, params.WorkspaceID)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
+	if params.Currency == "" {
+		params.Currency = converter.base
+	}
+
+	params.BaseAmount, err = converter.toBase(ctx, params.Amount, params.Currency, params.SpentAt)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
+	e, inserted, err := insertExpense(ctx, qtx, params, splits)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
+	names, err := setExpenseTags(ctx, qtx, e.WorkspaceID, e.ID, tags)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return TaggedExpense{}, err
+	}
+
+	return TaggedExpense{Expense: e, Tags: names, Splits: inserted}, nil
+}
+
+// insertExpense inserts the expense with its splits, already checked, and
+// adds its base amount to the matching budgets, recording the alerts it
+// fires. queries should be bound to a transaction.
+func insertExpense(
+	ctx context.Context,
+	queries *repository.Queries,
+	params repository.CreateExpenseParams,
+	splits []ExpenseSplit,
+) (repository.Expense, []repository.ExpenseSplit, error) {
+	e, err := queries.CreateExpense(ctx, params)
 	if err != nil {
 		fmt.Println("failed to insert:", err)
-		return repository.Expense{}, err
+		return repository.Expense{}, nil, err
 	}
 
-	return e, nil
+	inserted, err := insertExpenseSplits(ctx, queries, e, splits)
+	if err != nil {
+		return repository.Expense{}, nil, err
+	}
+
+	if err := updateExpenseBudgets(ctx, queries, e, inserted, false); err != nil {
+		return repository.Expense{}, nil, err
+	}
+
+	return e, inserted, nil
 }
 
 func (s *Expense) DeleteByID(
 	ctx context.Context,
-	id, userID uuid.UUID,
+	id, workspaceID uuid.UUID,
 ) (repository.Expense, error) {
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
@@ -152,9 +422,17 @@ func (s *Expense) DeleteByID(
 
 	qtx := s.Queries.WithTx(tx)
 
+	// The splits are deleted with the expense, but they are needed to update
+	// the budgets of their categories
+	splits, err := qtx.DeleteExpenseSplits(ctx, id)
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Expense{}, err
+	}
+
 	e, err := qtx.DeleteExpense(ctx, repository.DeleteExpenseParams{
-		ID:     id,
-		UserID: userID,
+		ID:          id,
+		WorkspaceID: workspaceID,
 	})
 	if errors.Is(err, pgx.ErrNoRows) {
 		return repository.Expense{}, ErrExpenseNotFound
@@ -163,11 +441,9 @@ func (s *Expense) DeleteByID(
 		return repository.Expense{}, err
 	}
 
-	err = qtx.UpdateBudgetAmount(ctx, repository.UpdateBudgetAmountParams{
-		CategoryID: e.CategoryID,
-		Amount:     e.Amount.Neg(),
-		StartDate:  e.CreatedAt,
-	})
+	// Removing an expense can not reach a threshold, but the budgets are
+	// updated the same way as when adding one
+	err = updateExpenseBudgets(ctx, qtx, e, splits, true)
 	if err != nil {
 		return repository.Expense{}, err
 	}
@@ -179,34 +455,53 @@ func (s *Expense) DeleteByID(
 	return e, nil
 }
 
+// Update changes the expense, empty values keep the old ones. The tags and
+// splits are replaced unless they are nil, an empty slice removes them all.
+// The kept splits must still add up to the amount, and a split expense keeps
+// the category of its first split.
 func (s *Expense) Update(
 	ctx context.Context,
-	id, categoryID, userID uuid.UUID,
-	description string,
+	id, categoryID, accountID, workspaceID uuid.UUID,
+	description, merchant, notes string,
 	amount decimal.Decimal,
-) (repository.Expense, error) {
+	currency string,
+	spentAt time.Time,
+	tags []string,
+	splits []ExpenseSplit,
+) (TaggedExpense, error) {
 	tx, err := s.DB.Begin(ctx)
 	if err != nil {
-		return repository.Expense{}, err
+		return TaggedExpense{}, err
 	}
 	defer tx.Rollback(ctx)
 
 	qtx := s.Queries.WithTx(tx)
 
 	e, err := qtx.GetExpenseByID(ctx, repository.GetExpenseByIDParams{
-		ID:     id,
-		UserID: userID,
+		ID:          id,
+		WorkspaceID: workspaceID,
 	})
 
 	if errors.Is(err, pgx.ErrNoRows) {
-		return repository.Expense{}, ErrExpenseNotFound
+		return TaggedExpense{}, ErrExpenseNotFound
 	} else if err != nil {
 		fmt.Println("failed to update:", err)
-		return repository.Expense{}, err
+		return TaggedExpense{}, err
 	}
 
-	oldCategory := e.CategoryID
-	oldAmount := e.Amount
+	// The splits are inserted again since their base amounts change with the
+	// expense
+	oldSplits, err := qtx.DeleteExpenseSplits(ctx, id)
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return TaggedExpense{}, err
+	}
+
+	old := e
+
+	if splits == nil {
+		splits = splitsOf(oldSplits)
+	}
 
 	if description == "" {
 		description = e.Description
@@ -216,47 +511,173 @@ func (s *Expense) Update(
 		amount = e.Amount
 	}
 
+	merchantText := e.Merchant
+	if merchant != "" {
+		merchantText = optionalText(merchant)
+	}
+
+	notesText := e.Notes
+	if notes != "" {
+		notesText = optionalText(notes)
+	}
+
+	// Moving the expense to another account has to be reconciled again
+	account := e.AccountID
+	clearedAt := e.ClearedAt
+	if accountID != uuid.Nil && accountID != uuid.UUID(e.AccountID.Bytes) {
+		account = pgtype.UUID{Bytes: accountID, Valid: true}
+		clearedAt = pgtype.Timestamptz{}
+	}
+
+	if account.Valid {
+		account, currency, err = findAccount(ctx, qtx, workspaceID, account.Bytes, currency)
+		if err != nil {
+			return TaggedExpense{}, err
+		}
+	}
+
+	if currency == "" {
+		currency = e.Currency
+	}
+
 	if categoryID == uuid.Nil {
 		categoryID = e.CategoryID
 	}
 
+	if len(splits) > 0 {
+		if err := checkSplits(ctx, qtx, workspaceID, amount, splits); err != nil {
+			return TaggedExpense{}, err
+		}
+
+		categoryID = splits[0].CategoryID
+	} else if categoryID != e.CategoryID {
+		if err := checkCategory(ctx, qtx, workspaceID, categoryID); err != nil {
+			return TaggedExpense{}, err
+		}
+	}
+
+	if spentAt.IsZero() {
+		spentAt = e.SpentAt
+	}
+
+	converter, err := newCurrencyConverter(ctx, qtx, workspaceID)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
+	baseAmount, err := converter.toBase(ctx, amount, currency, spentAt)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
 	now := time.Now()
 	e, err = qtx.UpdateExpense(ctx, repository.UpdateExpenseParams{
 		ID:          id,
-		UserID:      userID,
+		WorkspaceID: workspaceID,
 		Description: description,
+		Merchant:    merchantText,
+		Notes:       notesText,
 		Amount:      amount,
+		Currency:    currency,
+		BaseAmount:  baseAmount,
 		CategoryID:  categoryID,
+		AccountID:   account,
+		ClearedAt:   clearedAt,
+		SpentAt:     spentAt,
 		UpdatedAt:   now,
 	})
 	if err != nil {
 		fmt.Println("failed to update:", err)
-		return repository.Expense{}, err
+		return TaggedExpense{}, err
 	}
 
-	err = qtx.UpdateBudgetAmount(ctx, repository.UpdateBudgetAmountParams{
-		CategoryID: oldCategory,
-		Amount:     oldAmount.Neg(),
-		StartDate:  e.CreatedAt,
-	})
+	inserted, err := insertExpenseSplits(ctx, qtx, e, splits)
 	if err != nil {
-		fmt.Println("failed to update:", err)
-		return repository.Expense{}, err
+		return TaggedExpense{}, err
 	}
 
-	err = qtx.UpdateBudgetAmount(ctx, repository.UpdateBudgetAmountParams{
-		CategoryID: e.CategoryID,
-		Amount:     e.Amount,
-		StartDate:  e.UpdatedAt, // Should this be CreatedAt?
-	})
+	err = updateExpenseBudgets(ctx, qtx, old, oldSplits, true)
 	if err != nil {
-		fmt.Println("failed to update:", err)
-		return repository.Expense{}, err
+		return TaggedExpense{}, err
+	}
+
+	err = updateExpenseBudgets(ctx, qtx, e, inserted, false)
+	if err != nil {
+		return TaggedExpense{}, err
+	}
+
+	var updated []TaggedExpense
+	if tags == nil {
+		updated, err = withDetails(ctx, qtx, []repository.Expense{e})
+	} else {
+		var names []string
+		names, err = setExpenseTags(ctx, qtx, workspaceID, e.ID, tags)
+		updated = []TaggedExpense{{Expense: e, Tags: names, Splits: inserted}}
+	}
+	if err != nil {
+		return TaggedExpense{}, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return repository.Expense{}, err
+		return TaggedExpense{}, err
 	}
 
-	return e, nil
+	return updated[0], nil
+}
+
+// withDetails adds the names of their tags and their splits to the expenses.
+func withDetails(
+	ctx context.Context,
+	queries *repository.Queries,
+	expenses []repository.Expense,
+) ([]TaggedExpense, error) {
+	tagged := make([]TaggedExpense, len(expenses))
+	if len(expenses) == 0 {
+		return tagged, nil
+	}
+
+	ids := make([]uuid.UUID, len(expenses))
+	for i, e := range expenses {
+		ids[i] = e.ID
+	}
+
+	rows, err := queries.GetExpensesTags(ctx, ids)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	tags := make(map[uuid.UUID][]string, len(expenses))
+	for _, row := range rows {
+		tags[row.ExpenseID] = append(tags[row.ExpenseID], row.Name)
+	}
+
+	stored, err := queries.GetExpensesSplits(ctx, ids)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	splits := make(map[uuid.UUID][]repository.ExpenseSplit, len(expenses))
+	for _, split := range stored {
+		splits[split.ExpenseID] = append(splits[split.ExpenseID], split)
+	}
+
+	for i, e := range expenses {
+		tagged[i] = TaggedExpense{Expense: e, Tags: tags[e.ID], Splits: splits[e.ID]}
+		if tagged[i].Tags == nil {
+			tagged[i].Tags = []string{}
+		}
+
+		if tagged[i].Splits == nil {
+			tagged[i].Splits = []repository.ExpenseSplit{}
+		}
+	}
+
+	return tagged, nil
+}
+
+// optionalText stores empty strings as NULL
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
 }
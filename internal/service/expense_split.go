@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// ExpenseSplit is a part of an expense counted against its own category,
+// e.g. the household goods of a supermarket receipt
+type ExpenseSplit struct {
+	CategoryID uuid.UUID
+	Amount     decimal.Decimal
+	Note       string
+}
+
+// checkSplits checks each split has a positive amount in a category of the
+// workspace and that they add up to the amount of the expense.
+func checkSplits(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID uuid.UUID,
+	amount decimal.Decimal,
+	splits []ExpenseSplit,
+) error {
+	total := decimal.Zero
+	for _, split := range splits {
+		if split.CategoryID == uuid.Nil || !split.Amount.IsPositive() {
+			return ErrInvalidSplits
+		}
+
+		total = total.Add(split.Amount)
+	}
+
+	if !total.Equal(amount) {
+		return ErrInvalidSplits
+	}
+
+	for _, split := range splits {
+		if err := checkCategory(ctx, queries, workspaceID, split.CategoryID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertExpenseSplits stores the splits of the expense in order. Their base
+// amounts share the one of the expense in proportion to their amounts, the
+// last split taking the rounding difference so they add up to it. queries
+// should be bound to a transaction.
+func insertExpenseSplits(
+	ctx context.Context,
+	queries *repository.Queries,
+	e repository.Expense,
+	splits []ExpenseSplit,
+) ([]repository.ExpenseSplit, error) {
+	inserted := make([]repository.ExpenseSplit, len(splits))
+	remaining := e.BaseAmount
+
+	for i, split := range splits {
+		baseAmount := remaining
+		if i < len(splits)-1 {
+			baseAmount = split.Amount.Mul(e.BaseAmount).Div(e.Amount).Round(2)
+			remaining = remaining.Sub(baseAmount)
+		}
+
+		s, err := queries.CreateExpenseSplit(ctx, repository.CreateExpenseSplitParams{
+			ID:         uuid.New(),
+			Position:   int32(i),
+			Amount:     split.Amount,
+			BaseAmount: baseAmount,
+			Note:       optionalText(split.Note),
+			CategoryID: split.CategoryID,
+			ExpenseID:  e.ID,
+		})
+		if err != nil {
+			fmt.Println("failed to insert:", err)
+			return nil, err
+		}
+
+		inserted[i] = s
+	}
+
+	return inserted, nil
+}
+
+// updateExpenseBudgets adds the expense to the budgets of its category, or
+// each of its splits to the budgets of their own categories, recording the
+// alerts they fire. A removed expense is subtracted instead.
+func updateExpenseBudgets(
+	ctx context.Context,
+	queries *repository.Queries,
+	e repository.Expense,
+	splits []repository.ExpenseSplit,
+	removed bool,
+) error {
+	sign := decimal.NewFromInt(1)
+	if removed {
+		sign = sign.Neg()
+	}
+
+	if len(splits) == 0 {
+		return updateBudgetAmount(ctx, queries, e.WorkspaceID, e.CategoryID, e.BaseAmount.Mul(sign), e.SpentAt)
+	}
+
+	for _, split := range splits {
+		err := updateBudgetAmount(ctx, queries, e.WorkspaceID, split.CategoryID, split.BaseAmount.Mul(sign), e.SpentAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitsOf turns the stored splits back into the ones given when creating or
+// updating an expense.
+func splitsOf(stored []repository.ExpenseSplit) []ExpenseSplit {
+	splits := make([]ExpenseSplit, len(stored))
+	for i, s := range stored {
+		splits[i] = ExpenseSplit{
+			CategoryID: s.CategoryID,
+			Amount:     s.Amount,
+			Note:       s.Note.String,
+		}
+	}
+
+	return splits
+}
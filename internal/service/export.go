@@ -0,0 +1,517 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// Formats of the exported files
+const (
+	ExportCSV    = "csv"
+	ExportJSON   = "json"
+	ExportNDJSON = "ndjson"
+)
+
+// Separates the tags of an expense in the CSV exports
+const exportTagSeparator = "|"
+
+// Column order of the CSV exports, new columns should only be appended
+var (
+	expenseExportHeader = []string{
+		"id", "spent_at", "description", "amount", "category_id", "category_name",
+		"recurring_expense_id", "created_at", "updated_at", "currency", "base_amount",
+		"merchant", "notes", "tags", "account_id", "cleared_at",
+	}
+	categoryExportHeader = []string{
+		"id", "name", "created_at", "updated_at", "parent_id",
+	}
+	budgetExportHeader = []string{
+		"id", "category_ids", "category_names", "amount", "goal", "start_date", "end_date",
+		"created_at", "updated_at", "currency", "period", "series_id", "closed_at",
+		"rollover", "rollover_cap", "carried", "thresholds",
+	}
+	recurringExpenseExportHeader = []string{
+		"id", "description", "amount", "category_id", "category_name", "frequency",
+		"repeat_interval", "start_date", "end_date", "occurrence_limit", "occurrences",
+		"next_occurrence", "created_at", "updated_at", "currency",
+	}
+	incomeExportHeader = []string{
+		"id", "received_at", "source", "amount", "category_id", "category_name",
+		"created_at", "updated_at", "currency", "base_amount", "account_id",
+	}
+	accountExportHeader = []string{
+		"id", "name", "type", "currency", "opening_balance", "reconciled_at", "created_at", "updated_at",
+	}
+	transferExportHeader = []string{
+		"id", "transferred_at", "from_account_id", "to_account_id", "amount", "to_amount",
+		"description", "created_at",
+	}
+	expenseSplitExportHeader = []string{
+		"id", "expense_id", "position", "amount", "base_amount", "note", "category_id", "category_name",
+	}
+)
+
+// Export streams the rows while the client downloads them, which can hold
+// a connection for minutes, so each export opens its own to PostgresUrl
+// instead of taking the one shared with the request handlers.
+type Export struct {
+	PostgresUrl string
+}
+
+// exportSnapshot reads the rows of an export in a single transaction.
+type exportSnapshot struct {
+	Queries *repository.Queries
+}
+
+func ValidExportFormat(format string) bool {
+	switch format {
+	case ExportCSV, ExportJSON, ExportNDJSON:
+		return true
+	}
+
+	return false
+}
+
+// Expenses writes the expenses spent between startDate and endDate in
+// category to w, as they are read from the database. Zero values are not
+// used to filter.
+func (s *Export) Expenses(
+	ctx context.Context,
+	w io.Writer,
+	format string,
+	workspaceID uuid.UUID,
+	startDate, endDate time.Time,
+	categoryID uuid.UUID,
+) error {
+	params := repository.ExportExpensesParams{WorkspaceID: workspaceID}
+
+	if !startDate.IsZero() {
+		params.StartDate = pgtype.Timestamptz{Time: startDate, Valid: true}
+	}
+
+	if !endDate.IsZero() {
+		params.EndDate = pgtype.Timestamptz{Time: endDate, Valid: true}
+	}
+
+	if categoryID != uuid.Nil {
+		params.CategoryID = pgtype.UUID{Bytes: categoryID, Valid: true}
+	}
+
+	return s.snapshot(ctx, func(tx *exportSnapshot) error {
+		return tx.expenses(ctx, w, format, params)
+	})
+}
+
+// All writes a zip archive to w with a file per table of the workspace, all
+// read from the same snapshot so they agree with each other.
+func (s *Export) All(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	return s.snapshot(ctx, func(tx *exportSnapshot) error {
+		files := []struct {
+			name  string
+			write func(io.Writer) error
+		}{
+			{"expenses", func(w io.Writer) error {
+				return tx.expenses(ctx, w, format, repository.ExportExpensesParams{WorkspaceID: workspaceID})
+			}},
+			{"categories", func(w io.Writer) error { return tx.categories(ctx, w, format, workspaceID) }},
+			{"budgets", func(w io.Writer) error { return tx.budgets(ctx, w, format, workspaceID) }},
+			{"recurring_expenses", func(w io.Writer) error { return tx.recurringExpenses(ctx, w, format, workspaceID) }},
+			{"incomes", func(w io.Writer) error { return tx.incomes(ctx, w, format, workspaceID) }},
+			{"accounts", func(w io.Writer) error { return tx.accounts(ctx, w, format, workspaceID) }},
+			{"transfers", func(w io.Writer) error { return tx.transfers(ctx, w, format, workspaceID) }},
+			{"expense_splits", func(w io.Writer) error { return tx.expenseSplits(ctx, w, format, workspaceID) }},
+		}
+
+		archive := zip.NewWriter(w)
+
+		for _, file := range files {
+			fw, err := archive.CreateHeader(&zip.FileHeader{
+				Name:     file.name + "." + format,
+				Method:   zip.Deflate,
+				Modified: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := file.write(fw); err != nil {
+				return err
+			}
+		}
+
+		return archive.Close()
+	})
+}
+
+// snapshot runs read on a connection of its own, in a read only REPEATABLE
+// READ transaction so every query sees the rows as they were at the start.
+func (s *Export) snapshot(ctx context.Context, read func(tx *exportSnapshot) error) error {
+	conn, err := pgx.Connect(ctx, s.PostgresUrl)
+	if err != nil {
+		fmt.Println("failed to connect:", err)
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		fmt.Println("failed to begin transaction:", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := read(&exportSnapshot{Queries: repository.New(tx)}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *exportSnapshot) expenses(
+	ctx context.Context,
+	w io.Writer,
+	format string,
+	params repository.ExportExpensesParams,
+) error {
+	enc, err := newExportEncoder(w, format, expenseExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportExpensesEach(ctx, params, func(e repository.ExportExpensesRow) error {
+		return enc.encode(e, []string{
+			e.ID.String(),
+			formatExportTime(e.SpentAt),
+			e.Description,
+			e.Amount.StringFixed(2),
+			e.CategoryID.String(),
+			e.CategoryName,
+			formatExportUUID(e.RecurringExpenseID),
+			formatExportTime(e.CreatedAt),
+			formatExportTime(e.UpdatedAt),
+			e.Currency,
+			e.BaseAmount.StringFixed(2),
+			e.Merchant.String,
+			e.Notes.String,
+			strings.Join(e.Tags, exportTagSeparator),
+			formatExportUUID(e.AccountID),
+			formatExportTimestamptz(e.ClearedAt),
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export expenses:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+func (s *exportSnapshot) categories(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	enc, err := newExportEncoder(w, format, categoryExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportCategoriesEach(ctx, workspaceID, func(c repository.Category) error {
+		return enc.encode(c, []string{
+			c.ID.String(),
+			c.Name,
+			formatExportTime(c.CreatedAt),
+			formatExportTime(c.UpdatedAt),
+			formatExportUUID(c.ParentID),
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export categories:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+func (s *exportSnapshot) budgets(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	enc, err := newExportEncoder(w, format, budgetExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportBudgetsEach(ctx, workspaceID, func(b repository.ExportBudgetsRow) error {
+		return enc.encode(b, []string{
+			b.ID.String(),
+			b.CategoryIds,
+			b.CategoryNames,
+			b.Amount.StringFixed(2),
+			b.Goal.StringFixed(2),
+			formatExportTime(b.StartDate),
+			formatExportTime(b.EndDate),
+			formatExportTime(b.CreatedAt),
+			formatExportTime(b.UpdatedAt),
+			b.Currency,
+			b.Period.String,
+			formatExportUUID(b.SeriesID),
+			formatExportTimestamptz(b.ClosedAt),
+			b.Rollover,
+			b.RolloverCap.StringFixed(2),
+			b.Carried.StringFixed(2),
+			formatExportThresholds(b.Thresholds),
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export budgets:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+func (s *exportSnapshot) recurringExpenses(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	enc, err := newExportEncoder(w, format, recurringExpenseExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportRecurringExpensesEach(ctx, workspaceID, func(r repository.ExportRecurringExpensesRow) error {
+		occurrenceLimit := ""
+		if r.OccurrenceLimit.Valid {
+			occurrenceLimit = strconv.Itoa(int(r.OccurrenceLimit.Int32))
+		}
+
+		return enc.encode(r, []string{
+			r.ID.String(),
+			r.Description,
+			r.Amount.StringFixed(2),
+			r.CategoryID.String(),
+			r.CategoryName,
+			r.Frequency,
+			strconv.Itoa(int(r.RepeatInterval)),
+			formatExportTime(r.StartDate),
+			formatExportTimestamptz(r.EndDate),
+			occurrenceLimit,
+			strconv.Itoa(int(r.Occurrences)),
+			formatExportTimestamptz(r.NextOccurrence),
+			formatExportTime(r.CreatedAt),
+			formatExportTime(r.UpdatedAt),
+			r.Currency,
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export recurring expenses:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+// exportEncoder writes the rows of an export one at a time, either as a CSV
+// record or as the JSON of the row.
+type exportEncoder struct {
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+	rows   int
+}
+
+func newExportEncoder(w io.Writer, format string, header []string) (*exportEncoder, error) {
+	enc := &exportEncoder{w: w, format: format}
+
+	switch format {
+	case ExportCSV:
+		enc.csv = csv.NewWriter(w)
+		return enc, enc.csv.Write(header)
+	case ExportJSON:
+		_, err := io.WriteString(w, "[")
+		return enc, err
+	case ExportNDJSON:
+		return enc, nil
+	}
+
+	return nil, ErrInvalidExportFormat
+}
+
+func (enc *exportEncoder) encode(row interface{}, record []string) error {
+	enc.rows++
+
+	if enc.format == ExportCSV {
+		return enc.csv.Write(record)
+	}
+
+	b, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case enc.format == ExportNDJSON:
+		b = append(b, '\n')
+	case enc.rows > 1:
+		b = append([]byte(","), b...)
+	}
+
+	_, err = enc.w.Write(b)
+	return err
+}
+
+func (enc *exportEncoder) close() error {
+	switch enc.format {
+	case ExportCSV:
+		enc.csv.Flush()
+		return enc.csv.Error()
+	case ExportJSON:
+		_, err := io.WriteString(enc.w, "]\n")
+		return err
+	}
+
+	return nil
+}
+
+func (s *exportSnapshot) incomes(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	enc, err := newExportEncoder(w, format, incomeExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportIncomesEach(ctx, workspaceID, func(i repository.ExportIncomesRow) error {
+		return enc.encode(i, []string{
+			i.ID.String(),
+			formatExportTime(i.ReceivedAt),
+			i.Source,
+			i.Amount.StringFixed(2),
+			formatExportUUID(i.CategoryID),
+			i.CategoryName.String,
+			formatExportTime(i.CreatedAt),
+			formatExportTime(i.UpdatedAt),
+			i.Currency,
+			i.BaseAmount.StringFixed(2),
+			formatExportUUID(i.AccountID),
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export incomes:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+func (s *exportSnapshot) accounts(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	enc, err := newExportEncoder(w, format, accountExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportAccountsEach(ctx, workspaceID, func(a repository.Account) error {
+		return enc.encode(a, []string{
+			a.ID.String(),
+			a.Name,
+			a.Type,
+			a.Currency,
+			a.OpeningBalance.StringFixed(2),
+			formatExportTimestamptz(a.ReconciledAt),
+			formatExportTime(a.CreatedAt),
+			formatExportTime(a.UpdatedAt),
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export accounts:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+func (s *exportSnapshot) transfers(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	enc, err := newExportEncoder(w, format, transferExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportTransfersEach(ctx, workspaceID, func(t repository.Transfer) error {
+		return enc.encode(t, []string{
+			t.ID.String(),
+			formatExportTime(t.TransferredAt),
+			t.FromAccountID.String(),
+			t.ToAccountID.String(),
+			t.Amount.StringFixed(2),
+			t.ToAmount.StringFixed(2),
+			t.Description,
+			formatExportTime(t.CreatedAt),
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export transfers:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+func (s *exportSnapshot) expenseSplits(ctx context.Context, w io.Writer, format string, workspaceID uuid.UUID) error {
+	enc, err := newExportEncoder(w, format, expenseSplitExportHeader)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.ExportExpenseSplitsEach(ctx, workspaceID, func(es repository.ExportExpenseSplitsRow) error {
+		return enc.encode(es, []string{
+			es.ID.String(),
+			es.ExpenseID.String(),
+			strconv.Itoa(int(es.Position)),
+			es.Amount.StringFixed(2),
+			es.BaseAmount.StringFixed(2),
+			es.Note.String,
+			es.CategoryID.String(),
+			es.CategoryName,
+		})
+	})
+	if err != nil {
+		fmt.Println("failed to export expense splits:", err)
+		return err
+	}
+
+	return enc.close()
+}
+
+func formatExportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatExportTimestamptz(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return ""
+	}
+
+	return formatExportTime(t.Time)
+}
+
+// formatExportThresholds joins the thresholds of a budget like the tags
+func formatExportThresholds(thresholds []int32) string {
+	values := make([]string, len(thresholds))
+	for i, t := range thresholds {
+		values[i] = strconv.Itoa(int(t))
+	}
+
+	return strings.Join(values, exportTagSeparator)
+}
+
+func formatExportUUID(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+
+	return uuid.UUID(id.Bytes).String()
+}
@@ -0,0 +1,522 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Sign conventions of the amount column. Rows with the other sign are
+// refunds or incoming transfers and are skipped.
+const (
+	SignExpensesPositive = "positive"
+	SignExpensesNegative = "negative"
+)
+
+// Status of each imported row
+const (
+	ImportRowValid     = "valid"
+	ImportRowDuplicate = "duplicate"
+	ImportRowSkipped   = "skipped"
+	ImportRowInvalid   = "invalid"
+)
+
+const maxImportRows = 10000
+
+// Largest amount that fits in NUMERIC(10, 2)
+var maxImportAmount = decimal.New(1, 8)
+
+// ImportMapping describes how to read the expenses from a CSV file. Columns
+// are matched against the header row, ignoring case.
+type ImportMapping struct {
+	DateColumn        string
+	DescriptionColumn string
+	AmountColumn      string
+	// Optional, DefaultCategory is used for the rows without one
+	CategoryColumn   string
+	DefaultCategory  string
+	CreateCategories bool
+	// Optional, the rows without one are in DefaultCurrency or, when it is
+	// empty, in the base currency of the workspace
+	CurrencyColumn  string
+	DefaultCurrency string
+
+	Sign string
+	// Made of YYYY, YY, MM and DD, e.g. DD/MM/YYYY
+	DateFormat       string
+	DecimalSeparator string
+	Delimiter        rune
+}
+
+type ImportRow struct {
+	Line        int             `json:"line"`
+	SpentAt     time.Time       `json:"spent_at"`
+	Description string          `json:"description"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency"`
+	BaseAmount  decimal.Decimal `json:"base_amount"`
+	Category    string          `json:"category"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+
+	categoryID uuid.UUID
+}
+
+type ImportResult struct {
+	Rows       []ImportRow `json:"rows"`
+	Valid      int         `json:"valid"`
+	Duplicates int         `json:"duplicates"`
+	Skipped    int         `json:"skipped"`
+	Invalid    int         `json:"invalid"`
+	// Categories that do not exist yet, created on commit
+	NewCategories []string `json:"new_categories"`
+	Imported      int      `json:"imported"`
+	Committed     bool     `json:"committed"`
+}
+
+type Import struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+// CSV parses the expenses in r, with their dates in loc, and checks them
+// against the existing ones. When commit is true the valid rows are inserted
+// in a single transaction, but only if no row is invalid. Duplicates and
+// skipped rows are never inserted.
+func (s *Import) CSV(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	r io.Reader,
+	mapping ImportMapping,
+	loc *time.Location,
+	commit bool,
+) (ImportResult, error) {
+	layout, err := dateLayout(mapping.DateFormat)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	if mapping.Sign != SignExpensesPositive && mapping.Sign != SignExpensesNegative {
+		return ImportResult{}, fmt.Errorf("%w: sign must be %q or %q", ErrInvalidImportMapping, SignExpensesPositive, SignExpensesNegative)
+	}
+
+	if mapping.DecimalSeparator != "." && mapping.DecimalSeparator != "," {
+		return ImportResult{}, fmt.Errorf("%w: decimal separator must be \".\" or \",\"", ErrInvalidImportMapping)
+	}
+
+	if mapping.CategoryColumn == "" && mapping.DefaultCategory == "" {
+		return ImportResult{}, fmt.Errorf("%w: a category column or a default category is required", ErrInvalidImportMapping)
+	}
+
+	if mapping.DefaultCurrency != "" && !ValidCurrency(mapping.DefaultCurrency) {
+		return ImportResult{}, fmt.Errorf("%w: invalid default currency %q", ErrInvalidImportMapping, mapping.DefaultCurrency)
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = mapping.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return ImportResult{}, fmt.Errorf("%w: the file is empty", ErrInvalidCSV)
+	} else if err != nil {
+		return ImportResult{}, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	column := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+
+		i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("%w: no %q column", ErrInvalidImportMapping, name)
+		}
+
+		return i, nil
+	}
+
+	dateCol, err := column(mapping.DateColumn)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	descriptionCol, err := column(mapping.DescriptionColumn)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	amountCol, err := column(mapping.AmountColumn)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	categoryCol, err := column(mapping.CategoryColumn)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	currencyCol, err := column(mapping.CurrencyColumn)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	if dateCol < 0 || descriptionCol < 0 || amountCol < 0 {
+		return ImportResult{}, fmt.Errorf("%w: date, description and amount columns are required", ErrInvalidImportMapping)
+	}
+
+	workspaceCategories, err := s.Queries.GetAllUserCategories(ctx, workspaceID)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return ImportResult{}, err
+	}
+
+	categories := make(map[string]repository.Category, len(workspaceCategories))
+	for _, c := range workspaceCategories {
+		categories[strings.ToLower(c.Name)] = c
+	}
+
+	converter, err := newCurrencyConverter(ctx, s.Queries, workspaceID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	if mapping.DefaultCurrency == "" {
+		mapping.DefaultCurrency = converter.base
+	}
+
+	result := ImportResult{
+		Rows:          []ImportRow{},
+		NewCategories: []string{},
+	}
+	newCategories := map[string]bool{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return ImportResult{}, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+
+		if isBlankRecord(record) {
+			continue
+		}
+
+		if len(result.Rows) == maxImportRows {
+			return ImportResult{}, fmt.Errorf("%w: more than %d rows", ErrInvalidCSV, maxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := ImportRow{Line: line, Status: ImportRowValid}
+
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[i])
+		}
+
+		row.Description = field(descriptionCol)
+		row.Category = field(categoryCol)
+		if row.Category == "" {
+			row.Category = mapping.DefaultCategory
+		}
+
+		if c, ok := categories[strings.ToLower(row.Category)]; ok {
+			row.Category = c.Name
+			row.categoryID = c.ID
+		}
+
+		row.Currency = strings.ToUpper(field(currencyCol))
+		if row.Currency == "" {
+			row.Currency = mapping.DefaultCurrency
+		}
+
+		row.SpentAt, err = time.ParseInLocation(layout, field(dateCol), loc)
+		if err != nil {
+			row.invalid(fmt.Sprintf("Invalid date %q", field(dateCol)))
+		}
+
+		amount, err := parseAmount(field(amountCol), mapping.DecimalSeparator)
+		if err != nil {
+			row.invalid(fmt.Sprintf("Invalid amount %q", field(amountCol)))
+		} else if mapping.Sign == SignExpensesNegative {
+			amount = amount.Neg()
+		}
+		row.Amount = amount
+
+		switch {
+		case row.Status == ImportRowInvalid:
+		case row.Description == "":
+			row.invalid("Missing description")
+		case amount.IsZero():
+			row.invalid("Amount is zero")
+		case amount.Abs().GreaterThanOrEqual(maxImportAmount):
+			row.invalid("Amount is too large")
+		case amount.IsNegative():
+			row.Status = ImportRowSkipped
+			row.Error = "Not an expense"
+		case !ValidCurrency(row.Currency):
+			row.invalid(fmt.Sprintf("Invalid currency %q", row.Currency))
+		case row.categoryID == uuid.Nil && !mapping.CreateCategories:
+			row.invalid(fmt.Sprintf("Category %q does not exist", row.Category))
+		case row.categoryID == uuid.Nil && len([]rune(row.Category)) > 255:
+			row.invalid("Category name is too long")
+		case row.categoryID == uuid.Nil:
+			key := strings.ToLower(row.Category)
+			if !newCategories[key] {
+				newCategories[key] = true
+				result.NewCategories = append(result.NewCategories, row.Category)
+			}
+		}
+
+		if row.Status == ImportRowValid {
+			row.BaseAmount, err = converter.toBase(ctx, row.Amount, row.Currency, row.SpentAt)
+			if errors.Is(err, ErrExchangeRateNotFound) {
+				row.invalid(err.Error())
+			} else if err != nil {
+				return ImportResult{}, err
+			}
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := s.markDuplicates(ctx, workspaceID, result.Rows, loc); err != nil {
+		return ImportResult{}, err
+	}
+
+	for _, row := range result.Rows {
+		switch row.Status {
+		case ImportRowValid:
+			result.Valid++
+		case ImportRowDuplicate:
+			result.Duplicates++
+		case ImportRowSkipped:
+			result.Skipped++
+		case ImportRowInvalid:
+			result.Invalid++
+		}
+	}
+
+	if !commit {
+		return result, nil
+	}
+
+	if result.Invalid > 0 {
+		return result, ErrInvalidImportRows
+	}
+
+	if err := s.commit(ctx, workspaceID, &result); err != nil {
+		return ImportResult{}, err
+	}
+
+	return result, nil
+}
+
+// markDuplicates flags the valid rows with the same date in loc, amount,
+// currency and description as an existing expense or an earlier row.
+func (s *Import) markDuplicates(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	rows []ImportRow,
+	loc *time.Location,
+) error {
+	var start, end time.Time
+	for _, row := range rows {
+		if row.Status != ImportRowValid {
+			continue
+		}
+
+		if start.IsZero() || row.SpentAt.Before(start) {
+			start = row.SpentAt
+		}
+
+		if end.IsZero() || row.SpentAt.After(end) {
+			end = row.SpentAt
+		}
+	}
+
+	if start.IsZero() {
+		return nil
+	}
+
+	existing, err := s.Queries.GetUserExpenseKeysInRange(ctx, repository.GetUserExpenseKeysInRangeParams{
+		WorkspaceID: workspaceID,
+		StartDate:   start,
+		EndDate:     end.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return err
+	}
+
+	keys := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		keys[expenseKey(e.SpentAt.In(loc), e.Amount, e.Currency, e.Description)] = true
+	}
+
+	// Lines of the rows already accepted, so a row repeated in the file is
+	// only imported once
+	lines := make(map[string]int)
+
+	for i, row := range rows {
+		if row.Status != ImportRowValid {
+			continue
+		}
+
+		key := expenseKey(row.SpentAt.In(loc), row.Amount, row.Currency, row.Description)
+		if keys[key] {
+			rows[i].Status = ImportRowDuplicate
+			rows[i].Error = "An expense with the same date, amount, currency and description already exists"
+		} else if line, ok := lines[key]; ok {
+			rows[i].Status = ImportRowDuplicate
+			rows[i].Error = fmt.Sprintf("Same date, amount, currency and description as line %d", line)
+		} else {
+			lines[key] = row.Line
+		}
+	}
+
+	return nil
+}
+
+// commit inserts the valid rows, creating the missing categories first, and
+// records the budget alerts they fire.
+func (s *Import) commit(ctx context.Context, workspaceID uuid.UUID, result *ImportResult) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+	now := time.Now()
+
+	created := make(map[string]uuid.UUID, len(result.NewCategories))
+	for _, name := range result.NewCategories {
+		c, err := qtx.CreateCategory(ctx, repository.CreateCategoryParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Name:        name,
+			WorkspaceID: workspaceID,
+		})
+		if err != nil {
+			fmt.Println("failed to insert:", err)
+			return err
+		}
+
+		created[strings.ToLower(name)] = c.ID
+	}
+
+	for _, row := range result.Rows {
+		if row.Status != ImportRowValid {
+			continue
+		}
+
+		categoryID := row.categoryID
+		if categoryID == uuid.Nil {
+			categoryID = created[strings.ToLower(row.Category)]
+		}
+
+		_, _, err := insertExpense(ctx, qtx, repository.CreateExpenseParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Description: row.Description,
+			Amount:      row.Amount,
+			Currency:    row.Currency,
+			BaseAmount:  row.BaseAmount,
+			CategoryID:  categoryID,
+			WorkspaceID: workspaceID,
+			SpentAt:     row.SpentAt,
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		result.Imported++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	result.Committed = true
+
+	return nil
+}
+
+func (row *ImportRow) invalid(reason string) {
+	// Keep the first reason
+	if row.Status == ImportRowInvalid {
+		return
+	}
+
+	row.Status = ImportRowInvalid
+	row.Error = reason
+}
+
+// dateLayout converts a format like DD/MM/YYYY to a time layout.
+func dateLayout(format string) (string, error) {
+	layout := strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02").Replace(format)
+
+	hasYear := strings.Contains(layout, "06")
+	if !hasYear || !strings.Contains(layout, "01") || !strings.Contains(layout, "02") {
+		return "", fmt.Errorf("%w: invalid date format %q", ErrInvalidImportMapping, format)
+	}
+
+	return layout, nil
+}
+
+// parseAmount parses a number using the given decimal separator, ignoring
+// the thousands separators.
+func parseAmount(value, decimalSeparator string) (decimal.Decimal, error) {
+	thousandsSeparator := ","
+	if decimalSeparator == "," {
+		thousandsSeparator = "."
+	}
+
+	value = strings.NewReplacer(thousandsSeparator, "", " ", "", "\u00a0", "").Replace(value)
+	value = strings.Replace(value, decimalSeparator, ".", 1)
+	value = strings.TrimPrefix(value, "+")
+
+	return decimal.NewFromString(value)
+}
+
+// expenseKey identifies an expense by its day, in the location of spentAt,
+// amount, currency and description.
+func expenseKey(spentAt time.Time, amount decimal.Decimal, currency, description string) string {
+	return spentAt.Format(time.DateOnly) + "|" + amount.StringFixed(2) + "|" + currency + "|" + description
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDateLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		value  string
+		want   time.Time
+	}{
+		{"DD/MM/YY", "31/01/24", time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)},
+		{"DD/MM/YYYY", "05/11/2023", time.Date(2023, time.November, 5, 0, 0, 0, 0, time.UTC)},
+		{"YYYY-MM-DD", "2024-02-29", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"MM.DD.YYYY", "12.25.2024", time.Date(2024, time.December, 25, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			layout, err := dateLayout(tt.format)
+			if err != nil {
+				t.Fatalf("dateLayout(%q) = %v", tt.format, err)
+			}
+
+			got, err := time.Parse(layout, tt.value)
+			if err != nil {
+				t.Fatalf("parsing %q with %q: %v", tt.value, layout, err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("parsing %q with %q = %v, want %v", tt.value, layout, got, tt.want)
+			}
+		})
+	}
+
+	for _, format := range []string{"", "DD/MM", "MM/YYYY", "YYYY-DD"} {
+		if _, err := dateLayout(format); !errors.Is(err, ErrInvalidImportMapping) {
+			t.Errorf("dateLayout(%q) = %v, want %v", format, err, ErrInvalidImportMapping)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value            string
+		decimalSeparator string
+		want             string
+	}{
+		{"1.234,56", ",", "1234.56"},
+		{"1\u00a0234,56", ",", "1234.56"},
+		{"1\u00a0234\u00a0567,8", ",", "1234567.8"},
+		{"-12,50", ",", "-12.5"},
+		{"1,234.56", ".", "1234.56"},
+		{"1 234.56", ".", "1234.56"},
+		{"+3", ".", "3"},
+		{"0.99", ".", "0.99"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAmount(tt.value, tt.decimalSeparator)
+		if err != nil {
+			t.Errorf("parseAmount(%q, %q) = %v", tt.value, tt.decimalSeparator, err)
+			continue
+		}
+
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("parseAmount(%q, %q) = %s, want %s", tt.value, tt.decimalSeparator, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "12,34,56"} {
+		if got, err := parseAmount(value, ","); err == nil {
+			t.Errorf("parseAmount(%q, \",\") = %s, want an error", value, got)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+const maxIncomeSourceLength = 255
+
+// Income is money received, kept apart from the expenses so it never counts
+// towards the budgets
+type Income struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+func (s *Income) GetByID(ctx context.Context, id, workspaceID uuid.UUID) (repository.Income, error) {
+	i, err := s.Queries.GetIncomeByID(ctx, repository.GetIncomeByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Income{}, ErrIncomeNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.Income{}, err
+	}
+
+	return i, nil
+}
+
+// GetAll returns a page of the incomes of the workspace received between the
+// dates, the latest first. Zero dates leave the range open.
+func (s *Income) GetAll(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	startDate, endDate time.Time,
+	limit int32,
+	cur string,
+) ([]repository.Income, error) {
+	params := repository.GetUserIncomesParams{
+		WorkspaceID: workspaceID,
+		PageSize:    limit,
+	}
+
+	if !startDate.IsZero() {
+		params.StartDate = pgtype.Timestamptz{Time: startDate, Valid: true}
+	}
+
+	if !endDate.IsZero() {
+		params.EndDate = pgtype.Timestamptz{Time: endDate, Valid: true}
+	}
+
+	if cur != "" {
+		t, id, err := internal.DecodeCursor(cur)
+		if err != nil {
+			return []repository.Income{}, ErrDecodeCursor
+		}
+
+		params.CursorReceivedAt = pgtype.Timestamptz{Time: t, Valid: true}
+		params.CursorID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	incomes, err := s.Queries.GetUserIncomes(ctx, params)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.Income{}, err
+	}
+
+	if incomes == nil {
+		incomes = []repository.Income{}
+	}
+
+	return incomes, nil
+}
+
+// Create adds an income, without a category or account when their IDs are
+// uuid.Nil. The amount is converted to the base currency of the workspace,
+// which is also the currency when none is given unless the income is in an
+// account.
+func (s *Income) Create(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	source string,
+	amount decimal.Decimal,
+	currency string,
+	categoryID, accountID uuid.UUID,
+	receivedAt time.Time,
+) (repository.Income, error) {
+	if !validIncome(source, amount) {
+		return repository.Income{}, ErrInvalidIncome
+	}
+
+	now := time.Now()
+	if receivedAt.IsZero() {
+		receivedAt = now
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.Income{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	category, err := incomeCategory(ctx, qtx, workspaceID, categoryID)
+	if err != nil {
+		return repository.Income{}, err
+	}
+
+	account, currency, err := findAccount(ctx, qtx, workspaceID, accountID, currency)
+	if err != nil {
+		return repository.Income{}, err
+	}
+
+	converter, err := newCurrencyConverter(ctx, qtx, workspaceID)
+	if err != nil {
+		return repository.Income{}, err
+	}
+
+	if currency == "" {
+		currency = converter.base
+	}
+
+	baseAmount, err := converter.toBase(ctx, amount, currency, receivedAt)
+	if err != nil {
+		return repository.Income{}, err
+	}
+
+	i, err := qtx.CreateIncome(ctx, repository.CreateIncomeParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Source:      source,
+		Amount:      amount,
+		Currency:    currency,
+		BaseAmount:  baseAmount,
+		ReceivedAt:  receivedAt,
+		CategoryID:  category,
+		AccountID:   account,
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return repository.Income{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.Income{}, err
+	}
+
+	return i, nil
+}
+
+// Update changes the given fields of the income, empty values are kept as
+// they are. The base amount is converted again with the new values.
+func (s *Income) Update(
+	ctx context.Context,
+	id, categoryID, accountID, workspaceID uuid.UUID,
+	source string,
+	amount decimal.Decimal,
+	currency string,
+	receivedAt time.Time,
+) (repository.Income, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.Income{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	i, err := qtx.GetIncomeByID(ctx, repository.GetIncomeByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Income{}, ErrIncomeNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Income{}, err
+	}
+
+	if source == "" {
+		source = i.Source
+	}
+
+	if amount.IsZero() {
+		amount = i.Amount
+	}
+
+	if !validIncome(source, amount) {
+		return repository.Income{}, ErrInvalidIncome
+	}
+
+	account := i.AccountID
+	if accountID != uuid.Nil {
+		account = pgtype.UUID{Bytes: accountID, Valid: true}
+	}
+
+	if account.Valid {
+		account, currency, err = findAccount(ctx, qtx, workspaceID, account.Bytes, currency)
+		if err != nil {
+			return repository.Income{}, err
+		}
+	}
+
+	if currency == "" {
+		currency = i.Currency
+	}
+
+	if receivedAt.IsZero() {
+		receivedAt = i.ReceivedAt
+	}
+
+	category := i.CategoryID
+	if categoryID != uuid.Nil {
+		category, err = incomeCategory(ctx, qtx, workspaceID, categoryID)
+		if err != nil {
+			return repository.Income{}, err
+		}
+	}
+
+	converter, err := newCurrencyConverter(ctx, qtx, workspaceID)
+	if err != nil {
+		return repository.Income{}, err
+	}
+
+	baseAmount, err := converter.toBase(ctx, amount, currency, receivedAt)
+	if err != nil {
+		return repository.Income{}, err
+	}
+
+	i, err = qtx.UpdateIncome(ctx, repository.UpdateIncomeParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+		Source:      source,
+		Amount:      amount,
+		Currency:    currency,
+		BaseAmount:  baseAmount,
+		ReceivedAt:  receivedAt,
+		CategoryID:  category,
+		AccountID:   account,
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Income{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.Income{}, err
+	}
+
+	return i, nil
+}
+
+func (s *Income) DeleteByID(ctx context.Context, id, workspaceID uuid.UUID) (repository.Income, error) {
+	i, err := s.Queries.DeleteIncome(ctx, repository.DeleteIncomeParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Income{}, ErrIncomeNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Income{}, err
+	}
+
+	return i, nil
+}
+
+func validIncome(source string, amount decimal.Decimal) bool {
+	return source != "" && utf8.RuneCountInString(source) <= maxIncomeSourceLength && amount.IsPositive()
+}
+
+// incomeCategory checks the category belongs to the workspace, uuid.Nil
+// being no category at all.
+func incomeCategory(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID, categoryID uuid.UUID,
+) (pgtype.UUID, error) {
+	if categoryID == uuid.Nil {
+		return pgtype.UUID{}, nil
+	}
+
+	if err := checkCategory(ctx, queries, workspaceID, categoryID); err != nil {
+		return pgtype.UUID{}, err
+	}
+
+	return pgtype.UUID{Bytes: categoryID, Valid: true}, nil
+}
@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// A login waiting for the second factor lasts mfaChallengeTTL and takes up
+// to maxMFAAttempts codes, so guessing one takes logging in again
+const (
+	mfaChallengeTTL = 5 * time.Minute
+	maxMFAAttempts  = 5
+)
+
+// Signed in users get maxMFAAttempts passwords and codes to disable
+// two-factor authentication or regenerate the recovery codes, then wait
+// mfaLockout before trying again
+const mfaLockout = 15 * time.Minute
+
+// Recovery codes are recoveryCodeCount codes of recoveryCodeLength letters
+// and digits, without the ones easy to mix up
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+)
+
+type MFA struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+// MFAStatus tells whether the user has two-factor authentication and how
+// many recovery codes are left.
+type MFAStatus struct {
+	Enabled           bool               `json:"enabled"`
+	EnabledAt         pgtype.Timestamptz `json:"enabled_at"`
+	RecoveryCodesLeft int64              `json:"recovery_codes_left"`
+}
+
+// TOTPEnrollment is the secret to add to an authenticator app, typing it or
+// scanning the QR code of its otpauth URI.
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func (s *MFA) Status(ctx context.Context, userID uuid.UUID) (MFAStatus, error) {
+	u, err := s.user(ctx, userID)
+	if err != nil {
+		return MFAStatus{}, err
+	}
+
+	status := MFAStatus{
+		Enabled:   u.TotpEnabledAt.Valid,
+		EnabledAt: u.TotpEnabledAt,
+	}
+
+	if status.Enabled {
+		status.RecoveryCodesLeft, err = s.Queries.CountUnusedRecoveryCodes(ctx, userID)
+		if err != nil {
+			fmt.Println("failed to find:", err)
+			return MFAStatus{}, err
+		}
+	}
+
+	return status, nil
+}
+
+// EnrollTOTP starts the enrollment with a new secret, replacing the one of
+// an enrollment that was not confirmed. Two-factor authentication is only
+// enabled once a code of the secret is confirmed.
+func (s *MFA) EnrollTOTP(ctx context.Context, userID uuid.UUID) (TOTPEnrollment, error) {
+	u, err := s.user(ctx, userID)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	if u.TotpEnabledAt.Valid {
+		return TOTPEnrollment{}, ErrMFAEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+
+	err = s.Queries.SetUserTOTPSecret(ctx, repository.SetUserTOTPSecretParams{
+		TotpSecret: pgtype.Text{String: secret, Valid: true},
+		UpdatedAt:  time.Now(),
+		ID:         userID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return TOTPEnrollment{}, err
+	}
+
+	return TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totpURI(secret, u.Email),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication with a code of the secret
+// being enrolled, returning the recovery codes. Only their hashes are
+// stored, so they can not be shown again.
+func (s *MFA) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	u, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TotpEnabledAt.Valid {
+		return nil, ErrMFAEnabled
+	}
+
+	if !u.TotpSecret.Valid {
+		return nil, ErrMFANotEnrolled
+	}
+
+	now := time.Now()
+
+	step, ok := validateTOTP(u.TotpSecret.String, strings.TrimSpace(code), now)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	rows, err := qtx.EnableUserTOTP(ctx, repository.EnableUserTOTPParams{
+		EnabledAt: pgtype.Timestamptz{Time: now, Valid: true},
+		ID:        userID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return nil, err
+	}
+
+	// Confirmed by another request in the meantime
+	if rows == 0 {
+		return nil, ErrMFAEnabled
+	}
+
+	// The code confirmed can not be used to log in
+	if _, err := qtx.UseTOTPStep(ctx, repository.UseTOTPStepParams{Step: step, ID: userID}); err != nil {
+		fmt.Println("failed to update:", err)
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(ctx, qtx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off, which takes the password
+// and a code of the authenticator or a recovery code.
+func (s *MFA) DisableTOTP(ctx context.Context, userID uuid.UUID, password, code string) error {
+	u, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if err := s.checkPassword(ctx, u, password, now); err != nil {
+		return err
+	}
+
+	if !u.TotpEnabledAt.Valid {
+		return ErrMFANotEnabled
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	if err := s.verifyCode(ctx, tx, u, code, now); err != nil {
+		return err
+	}
+
+	err = qtx.DisableUserTOTP(ctx, repository.DisableUserTOTPParams{
+		UpdatedAt: now,
+		ID:        userID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	if err := qtx.DeleteRecoveryCodes(ctx, userID); err != nil {
+		fmt.Println("failed to delete:", err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, used or not, after
+// checking the password and a code of the authenticator or a recovery code.
+func (s *MFA) RegenerateRecoveryCodes(
+	ctx context.Context,
+	userID uuid.UUID,
+	password, code string,
+) ([]string, error) {
+	u, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if err := s.checkPassword(ctx, u, password, now); err != nil {
+		return nil, err
+	}
+
+	if !u.TotpEnabledAt.Valid {
+		return nil, ErrMFANotEnabled
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	if err := s.verifyCode(ctx, tx, u, code, now); err != nil {
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(ctx, qtx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DeleteExpiredChallenges removes the logins that were never completed and
+// can no longer be, returning how many were removed.
+func (s *MFA) DeleteExpiredChallenges(ctx context.Context, now time.Time) (int64, error) {
+	deleted, err := s.Queries.DeleteExpiredMFAChallenges(ctx, now)
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+func (s *MFA) user(ctx context.Context, userID uuid.UUID) (repository.User, error) {
+	u, err := s.Queries.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.User{}, ErrUserNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.User{}, err
+	}
+
+	return u, nil
+}
+
+// checkPassword checks the password of a signed in user who is not locked
+// out, counting it as a failed attempt when it is wrong.
+func (s *MFA) checkPassword(ctx context.Context, u repository.User, password string, now time.Time) error {
+	if u.MfaLockedUntil.Valid && u.MfaLockedUntil.Time.After(now) {
+		return ErrMFALocked
+	}
+
+	if !comparePassword(u.Password, password) {
+		if err := s.failAttempt(ctx, u.ID, now); err != nil {
+			return err
+		}
+
+		return ErrWrongCredentials
+	}
+
+	return nil
+}
+
+// verifyCode is verifySecondFactor for a signed in user, counting a wrong
+// code as a failed attempt and starting the count again on a right one.
+// A wrong code rolls tx back, so the attempt is not rolled back with it.
+func (s *MFA) verifyCode(ctx context.Context, tx pgx.Tx, u repository.User, code string, now time.Time) error {
+	qtx := s.Queries.WithTx(tx)
+
+	err := verifySecondFactor(ctx, qtx, u, code, now)
+	if errors.Is(err, ErrInvalidMFACode) {
+		if err := tx.Rollback(ctx); err != nil {
+			return err
+		}
+
+		if err := s.failAttempt(ctx, u.ID, now); err != nil {
+			return err
+		}
+
+		return ErrInvalidMFACode
+	} else if err != nil {
+		return err
+	}
+
+	if err := qtx.ResetMFAAttempts(ctx, u.ID); err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	return nil
+}
+
+// failAttempt counts a wrong password or code of the user, locking them out
+// for mfaLockout after maxMFAAttempts.
+func (s *MFA) failAttempt(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	err := s.Queries.FailMFAAttempt(ctx, repository.FailMFAAttemptParams{
+		MaxAttempts: maxMFAAttempts,
+		LockedUntil: now.Add(mfaLockout),
+		ID:          userID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	return nil
+}
+
+// verifySecondFactor checks code is a code of the authenticator of the user
+// not used before, or one of the recovery codes left, which is used up.
+func verifySecondFactor(
+	ctx context.Context,
+	queries *repository.Queries,
+	u repository.User,
+	code string,
+	now time.Time,
+) error {
+	code = strings.TrimSpace(code)
+
+	if step, ok := validateTOTP(u.TotpSecret.String, code, now); ok {
+		rows, err := queries.UseTOTPStep(ctx, repository.UseTOTPStepParams{Step: step, ID: u.ID})
+		if err != nil {
+			fmt.Println("failed to update:", err)
+			return err
+		}
+
+		// The code, or a later one, was already used
+		if rows == 0 {
+			return ErrInvalidMFACode
+		}
+
+		return nil
+	}
+
+	rows, err := queries.UseRecoveryCode(ctx, repository.UseRecoveryCodeParams{
+		UsedAt:   pgtype.Timestamptz{Time: now, Valid: true},
+		UserID:   u.ID,
+		CodeHash: hashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	if rows == 0 {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes stores the hashes of new recovery codes in place of
+// the ones of the user, returning the codes.
+func replaceRecoveryCodes(
+	ctx context.Context,
+	queries *repository.Queries,
+	userID uuid.UUID,
+	now time.Time,
+) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	ids := make([]uuid.UUID, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = code
+		ids[i] = uuid.New()
+		hashes[i] = hashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := queries.DeleteRecoveryCodes(ctx, userID); err != nil {
+		fmt.Println("failed to delete:", err)
+		return nil, err
+	}
+
+	err := queries.AddRecoveryCodes(ctx, repository.AddRecoveryCodesParams{
+		Ids:        ids,
+		CreatedAt:  now,
+		CodeHashes: hashes,
+		UserID:     userID,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// newRecoveryCode returns a random code split in two halves to read it
+// easily, like "abcde-23456".
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	// The alphabet has 32 characters, so every one is as likely
+	for i := range b {
+		b[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+	}
+
+	half := recoveryCodeLength / 2
+	return string(b[:half]) + "-" + string(b[half:]), nil
+}
+
+// normalizeRecoveryCode ignores the case and separators of a code.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// Notification is the in-app inbox of a user, filled by notify.Inbox
+type Notification struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+// GetAll returns a page of the notifications of the user, the latest first.
+func (s *Notification) GetAll(
+	ctx context.Context,
+	userID uuid.UUID,
+	unreadOnly bool,
+	limit int32,
+	cur string,
+) ([]repository.Notification, error) {
+	params := repository.GetUserNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: unreadOnly,
+		PageSize:   limit,
+	}
+
+	if cur != "" {
+		t, id, err := internal.DecodeCursor(cur)
+		if err != nil {
+			return []repository.Notification{}, ErrDecodeCursor
+		}
+
+		params.CursorCreatedAt = pgtype.Timestamptz{Time: t, Valid: true}
+		params.CursorID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	notifications, err := s.Queries.GetUserNotifications(ctx, params)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.Notification{}, err
+	}
+
+	if notifications == nil {
+		notifications = []repository.Notification{}
+	}
+
+	return notifications, nil
+}
+
+// MarkRead marks the notification as read, keeping when it was first read.
+func (s *Notification) MarkRead(ctx context.Context, id, userID uuid.UUID) (repository.Notification, error) {
+	n, err := s.Queries.MarkNotificationRead(ctx, repository.MarkNotificationReadParams{
+		ReadAt: time.Now(),
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Notification{}, ErrNotificationNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Notification{}, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,558 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// Number of due recurring expenses handled in each scheduler run
+const materializeBatchSize = 100
+
+// RecurrenceRule is a subset of the iCalendar RRULE: an occurrence happens
+// every Interval days/weeks/months/years starting at StartDate, until EndDate
+// or until Count occurrences were added (zero values repeat forever).
+type RecurrenceRule struct {
+	Frequency string
+	Interval  int32
+	StartDate time.Time
+	EndDate   time.Time
+	Count     int32
+}
+
+// RecurrenceUpdate changes a recurrence rule, empty values keeping the old
+// ones. EndDate and Count are only changed when they are not nil, a zero
+// value removing them so the rule repeats forever again.
+type RecurrenceUpdate struct {
+	Frequency string
+	Interval  int32
+	StartDate time.Time
+	EndDate   *time.Time
+	Count     *int32
+}
+
+// apply returns the rule with the changes of the update.
+func (u RecurrenceUpdate) apply(rule RecurrenceRule) RecurrenceRule {
+	if u.Frequency != "" {
+		rule.Frequency = u.Frequency
+	}
+
+	if u.Interval != 0 {
+		rule.Interval = u.Interval
+	}
+
+	if !u.StartDate.IsZero() {
+		rule.StartDate = u.StartDate
+	}
+
+	if u.EndDate != nil {
+		rule.EndDate = *u.EndDate
+	}
+
+	if u.Count != nil {
+		rule.Count = *u.Count
+	}
+
+	return rule
+}
+
+func (r RecurrenceRule) validate() error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+	default:
+		return ErrInvalidRecurrence
+	}
+
+	if r.Interval < 1 || r.Count < 0 || r.StartDate.IsZero() {
+		return ErrInvalidRecurrence
+	}
+
+	if !r.EndDate.IsZero() && r.EndDate.Before(r.StartDate) {
+		return ErrInvalidRecurrence
+	}
+
+	return nil
+}
+
+// occurrence returns the nth (starting at 0) occurrence of the rule, ignoring
+// the end date and count. Months are clamped, so a rule starting on the 31st
+// happens on the last day of shorter months.
+func (r RecurrenceRule) occurrence(n int32) time.Time {
+	steps := int(n * r.Interval)
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return r.StartDate.AddDate(0, 0, steps)
+	case FrequencyWeekly:
+		return r.StartDate.AddDate(0, 0, 7*steps)
+	case FrequencyMonthly:
+		return addMonths(r.StartDate, steps)
+	default:
+		return addMonths(r.StartDate, 12*steps)
+	}
+}
+
+// firstFrom returns the first occurrence at or after t.
+func (r RecurrenceRule) firstFrom(t time.Time) time.Time {
+	n := r.estimate(t)
+	for n > 0 && !r.occurrence(n).Before(t) {
+		n--
+	}
+
+	for {
+		o := r.occurrence(n)
+		if !o.Before(t) {
+			return o
+		}
+		n++
+	}
+}
+
+// next returns the occurrence after the given one, or false when the rule
+// does not repeat anymore.
+func (r RecurrenceRule) next(after time.Time, done int32) (time.Time, bool) {
+	if r.Count > 0 && done >= r.Count {
+		return time.Time{}, false
+	}
+
+	o := r.firstFrom(after.Add(time.Nanosecond))
+	if !r.EndDate.IsZero() && o.After(r.EndDate) {
+		return time.Time{}, false
+	}
+
+	return o, true
+}
+
+// estimate returns an occurrence index close to (and usually before) t so
+// catching up on a long running rule does not start from the first one.
+func (r RecurrenceRule) estimate(t time.Time) int32 {
+	if !t.After(r.StartDate) {
+		return 0
+	}
+
+	var steps int
+	switch r.Frequency {
+	case FrequencyDaily:
+		steps = int(t.Sub(r.StartDate).Hours() / 24)
+	case FrequencyWeekly:
+		steps = int(t.Sub(r.StartDate).Hours() / (24 * 7))
+	case FrequencyMonthly:
+		steps = (t.Year()-r.StartDate.Year())*12 + int(t.Month()-r.StartDate.Month())
+	default:
+		steps = t.Year() - r.StartDate.Year()
+	}
+
+	n := int32(steps)/r.Interval - 1
+	if n < 0 {
+		return 0
+	}
+
+	return n
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	// Clamp to the last day of the target month
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+
+	return first.AddDate(0, 0, d-1)
+}
+
+func ruleOf(re repository.RecurringExpense) RecurrenceRule {
+	rule := RecurrenceRule{
+		Frequency: re.Frequency,
+		Interval:  re.RepeatInterval,
+		StartDate: re.StartDate,
+	}
+
+	if re.EndDate.Valid {
+		rule.EndDate = re.EndDate.Time
+	}
+
+	if re.OccurrenceLimit.Valid {
+		rule.Count = re.OccurrenceLimit.Int32
+	}
+
+	return rule
+}
+
+type RecurringExpense struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+func (s *RecurringExpense) GetByID(
+	ctx context.Context,
+	id, workspaceID uuid.UUID,
+) (repository.RecurringExpense, error) {
+	re, err := s.Queries.GetRecurringExpenseByID(ctx, repository.GetRecurringExpenseByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.RecurringExpense{}, ErrRecurringExpenseNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.RecurringExpense{}, err
+	}
+
+	return re, nil
+}
+
+func (s *RecurringExpense) GetAll(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	limit int32,
+	cur string,
+) ([]repository.RecurringExpense, error) {
+	var recurringExpenses []repository.RecurringExpense
+	var err error
+
+	if cur == "" {
+		recurringExpenses, err = s.Queries.GetUserRecurringExpenses(ctx, repository.GetUserRecurringExpensesParams{
+			WorkspaceID: workspaceID,
+			Limit:       limit,
+		})
+	} else {
+		var t time.Time
+		var id uuid.UUID
+		t, id, err = internal.DecodeCursor(cur)
+		if err != nil {
+			return []repository.RecurringExpense{}, ErrDecodeCursor
+		}
+
+		recurringExpenses, err = s.Queries.GetUserRecurringExpensesPaged(ctx, repository.GetUserRecurringExpensesPagedParams{
+			WorkspaceID:     workspaceID,
+			CursorCreatedAt: t,
+			CursorID:        id,
+			PageSize:        limit,
+		})
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return []repository.RecurringExpense{}, ErrRecurringExpenseNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.RecurringExpense{}, err
+	}
+
+	return recurringExpenses, nil
+}
+
+func (s *RecurringExpense) Create(
+	ctx context.Context,
+	workspaceID, categoryID uuid.UUID,
+	description string,
+	amount decimal.Decimal,
+	currency string,
+	rule RecurrenceRule,
+) (repository.RecurringExpense, error) {
+	if err := rule.validate(); err != nil {
+		return repository.RecurringExpense{}, err
+	}
+
+	if err := checkCategory(ctx, s.Queries, workspaceID, categoryID); err != nil {
+		return repository.RecurringExpense{}, err
+	}
+
+	if currency == "" {
+		var err error
+		currency, err = baseCurrency(ctx, s.Queries, workspaceID)
+		if err != nil {
+			return repository.RecurringExpense{}, err
+		}
+	}
+
+	now := time.Now()
+	params := repository.CreateRecurringExpenseParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+
+		Description:    description,
+		Amount:         amount,
+		Currency:       currency,
+		Frequency:      rule.Frequency,
+		RepeatInterval: rule.Interval,
+		StartDate:      rule.StartDate,
+		// The first occurrence is the start date itself. If it is in the past
+		// the scheduler catches up on the missing occurrences.
+		NextOccurrence: pgtype.Timestamptz{Time: rule.StartDate, Valid: true},
+		CategoryID:     categoryID,
+		WorkspaceID:    workspaceID,
+	}
+
+	if !rule.EndDate.IsZero() {
+		params.EndDate = pgtype.Timestamptz{Time: rule.EndDate, Valid: true}
+	}
+
+	if rule.Count > 0 {
+		params.OccurrenceLimit = pgtype.Int4{Int32: rule.Count, Valid: true}
+	}
+
+	re, err := s.Queries.CreateRecurringExpense(ctx, params)
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return repository.RecurringExpense{}, err
+	}
+
+	return re, nil
+}
+
+// Update changes the recurring expense. Like the expense update, empty
+// values keep the old ones, except for the end date and count of the rule
+// (see RecurrenceUpdate). Changing the schedule only affects occurrences
+// that were not added yet.
+func (s *RecurringExpense) Update(
+	ctx context.Context,
+	id, workspaceID, categoryID uuid.UUID,
+	description string,
+	amount decimal.Decimal,
+	currency string,
+	update RecurrenceUpdate,
+) (repository.RecurringExpense, error) {
+	re, err := s.GetByID(ctx, id, workspaceID)
+	if err != nil {
+		return repository.RecurringExpense{}, err
+	}
+
+	old := ruleOf(re)
+
+	if description == "" {
+		description = re.Description
+	}
+
+	if amount.IsZero() {
+		amount = re.Amount
+	}
+
+	if currency == "" {
+		currency = re.Currency
+	}
+
+	if categoryID == uuid.Nil {
+		categoryID = re.CategoryID
+	} else if categoryID != re.CategoryID {
+		if err := checkCategory(ctx, s.Queries, workspaceID, categoryID); err != nil {
+			return repository.RecurringExpense{}, err
+		}
+	}
+
+	rule := update.apply(old)
+	if err := rule.validate(); err != nil {
+		return repository.RecurringExpense{}, err
+	}
+
+	next := re.NextOccurrence
+	if rule != old {
+		// Nothing was added yet so the new rule starts from scratch,
+		// otherwise past occurrences are not added again. The ones due
+		// that the scheduler did not add yet still are
+		from := rule.StartDate
+		if re.Occurrences > 0 {
+			from = time.Now()
+			if re.NextOccurrence.Valid && re.NextOccurrence.Time.Before(from) {
+				from = re.NextOccurrence.Time
+			}
+		}
+
+		next = pgtype.Timestamptz{}
+		if o, ok := rule.next(from.Add(-time.Nanosecond), re.Occurrences); ok {
+			next = pgtype.Timestamptz{Time: o, Valid: true}
+		}
+	}
+
+	params := repository.UpdateRecurringExpenseParams{
+		Description:    description,
+		Amount:         amount,
+		Currency:       currency,
+		Frequency:      rule.Frequency,
+		RepeatInterval: rule.Interval,
+		StartDate:      rule.StartDate,
+		NextOccurrence: next,
+		CategoryID:     categoryID,
+		UpdatedAt:      time.Now(),
+		ID:             id,
+		WorkspaceID:    workspaceID,
+	}
+
+	if !rule.EndDate.IsZero() {
+		params.EndDate = pgtype.Timestamptz{Time: rule.EndDate, Valid: true}
+	}
+
+	if rule.Count > 0 {
+		params.OccurrenceLimit = pgtype.Int4{Int32: rule.Count, Valid: true}
+	}
+
+	re, err = s.Queries.UpdateRecurringExpense(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.RecurringExpense{}, ErrRecurringExpenseNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.RecurringExpense{}, err
+	}
+
+	return re, nil
+}
+
+func (s *RecurringExpense) DeleteByID(
+	ctx context.Context,
+	id, workspaceID uuid.UUID,
+) (repository.RecurringExpense, error) {
+	re, err := s.Queries.DeleteRecurringExpense(ctx, repository.DeleteRecurringExpenseParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.RecurringExpense{}, ErrRecurringExpenseNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.RecurringExpense{}, err
+	}
+
+	return re, nil
+}
+
+// Preview returns the dates of the next n occurrences that were not added yet.
+func (s *RecurringExpense) Preview(
+	ctx context.Context,
+	id, workspaceID uuid.UUID,
+	n int,
+) ([]time.Time, error) {
+	re, err := s.GetByID(ctx, id, workspaceID)
+	if err != nil {
+		return []time.Time{}, err
+	}
+
+	occurrences := []time.Time{}
+	if !re.NextOccurrence.Valid {
+		return occurrences, nil
+	}
+
+	rule := ruleOf(re)
+	o := re.NextOccurrence.Time
+	done := re.Occurrences
+
+	for len(occurrences) < n {
+		occurrences = append(occurrences, o)
+		done++
+
+		next, ok := rule.next(o, done)
+		if !ok {
+			break
+		}
+		o = next
+	}
+
+	return occurrences, nil
+}
+
+// MaterializeDue adds every occurrence due until now to the expenses,
+// returning how many were added. Progress is saved after each occurrence and
+// an occurrence can only be added once, so it is safe to call again after
+// a failure or restart.
+func (s *RecurringExpense) MaterializeDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.Queries.GetDueRecurringExpenses(ctx, repository.GetDueRecurringExpensesParams{
+		DueAt:    now,
+		PageSize: materializeBatchSize,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return 0, err
+	}
+
+	expenses := Expense{DB: s.DB, Queries: s.Queries}
+
+	added := 0
+	for _, re := range due {
+		n, err := s.materialize(ctx, &expenses, re, now)
+		added += n
+		if err != nil {
+			// Keep going, the failed one is retried on the next run
+			fmt.Printf("failed to materialize recurring expense %s: %v\n", re.ID, err)
+		}
+	}
+
+	return added, nil
+}
+
+func (s *RecurringExpense) materialize(
+	ctx context.Context,
+	expenses *Expense,
+	re repository.RecurringExpense,
+	now time.Time,
+) (int, error) {
+	rule := ruleOf(re)
+	o := re.NextOccurrence.Time
+	done := re.Occurrences
+
+	added := 0
+	for {
+		_, err := expenses.create(ctx, nil, nil, repository.CreateExpenseParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+
+			Description:        re.Description,
+			Amount:             re.Amount,
+			Currency:           re.Currency,
+			CategoryID:         re.CategoryID,
+			WorkspaceID:        re.WorkspaceID,
+			SpentAt:            o,
+			RecurringExpenseID: pgtype.UUID{Bytes: re.ID, Valid: true},
+		})
+		if err == nil {
+			added++
+		} else if !isUniqueViolation(err) {
+			return added, err
+		}
+		// On a unique violation the occurrence was added by a previous run
+		// that stopped before saving its progress
+
+		done++
+		next, ok := rule.next(o, done)
+
+		params := repository.AdvanceRecurringExpenseParams{
+			Occurrences: done,
+			UpdatedAt:   now,
+			ID:          re.ID,
+		}
+		if ok {
+			params.NextOccurrence = pgtype.Timestamptz{Time: next, Valid: true}
+		}
+
+		if err := s.Queries.AdvanceRecurringExpense(ctx, params); err != nil {
+			return added, err
+		}
+
+		if !ok || next.After(now) {
+			return added, nil
+		}
+		o = next
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func ruleDate(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 9, 30, 0, 0, time.UTC)
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	tests := []struct {
+		name string
+		rule RecurrenceRule
+		want []time.Time
+	}{
+		{
+			name: "monthly on the 31st",
+			rule: RecurrenceRule{Frequency: FrequencyMonthly, Interval: 1, StartDate: ruleDate(2024, time.January, 31)},
+			want: []time.Time{
+				ruleDate(2024, time.February, 29),
+				ruleDate(2024, time.March, 31),
+				ruleDate(2024, time.April, 30),
+				ruleDate(2024, time.May, 31),
+				ruleDate(2024, time.June, 30),
+			},
+		},
+		{
+			name: "every other month on the 30th",
+			rule: RecurrenceRule{Frequency: FrequencyMonthly, Interval: 2, StartDate: ruleDate(2023, time.December, 30)},
+			want: []time.Time{
+				ruleDate(2024, time.February, 29),
+				ruleDate(2024, time.April, 30),
+				ruleDate(2024, time.June, 30),
+			},
+		},
+		{
+			name: "yearly on the 29th of February",
+			rule: RecurrenceRule{Frequency: FrequencyYearly, Interval: 1, StartDate: ruleDate(2024, time.February, 29)},
+			want: []time.Time{
+				ruleDate(2025, time.February, 28),
+				ruleDate(2026, time.February, 28),
+				ruleDate(2027, time.February, 28),
+				ruleDate(2028, time.February, 29),
+			},
+		},
+		{
+			name: "weekly",
+			rule: RecurrenceRule{Frequency: FrequencyWeekly, Interval: 1, StartDate: ruleDate(2024, time.December, 24)},
+			want: []time.Time{
+				ruleDate(2024, time.December, 31),
+				ruleDate(2025, time.January, 7),
+			},
+		},
+		{
+			name: "until the end date",
+			rule: RecurrenceRule{
+				Frequency: FrequencyMonthly,
+				Interval:  1,
+				StartDate: ruleDate(2024, time.January, 31),
+				EndDate:   ruleDate(2024, time.April, 30),
+			},
+			want: []time.Time{
+				ruleDate(2024, time.February, 29),
+				ruleDate(2024, time.March, 31),
+				ruleDate(2024, time.April, 30),
+			},
+		},
+		{
+			name: "up to the count",
+			rule: RecurrenceRule{Frequency: FrequencyDaily, Interval: 3, StartDate: ruleDate(2024, time.January, 30), Count: 3},
+			want: []time.Time{
+				ruleDate(2024, time.February, 2),
+				ruleDate(2024, time.February, 5),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := tt.rule.StartDate
+			done := int32(1)
+
+			for _, want := range tt.want {
+				got, ok := tt.rule.next(after, done)
+				if !ok || !got.Equal(want) {
+					t.Fatalf("next(%s, %d) = %s, %v, want %s", after.Format(time.DateOnly), done, got.Format(time.DateOnly), ok, want.Format(time.DateOnly))
+				}
+
+				after = got
+				done++
+			}
+
+			if got, ok := tt.rule.next(after, done); ok && (tt.rule.Count > 0 || !tt.rule.EndDate.IsZero()) {
+				t.Errorf("next(%s, %d) = %s, want no more occurrences", after.Format(time.DateOnly), done, got.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleFirstFrom(t *testing.T) {
+	rule := RecurrenceRule{Frequency: FrequencyMonthly, Interval: 1, StartDate: ruleDate(2020, time.January, 31)}
+
+	tests := []struct {
+		from time.Time
+		want time.Time
+	}{
+		{ruleDate(2019, time.June, 1), ruleDate(2020, time.January, 31)},
+		{ruleDate(2024, time.February, 1), ruleDate(2024, time.February, 29)},
+		{ruleDate(2024, time.February, 29), ruleDate(2024, time.February, 29)},
+		{ruleDate(2024, time.February, 29).Add(time.Second), ruleDate(2024, time.March, 31)},
+		{ruleDate(2025, time.September, 15), ruleDate(2025, time.September, 30)},
+	}
+
+	for _, tt := range tests {
+		if got := rule.firstFrom(tt.from); !got.Equal(tt.want) {
+			t.Errorf("firstFrom(%s) = %s, want %s", tt.from, got, tt.want)
+		}
+	}
+}
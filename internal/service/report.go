@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Periods used to bucket and compare reports, matching the date_trunc fields
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodYear  = "year"
+)
+
+// Periods a report can be compared against
+const (
+	CompareAgainstPrevious = "previous"
+	CompareAgainstLastYear = "last_year"
+)
+
+type PeriodTotal struct {
+	StartDate time.Time       `json:"start_date"`
+	EndDate   time.Time       `json:"end_date"`
+	Total     decimal.Decimal `json:"total"`
+}
+
+type Comparison struct {
+	Current    PeriodTotal     `json:"current"`
+	Previous   PeriodTotal     `json:"previous"`
+	Difference decimal.Decimal `json:"difference"`
+	// nil when nothing was spent in the previous period
+	ChangePercent *decimal.Decimal `json:"change_percent"`
+}
+
+type CashFlowPeriod struct {
+	Period   time.Time       `json:"period"`
+	Income   decimal.Decimal `json:"income"`
+	Expenses decimal.Decimal `json:"expenses"`
+	Net      decimal.Decimal `json:"net"`
+	// Percent of the income saved, nil when nothing was received
+	SavingsRate *decimal.Decimal `json:"savings_rate"`
+}
+
+type CashFlow struct {
+	Income      decimal.Decimal  `json:"income"`
+	Expenses    decimal.Decimal  `json:"expenses"`
+	Net         decimal.Decimal  `json:"net"`
+	SavingsRate *decimal.Decimal `json:"savings_rate"`
+	Periods     []CashFlowPeriod `json:"periods"`
+}
+
+type Report struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+func (s *Report) CategoryTotals(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	startDate, endDate time.Time,
+) ([]repository.GetCategoryTotalsRow, error) {
+	totals, err := s.Queries.GetCategoryTotals(ctx, repository.GetCategoryTotalsParams{
+		WorkspaceID: workspaceID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.GetCategoryTotalsRow{}, err
+	}
+
+	if totals == nil {
+		totals = []repository.GetCategoryTotalsRow{}
+	}
+
+	return totals, nil
+}
+
+// TagTotals returns the total spent with each tag between the dates.
+func (s *Report) TagTotals(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	startDate, endDate time.Time,
+) ([]repository.GetTagTotalsRow, error) {
+	totals, err := s.Queries.GetTagTotals(ctx, repository.GetTagTotalsParams{
+		WorkspaceID: workspaceID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.GetTagTotalsRow{}, err
+	}
+
+	if totals == nil {
+		totals = []repository.GetTagTotalsRow{}
+	}
+
+	return totals, nil
+}
+
+// TimeSeries returns the total spent in each bucket (day, week, month or year)
+// of cal in the date range, only in the given category unless it is uuid.Nil.
+func (s *Report) TimeSeries(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	bucket string,
+	cal Calendar,
+	startDate, endDate time.Time,
+	categoryID uuid.UUID,
+) ([]repository.GetSpendingTimeSeriesRow, error) {
+	if !validPeriod(bucket) {
+		return []repository.GetSpendingTimeSeriesRow{}, ErrInvalidPeriod
+	}
+
+	params := repository.GetSpendingTimeSeriesParams{
+		WorkspaceID: workspaceID,
+		Bucket:      bucket,
+		TimeZone:    cal.Location.String(),
+		ShiftDays:   cal.shiftDays(bucket),
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}
+
+	if categoryID != uuid.Nil {
+		params.CategoryID = pgtype.UUID{Bytes: categoryID, Valid: true}
+	}
+
+	series, err := s.Queries.GetSpendingTimeSeries(ctx, params)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.GetSpendingTimeSeriesRow{}, err
+	}
+
+	if series == nil {
+		series = []repository.GetSpendingTimeSeriesRow{}
+	}
+
+	return series, nil
+}
+
+// TimeSeriesByCategory is like TimeSeries but with a total per category in
+// each bucket.
+func (s *Report) TimeSeriesByCategory(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	bucket string,
+	cal Calendar,
+	startDate, endDate time.Time,
+) ([]repository.GetSpendingTimeSeriesByCategoryRow, error) {
+	if !validPeriod(bucket) {
+		return []repository.GetSpendingTimeSeriesByCategoryRow{}, ErrInvalidPeriod
+	}
+
+	series, err := s.Queries.GetSpendingTimeSeriesByCategory(ctx, repository.GetSpendingTimeSeriesByCategoryParams{
+		WorkspaceID: workspaceID,
+		Bucket:      bucket,
+		TimeZone:    cal.Location.String(),
+		ShiftDays:   cal.shiftDays(bucket),
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.GetSpendingTimeSeriesByCategoryRow{}, err
+	}
+
+	if series == nil {
+		series = []repository.GetSpendingTimeSeriesByCategoryRow{}
+	}
+
+	return series, nil
+}
+
+// CashFlow returns the income, expenses and what is left of the income in
+// each bucket (day, week, month or year) of cal in the date range, and over
+// the whole range.
+func (s *Report) CashFlow(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	bucket string,
+	cal Calendar,
+	startDate, endDate time.Time,
+) (CashFlow, error) {
+	if !validPeriod(bucket) {
+		return CashFlow{}, ErrInvalidPeriod
+	}
+
+	rows, err := s.Queries.GetCashFlow(ctx, repository.GetCashFlowParams{
+		WorkspaceID: workspaceID,
+		Bucket:      bucket,
+		TimeZone:    cal.Location.String(),
+		ShiftDays:   cal.shiftDays(bucket),
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return CashFlow{}, err
+	}
+
+	flow := CashFlow{Periods: make([]CashFlowPeriod, len(rows))}
+	for i, row := range rows {
+		net := row.Income.Sub(row.Expenses)
+		flow.Periods[i] = CashFlowPeriod{
+			Period:      row.Period,
+			Income:      row.Income,
+			Expenses:    row.Expenses,
+			Net:         net,
+			SavingsRate: savingsRate(row.Income, net),
+		}
+
+		flow.Income = flow.Income.Add(row.Income)
+		flow.Expenses = flow.Expenses.Add(row.Expenses)
+	}
+
+	flow.Net = flow.Income.Sub(flow.Expenses)
+	flow.SavingsRate = savingsRate(flow.Income, flow.Net)
+
+	return flow, nil
+}
+
+// IncomeVsExpenses returns the income and expenses of each category between
+// the dates, without their subcategories.
+func (s *Report) IncomeVsExpenses(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	startDate, endDate time.Time,
+) ([]repository.GetIncomeVsExpensesByCategoryRow, error) {
+	totals, err := s.Queries.GetIncomeVsExpensesByCategory(ctx, repository.GetIncomeVsExpensesByCategoryParams{
+		WorkspaceID: workspaceID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.GetIncomeVsExpensesByCategoryRow{}, err
+	}
+
+	if totals == nil {
+		totals = []repository.GetIncomeVsExpensesByCategoryRow{}
+	}
+
+	return totals, nil
+}
+
+// savingsRate is the percent of income left after the expenses, negative
+// when more was spent than received.
+func savingsRate(income, net decimal.Decimal) *decimal.Decimal {
+	if !income.IsPositive() {
+		return nil
+	}
+
+	rate := net.Div(income).Mul(decimal.NewFromInt(100)).Round(2)
+	return &rate
+}
+
+// Compare returns the total spent in the period (week, month or year) of cal
+// that contains date against the previous period or the same period last
+// year.
+func (s *Report) Compare(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	period, against string,
+	cal Calendar,
+	date time.Time,
+) (Comparison, error) {
+	if !validPeriod(period) || period == PeriodDay {
+		return Comparison{}, ErrInvalidPeriod
+	}
+
+	start := cal.StartOfPeriod(date, period)
+
+	var previousStart time.Time
+	switch against {
+	case CompareAgainstPrevious:
+		previousStart = addPeriods(start, period, -1)
+	case CompareAgainstLastYear:
+		if period == PeriodWeek {
+			// Same weekday a year ago
+			previousStart = start.AddDate(0, 0, -52*7)
+		} else {
+			previousStart = start.AddDate(-1, 0, 0)
+		}
+	default:
+		return Comparison{}, ErrInvalidPeriod
+	}
+
+	current, err := s.periodTotal(ctx, workspaceID, start, addPeriods(start, period, 1))
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	previous, err := s.periodTotal(ctx, workspaceID, previousStart, addPeriods(previousStart, period, 1))
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	c := Comparison{
+		Current:    current,
+		Previous:   previous,
+		Difference: current.Total.Sub(previous.Total),
+	}
+
+	if !previous.Total.IsZero() {
+		change := c.Difference.Div(previous.Total).Mul(decimal.NewFromInt(100)).Round(2)
+		c.ChangePercent = &change
+	}
+
+	return c, nil
+}
+
+// periodTotal returns the total spent in [start, end).
+func (s *Report) periodTotal(
+	ctx context.Context,
+	workspaceID uuid.UUID,
+	start, end time.Time,
+) (PeriodTotal, error) {
+	// The end of GetTotalSpent is inclusive
+	end = end.Add(-time.Microsecond)
+
+	total, err := s.Queries.GetTotalSpent(ctx, repository.GetTotalSpentParams{
+		WorkspaceID: workspaceID,
+		StartDate:   start,
+		EndDate:     end,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return PeriodTotal{}, err
+	}
+
+	return PeriodTotal{StartDate: start, EndDate: end, Total: total}, nil
+}
+
+func validPeriod(period string) bool {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth, PeriodYear:
+		return true
+	}
+
+	return false
+}
+
+func addPeriods(t time.Time, period string, n int) time.Time {
+	switch period {
+	case PeriodWeek:
+		return t.AddDate(0, 0, 7*n)
+	case PeriodMonth:
+		return t.AddDate(0, n, 0)
+	case PeriodYear:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// Session manages the logins of a user. A session is a refresh token family,
+// so its ID stays the same across token rotations.
+type Session struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+func (s *Session) GetAll(ctx context.Context, userID uuid.UUID) ([]repository.GetUserSessionsRow, error) {
+	sessions, err := s.Queries.GetUserSessions(ctx, repository.GetUserSessionsParams{
+		UserID: userID,
+		Now:    time.Now(),
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.GetUserSessionsRow{}, err
+	}
+
+	if sessions == nil {
+		sessions = []repository.GetUserSessionsRow{}
+	}
+
+	return sessions, nil
+}
+
+// DeleteByID signs out the session, its refresh token can no longer be used.
+func (s *Session) DeleteByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	rows, err := s.Queries.RevokeUserSession(ctx, repository.RevokeUserSessionParams{
+		RevokedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		FamilyID:  id,
+		UserID:    userID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the refresh tokens that expired before now, they
+// are rejected anyway so there is nothing left to detect reuse of.
+func (s *Session) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	deleted, err := s.Queries.DeleteExpiredRefreshTokens(ctx, now)
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"golang.org/x/text/language"
+)
+
+// Months can start on any day every month has
+const maxMonthStartDay = 28
+
+// Settings are the preferences of a user. The currency is the base currency
+// of the personal workspace and the default one of the workspaces the user
+// creates. Budget periods and report buckets start at midnight in TimeZone
+// (an IANA name), weeks on WeekStart (0 is Sunday) and months on
+// MonthStartDay, for people paid late in the month.
+type Settings struct {
+	Currency      string `json:"currency"`
+	TimeZone      string `json:"time_zone"`
+	Locale        string `json:"locale"`
+	WeekStart     int16  `json:"week_start"`
+	MonthStartDay int16  `json:"month_start_day"`
+}
+
+func settingsOf(u repository.User) Settings {
+	return Settings{
+		Currency:      u.Currency,
+		TimeZone:      u.TimeZone,
+		Locale:        u.Locale,
+		WeekStart:     u.WeekStart,
+		MonthStartDay: u.MonthStartDay,
+	}
+}
+
+// validate checks the settings, replacing the locale by its canonical form.
+func (s *Settings) validate() error {
+	if !ValidCurrency(s.Currency) {
+		return ErrInvalidCurrency
+	}
+
+	if _, err := loadLocation(s.TimeZone); err != nil {
+		return ErrInvalidTimeZone
+	}
+
+	tag, err := language.Parse(s.Locale)
+	if err != nil {
+		return ErrInvalidLocale
+	}
+	s.Locale = tag.String()
+
+	if s.WeekStart < int16(time.Sunday) || s.WeekStart > int16(time.Saturday) {
+		return ErrInvalidWeekStart
+	}
+
+	if s.MonthStartDay < 1 || s.MonthStartDay > maxMonthStartDay {
+		return ErrInvalidMonthStartDay
+	}
+
+	return nil
+}
+
+// Calendar returns how the settings split time into periods.
+func (s Settings) Calendar() Calendar {
+	loc, err := loadLocation(s.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	return Calendar{
+		Location:      loc,
+		WeekStart:     time.Weekday(s.WeekStart),
+		MonthStartDay: int(s.MonthStartDay),
+	}
+}
+
+// loadLocation loads an IANA time zone, but not the one of the server.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+
+	return time.LoadLocation(name)
+}
+
+// Calendar splits time into days, weeks, months and years starting at
+// midnight in Location, with the weeks starting on WeekStart and the months
+// on MonthStartDay. A year starts on MonthStartDay of January.
+type Calendar struct {
+	Location      *time.Location
+	WeekStart     time.Weekday
+	MonthStartDay int
+}
+
+// DefaultCalendar is the calendar of the default settings.
+var DefaultCalendar = Calendar{Location: time.UTC, WeekStart: time.Monday, MonthStartDay: 1}
+
+// StartOfPeriod returns the start of the day, week, month or year that
+// contains t.
+func (c Calendar) StartOfPeriod(t time.Time, period string) time.Time {
+	t = t.In(c.Location)
+	y, m, d := t.Date()
+
+	switch period {
+	case PeriodWeek:
+		daysSinceStart := (int(t.Weekday()) - int(c.WeekStart) + 7) % 7
+		return time.Date(y, m, d-daysSinceStart, 0, 0, 0, 0, c.Location)
+	case PeriodMonth:
+		if d < c.MonthStartDay {
+			m--
+		}
+		return time.Date(y, m, c.MonthStartDay, 0, 0, 0, 0, c.Location)
+	case PeriodYear:
+		if m == time.January && d < c.MonthStartDay {
+			y--
+		}
+		return time.Date(y, time.January, c.MonthStartDay, 0, 0, 0, 0, c.Location)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, c.Location)
+	}
+}
+
+// BudgetPeriodStart returns the start of the budget period (weekly, monthly,
+// quarterly or yearly) that contains t. Quarters start in January, April,
+// July and October.
+func (c Calendar) BudgetPeriodStart(t time.Time, period string) time.Time {
+	switch period {
+	case BudgetPeriodWeekly:
+		return c.StartOfPeriod(t, PeriodWeek)
+	case BudgetPeriodMonthly:
+		return c.StartOfPeriod(t, PeriodMonth)
+	case BudgetPeriodQuarterly:
+		y, m, d := c.StartOfPeriod(t, PeriodMonth).Date()
+		return time.Date(y, m-(m-1)%3, d, 0, 0, 0, 0, c.Location)
+	default:
+		return c.StartOfPeriod(t, PeriodYear)
+	}
+}
+
+// shiftDays is how many days the dates are moved before truncating them to
+// the bucket with date_trunc, which starts the weeks on Monday and the
+// months on the 1st, and moved back after it.
+func (c Calendar) shiftDays(bucket string) int32 {
+	switch bucket {
+	case PeriodWeek:
+		return int32((int(time.Monday) - int(c.WeekStart) + 7) % 7)
+	case PeriodMonth, PeriodYear:
+		return int32(1 - c.MonthStartDay)
+	default:
+		return 0
+	}
+}
+
+// Settings returns the preferences of the user.
+func (s *User) Settings(ctx context.Context, id uuid.UUID) (Settings, error) {
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	return settingsOf(u), nil
+}
+
+// UpdateSettings replaces the preferences of the user. The base currency
+// of the personal workspace changes with them, which is only allowed before
+// it has any amount in the old one.
+func (s *User) UpdateSettings(ctx context.Context, id uuid.UUID, settings Settings) (Settings, error) {
+	if err := settings.validate(); err != nil {
+		return Settings{}, err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return Settings{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	u, err := qtx.GetUserByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Settings{}, ErrUserNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return Settings{}, err
+	}
+
+	now := time.Now()
+
+	if settings.Currency != u.Currency {
+		// The personal workspace has the ID of the user
+		inUse, err := qtx.WorkspaceHasBaseAmounts(ctx, id)
+		if err != nil {
+			fmt.Println("failed to find:", err)
+			return Settings{}, err
+		}
+
+		if inUse {
+			return Settings{}, ErrCurrencyInUse
+		}
+
+		err = qtx.UpdateWorkspaceCurrency(ctx, repository.UpdateWorkspaceCurrencyParams{
+			Currency:  settings.Currency,
+			UpdatedAt: now,
+			ID:        id,
+		})
+		if err != nil {
+			fmt.Println("failed to update:", err)
+			return Settings{}, err
+		}
+	}
+
+	u, err = qtx.UpdateUserSettings(ctx, repository.UpdateUserSettingsParams{
+		Currency:      settings.Currency,
+		TimeZone:      settings.TimeZone,
+		Locale:        settings.Locale,
+		WeekStart:     settings.WeekStart,
+		MonthStartDay: settings.MonthStartDay,
+		UpdatedAt:     now,
+		ID:            id,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return Settings{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Settings{}, err
+	}
+
+	return settingsOf(u), nil
+}
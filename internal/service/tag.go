@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// Longest tag name, the size of the name column
+const maxTagLength = 255
+
+type Tag struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+func (s *Tag) GetAll(ctx context.Context, workspaceID uuid.UUID) ([]repository.Tag, error) {
+	tags, err := s.Queries.GetUserTags(ctx, workspaceID)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.Tag{}, err
+	}
+
+	if tags == nil {
+		tags = []repository.Tag{}
+	}
+
+	return tags, nil
+}
+
+func (s *Tag) Create(ctx context.Context, workspaceID uuid.UUID, name string) (repository.Tag, error) {
+	name, err := tagName(name)
+	if err != nil {
+		return repository.Tag{}, err
+	}
+
+	now := time.Now()
+	t, err := s.Queries.CreateTag(ctx, repository.CreateTagParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Name:        name,
+		WorkspaceID: workspaceID,
+	})
+	if isUniqueViolation(err) {
+		return repository.Tag{}, ErrTagExists
+	} else if err != nil {
+		fmt.Println("failed to insert:", err)
+		return repository.Tag{}, err
+	}
+
+	return t, nil
+}
+
+// Rename changes the name of the tag, and so of every expense tagged with
+// it. To rename it to an existing tag merge them instead.
+func (s *Tag) Rename(ctx context.Context, id, workspaceID uuid.UUID, name string) (repository.Tag, error) {
+	name, err := tagName(name)
+	if err != nil {
+		return repository.Tag{}, err
+	}
+
+	t, err := s.Queries.UpdateTag(ctx, repository.UpdateTagParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+		Name:        name,
+		UpdatedAt:   time.Now(),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Tag{}, ErrTagNotFound
+	} else if isUniqueViolation(err) {
+		return repository.Tag{}, ErrTagExists
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Tag{}, err
+	}
+
+	return t, nil
+}
+
+// Merge moves the expenses of the source tag to the target one and deletes
+// the source tag, returning the target.
+func (s *Tag) Merge(ctx context.Context, sourceID, targetID, workspaceID uuid.UUID) (repository.Tag, error) {
+	if sourceID == targetID {
+		return repository.Tag{}, ErrMergeSameTag
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.Tag{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	target, err := qtx.GetTagByID(ctx, repository.GetTagByIDParams{
+		ID:          targetID,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Tag{}, ErrTagNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.Tag{}, err
+	}
+
+	err = qtx.MoveExpenseTags(ctx, repository.MoveExpenseTagsParams{
+		SourceID: sourceID,
+		TargetID: target.ID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.Tag{}, err
+	}
+
+	// Also removes the links of the source, which were copied to the target
+	_, err = qtx.DeleteTag(ctx, repository.DeleteTagParams{
+		ID:          sourceID,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Tag{}, ErrTagNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Tag{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.Tag{}, err
+	}
+
+	return target, nil
+}
+
+func (s *Tag) DeleteByID(ctx context.Context, id, workspaceID uuid.UUID) (repository.Tag, error) {
+	t, err := s.Queries.DeleteTag(ctx, repository.DeleteTagParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Tag{}, ErrTagNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Tag{}, err
+	}
+
+	return t, nil
+}
+
+// setExpenseTags replaces the tags of the expense, creating the ones the
+// workspace does not have yet, and returns their names sorted. queries
+// should be bound to a transaction.
+func setExpenseTags(
+	ctx context.Context,
+	queries *repository.Queries,
+	workspaceID, expenseID uuid.UUID,
+	names []string,
+) ([]string, error) {
+	unique := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name, err := tagName(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+
+	if err := queries.DeleteExpenseTags(ctx, expenseID); err != nil {
+		fmt.Println("failed to delete:", err)
+		return nil, err
+	}
+
+	if len(unique) == 0 {
+		return []string{}, nil
+	}
+
+	existing, err := queries.GetUserTagsByName(ctx, repository.GetUserTagsByNameParams{
+		WorkspaceID: workspaceID,
+		Names:       unique,
+	})
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return nil, err
+	}
+
+	ids := make(map[string]uuid.UUID, len(existing))
+	for _, t := range existing {
+		ids[t.Name] = t.ID
+	}
+
+	now := time.Now()
+	for _, name := range unique {
+		id, ok := ids[name]
+		if !ok {
+			t, err := queries.CreateTag(ctx, repository.CreateTagParams{
+				ID:          uuid.New(),
+				CreatedAt:   now,
+				UpdatedAt:   now,
+				Name:        name,
+				WorkspaceID: workspaceID,
+			})
+			if err != nil {
+				fmt.Println("failed to insert:", err)
+				return nil, err
+			}
+
+			id = t.ID
+		}
+
+		err := queries.AddExpenseTag(ctx, repository.AddExpenseTagParams{
+			ExpenseID: expenseID,
+			TagID:     id,
+		})
+		if err != nil {
+			fmt.Println("failed to insert:", err)
+			return nil, err
+		}
+	}
+
+	sort.Strings(unique)
+
+	return unique, nil
+}
+
+func tagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > maxTagLength {
+		return "", ErrInvalidTag
+	}
+
+	return name, nil
+}
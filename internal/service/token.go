@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -9,6 +13,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -22,76 +27,374 @@ type Token struct {
 	JWTRefreshExp    time.Duration
 }
 
+// Login is the result of signing in with a password: the tokens of the new
+// session or, when the user has two-factor authentication, a challenge to
+// exchange for them with a code at CompleteMFA before MFAExpiresAt.
+type Login struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+	MFAExpiresAt time.Time
+}
+
 func (s *Token) Create(
 	ctx context.Context,
-	email, password string,
-) (accessToken, refreshToken string, err error) {
+	email, password, device string,
+) (Login, error) {
 	u, err := s.Queries.GetUserByEmail(ctx, email)
 	if errors.Is(err, pgx.ErrNoRows) {
-		return "", "", ErrUserNotFound
+		return Login{}, ErrUserNotFound
 	} else if err != nil {
-		return "", "", err
+		return Login{}, err
 	}
 
 	if !comparePassword(u.Password, password) {
-		return "", "", ErrWrongCredentials
+		return Login{}, ErrWrongCredentials
 	}
 
-	accessToken, err = createJWT(u, s.JWTAccessSecret, s.JWTAccessExp)
+	if u.TotpEnabledAt.Valid {
+		return s.createMFAChallenge(ctx, u, device)
+	}
+
+	accessToken, refreshToken, err := s.issue(ctx, u, device)
+	if err != nil {
+		return Login{}, err
+	}
+
+	return Login{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// CompleteMFA finishes a login waiting for the second factor with a code of
+// the authenticator or a recovery code, returning the tokens of the session.
+func (s *Token) CompleteMFA(
+	ctx context.Context,
+	mfaToken, code string,
+) (accessToken, refreshToken string, err error) {
+	now := time.Now()
+
+	// Counted before checking the code, so failed tries add up
+	challenge, err := s.Queries.AttemptMFAChallenge(ctx, repository.AttemptMFAChallengeParams{
+		TokenHash:   hashToken(mfaToken),
+		Now:         now,
+		MaxAttempts: maxMFAAttempts,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", ErrInvalidToken
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return "", "", err
+	}
+
+	u, err := s.Queries.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		fmt.Println("failed to query:", err)
+		return "", "", err
+	}
+
+	// Two-factor authentication was turned off in the meantime
+	if !u.TotpEnabledAt.Valid {
+		return "", "", ErrInvalidToken
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	if err := verifySecondFactor(ctx, qtx, u, code, now); err != nil {
+		return "", "", err
+	}
+
+	// Another request completed the same login in the meantime
+	rows, err := qtx.DeleteMFAChallenge(ctx, challenge.ID)
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return "", "", err
+	} else if rows == 0 {
+		return "", "", ErrInvalidToken
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", "", err
+	}
+
+	return s.issue(ctx, u, challenge.Device)
+}
+
+// CreateFor starts a new session of a user who is already signed in, like
+// after changing the password.
+func (s *Token) CreateFor(
+	ctx context.Context,
+	userID uuid.UUID,
+	device string,
+) (accessToken, refreshToken string, err error) {
+	u, err := s.Queries.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", ErrUserNotFound
+	} else if err != nil {
+		return "", "", err
+	}
+
+	return s.issue(ctx, u, device)
+}
+
+// issue creates the access and refresh tokens of a new session.
+func (s *Token) issue(
+	ctx context.Context,
+	u repository.User,
+	device string,
+) (accessToken, refreshToken string, err error) {
+	accessToken, err = createJWT(u, s.JWTAccessSecret, s.JWTAccessExp, "")
 	if err != nil {
 		fmt.Println("failed to create access token:", err)
 		return "", "", err
 	}
 
-	refreshToken, err = createJWT(u, s.JWTRefreshSecret, s.JWTRefreshExp)
+	// Every login starts a new token family
+	refreshToken, _, err = s.issueRefreshToken(ctx, s.Queries, u, uuid.New(), device)
 	if err != nil {
-		fmt.Println("failed to create refresh token:", err)
 		return "", "", err
 	}
 
 	return accessToken, refreshToken, nil
 }
 
-func (s *Token) Refresh(ctx context.Context, refreshToken string) (string, error) {
-	token, err := validateJWT(refreshToken, s.JWTRefreshSecret)
+// createMFAChallenge stores a login waiting for the second factor. The token
+// is random and only its hash is stored, so unlike a JWT it can not be used
+// as an access token.
+func (s *Token) createMFAChallenge(ctx context.Context, u repository.User, device string) (Login, error) {
+	token, err := randomToken()
 	if err != nil {
-		return "", ErrInvalidToken
+		return Login{}, err
 	}
 
-	if !token.Valid {
-		return "", ErrInvalidToken
+	now := time.Now()
+	challenge, err := s.Queries.CreateMFAChallenge(ctx, repository.CreateMFAChallengeParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		TokenHash: hashToken(token),
+		Device:    device,
+		ExpiresAt: now.Add(mfaChallengeTTL),
+		UserID:    u.ID,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return Login{}, err
 	}
 
-	userIDString, err := token.Claims.GetSubject()
+	return Login{MFAToken: token, MFAExpiresAt: challenge.ExpiresAt}, nil
+}
+
+// Refresh rotates refreshToken, returning a new access token and the refresh
+// token that replaces it. Using a refresh token that was already rotated
+// revokes every token of its family, since either the user or an attacker is
+// holding a stolen copy.
+func (s *Token) Refresh(
+	ctx context.Context,
+	refreshToken string,
+) (accessToken, newRefreshToken string, err error) {
+	stored, err := s.getRefreshToken(ctx, refreshToken)
 	if err != nil {
-		return "", ErrInvalidToken
+		return "", "", err
 	}
 
-	userID, err := uuid.Parse(userIDString)
-	if err != nil {
-		fmt.Printf("Error parsing UUID: %v", err)
-		return "", ErrInvalidToken
+	now := time.Now()
+
+	if stored.RevokedAt.Valid {
+		if stored.ReplacedBy.Valid {
+			s.revokeFamily(ctx, stored.FamilyID, now)
+			return "", "", ErrTokenReused
+		}
+
+		return "", "", ErrInvalidToken
 	}
 
-	u, err := s.Queries.GetUserByID(ctx, userID)
+	if !stored.ExpiresAt.After(now) {
+		return "", "", ErrInvalidToken
+	}
+
+	u, err := s.Queries.GetUserByID(ctx, stored.UserID)
 	if err != nil {
 		fmt.Print("failed to query:", err)
-		return "", err
+		return "", "", err
 	}
 
-	accessToken, err := createJWT(u, s.JWTAccessSecret, s.JWTAccessExp)
+	accessToken, err = createJWT(u, s.JWTAccessSecret, s.JWTAccessExp, "")
 	if err != nil {
 		fmt.Println("failed to create access token:", err)
+		return "", "", err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		fmt.Println("failed to start transaction:", err)
+		return "", "", err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	newRefreshToken, replacement, err := s.issueRefreshToken(ctx, qtx, u, stored.FamilyID, stored.Device)
+	if err != nil {
+		return "", "", err
+	}
+
+	rows, err := qtx.RotateRefreshToken(ctx, repository.RotateRefreshTokenParams{
+		UsedAt:     pgtype.Timestamptz{Time: now, Valid: true},
+		ReplacedBy: pgtype.UUID{Bytes: replacement.ID, Valid: true},
+		ID:         stored.ID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return "", "", err
+	}
+
+	// Another request rotated the same token in the meantime
+	if rows == 0 {
+		tx.Rollback(ctx)
+		s.revokeFamily(ctx, stored.FamilyID, now)
+		return "", "", ErrTokenReused
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		fmt.Println("failed to commit transaction:", err)
+		return "", "", err
+	}
+
+	return accessToken, newRefreshToken, nil
+}
+
+// Revoke signs out the session refreshToken belongs to.
+func (s *Token) Revoke(ctx context.Context, refreshToken string) error {
+	stored, err := s.getRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.RevokeRefreshTokenFamily(ctx, repository.RevokeRefreshTokenFamilyParams{
+		RevokedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		FamilyID:  stored.FamilyID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAll signs out every session of the user. Access tokens that were
+// already issued stay valid until they expire.
+func (s *Token) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	err := s.Queries.RevokeUserRefreshTokens(ctx, repository.RevokeUserRefreshTokensParams{
+		RevokedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		UserID:    userID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	return nil
+}
+
+// getRefreshToken validates the refresh JWT and finds the token it was
+// issued as.
+func (s *Token) getRefreshToken(
+	ctx context.Context,
+	refreshToken string,
+) (repository.RefreshToken, error) {
+	token, err := validateJWT(refreshToken, s.JWTRefreshSecret)
+	if err != nil {
+		return repository.RefreshToken{}, ErrInvalidToken
+	}
+
+	if !token.Valid {
+		return repository.RefreshToken{}, ErrInvalidToken
+	}
+
+	stored, err := s.Queries.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.RefreshToken{}, ErrInvalidToken
+	} else if err != nil {
+		fmt.Println("failed to query:", err)
+		return repository.RefreshToken{}, err
+	}
+
+	return stored, nil
+}
+
+// issueRefreshToken creates a refresh JWT and stores its hash as part of the
+// token family.
+func (s *Token) issueRefreshToken(
+	ctx context.Context,
+	queries *repository.Queries,
+	user repository.User,
+	familyID uuid.UUID,
+	device string,
+) (string, repository.RefreshToken, error) {
+	id := uuid.New()
+
+	refreshToken, err := createJWT(user, s.JWTRefreshSecret, s.JWTRefreshExp, id.String())
+	if err != nil {
+		fmt.Println("failed to create refresh token:", err)
+		return "", repository.RefreshToken{}, err
+	}
+
+	now := time.Now()
+	stored, err := queries.CreateRefreshToken(ctx, repository.CreateRefreshTokenParams{
+		ID:        id,
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		Device:    device,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.JWTRefreshExp),
+	})
+	if err != nil {
+		fmt.Println("failed to create refresh token:", err)
+		return "", repository.RefreshToken{}, err
+	}
+
+	return refreshToken, stored, nil
+}
+
+func (s *Token) revokeFamily(ctx context.Context, familyID uuid.UUID, now time.Time) {
+	err := s.Queries.RevokeRefreshTokenFamily(ctx, repository.RevokeRefreshTokenFamilyParams{
+		RevokedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		FamilyID:  familyID,
+	})
+	if err != nil {
+		fmt.Println("failed to revoke token family:", err)
+	}
+}
+
+// randomToken returns a URL safe token for the links sent by email and the
+// logins waiting for the second factor, only its hash is stored.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	return accessToken, nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex encoded SHA-256 of a token. Refresh tokens are
+// random enough that a slow hash is not needed.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func createJWT(
 	user repository.User,
 	jwtSecret string,
 	jwtExpiration time.Duration,
+	id string,
 ) (string, error) {
 	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
@@ -100,6 +403,7 @@ func createJWT(
 		NotBefore: jwt.NewNumericDate(now),
 		Issuer:    "expense-tracker",
 		Subject:   user.ID.String(),
+		ID:        id,
 	})
 
 	return token.SignedString([]byte(jwtSecret))
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// The shared secret of the test vectors of RFC 4226 and RFC 6238
+var rfcTOTPKey = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 Appendix B, SHA-1. The RFC codes have 8 digits, the last 6
+	// are the ones of a 6 digit code
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		step := totpStep(time.Unix(tt.unix, 0))
+		if got, want := totpCode(rfcTOTPKey, step), tt.want[2:]; got != want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestHOTPCode(t *testing.T) {
+	// RFC 4226 Appendix D, counters 0 to 9
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range want {
+		if got := totpCode(rfcTOTPKey, int64(counter)); got != code {
+			t.Errorf("totpCode at counter %d = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfcTOTPKey)
+	now := time.Unix(1111111111, 0)
+	step := totpStep(now)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		want   bool
+		step   int64
+	}{
+		{name: "current", secret: secret, code: totpCode(rfcTOTPKey, step), want: true, step: step},
+		{name: "previous", secret: secret, code: totpCode(rfcTOTPKey, step-1), want: true, step: step - 1},
+		{name: "next", secret: secret, code: totpCode(rfcTOTPKey, step+1), want: true, step: step + 1},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "050471", want: true, step: step},
+		{name: "too old", secret: secret, code: totpCode(rfcTOTPKey, step-2)},
+		{name: "too new", secret: secret, code: totpCode(rfcTOTPKey, step+2)},
+		{name: "8 digits", secret: secret, code: "14050471"},
+		{name: "invalid secret", secret: "not base32!", code: "050471"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := validateTOTP(tt.secret, tt.code, now)
+			if ok != tt.want || step != tt.step {
+				t.Errorf("validateTOTP(%q) = %d, %v, want %d, %v", tt.code, step, ok, tt.step, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"github.com/shopspring/decimal"
+)
+
+// Transfer moves money between two accounts of the workspace. Transfers are not
+// expenses, so they never count towards the budgets or reports
+type Transfer struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+}
+
+func (s *Transfer) GetByID(ctx context.Context, id, workspaceID uuid.UUID) (repository.Transfer, error) {
+	t, err := s.Queries.GetTransferByID(ctx, repository.GetTransferByIDParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Transfer{}, ErrTransferNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.Transfer{}, err
+	}
+
+	return t, nil
+}
+
+// GetAll returns a page of the transfers of the workspace, the latest first,
+// only from or to the account unless it is uuid.Nil.
+func (s *Transfer) GetAll(
+	ctx context.Context,
+	workspaceID, accountID uuid.UUID,
+	limit int32,
+	cur string,
+) ([]repository.Transfer, error) {
+	params := repository.GetUserTransfersParams{
+		WorkspaceID: workspaceID,
+		PageSize:    limit,
+	}
+
+	if accountID != uuid.Nil {
+		params.AccountID = pgtype.UUID{Bytes: accountID, Valid: true}
+	}
+
+	if cur != "" {
+		t, id, err := internal.DecodeCursor(cur)
+		if err != nil {
+			return []repository.Transfer{}, ErrDecodeCursor
+		}
+
+		params.CursorTransferredAt = pgtype.Timestamptz{Time: t, Valid: true}
+		params.CursorID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	transfers, err := s.Queries.GetUserTransfers(ctx, params)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.Transfer{}, err
+	}
+
+	if transfers == nil {
+		transfers = []repository.Transfer{}
+	}
+
+	return transfers, nil
+}
+
+// Create moves amount, in the currency of the first account, to the second
+// one. When the accounts have different currencies and toAmount is not
+// given, it is converted with the rate of the day of the transfer.
+func (s *Transfer) Create(
+	ctx context.Context,
+	workspaceID, fromID, toID uuid.UUID,
+	amount decimal.Decimal,
+	toAmount decimal.NullDecimal,
+	description string,
+	transferredAt time.Time,
+) (repository.Transfer, error) {
+	if fromID == toID || !amount.IsPositive() || (toAmount.Valid && !toAmount.Decimal.IsPositive()) {
+		return repository.Transfer{}, ErrInvalidTransfer
+	}
+
+	now := time.Now()
+	if transferredAt.IsZero() {
+		transferredAt = now
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.Transfer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	from, err := qtx.GetAccountByID(ctx, repository.GetAccountByIDParams{ID: fromID, WorkspaceID: workspaceID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Transfer{}, ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.Transfer{}, err
+	}
+
+	to, err := qtx.GetAccountByID(ctx, repository.GetAccountByIDParams{ID: toID, WorkspaceID: workspaceID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Transfer{}, ErrAccountNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.Transfer{}, err
+	}
+
+	if !toAmount.Valid {
+		toAmount.Decimal = amount
+
+		if from.Currency != to.Currency {
+			loc, err := workspaceLocation(ctx, qtx, workspaceID)
+			if err != nil {
+				return repository.Transfer{}, err
+			}
+
+			rate, err := exchangeRate(ctx, qtx, from.Currency, to.Currency, rateDay(transferredAt, loc))
+			if err != nil {
+				return repository.Transfer{}, err
+			}
+
+			toAmount.Decimal = amount.Mul(rate).Round(2)
+		}
+	}
+
+	t, err := qtx.CreateTransfer(ctx, repository.CreateTransferParams{
+		ID:            uuid.New(),
+		CreatedAt:     now,
+		FromAccountID: fromID,
+		ToAccountID:   toID,
+		Amount:        amount,
+		ToAmount:      toAmount.Decimal,
+		Description:   description,
+		TransferredAt: transferredAt,
+		WorkspaceID:   workspaceID,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return repository.Transfer{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.Transfer{}, err
+	}
+
+	return t, nil
+}
+
+func (s *Transfer) DeleteByID(ctx context.Context, id, workspaceID uuid.UUID) (repository.Transfer, error) {
+	t, err := s.Queries.DeleteTransfer(ctx, repository.DeleteTransferParams{
+		ID:          id,
+		WorkspaceID: workspaceID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Transfer{}, ErrTransferNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Transfer{}, err
+	}
+
+	return t, nil
+}
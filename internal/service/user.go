@@ -3,17 +3,27 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/mail"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/mailer"
 	"github.com/jamcunha/expense-tracker/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const maxUserNameLength = 255
+
 type User struct {
 	DB      *pgx.Conn
 	Queries *repository.Queries
+
+	// Sends the tokens to verify the email and reset the password
+	Mailer mailer.Mailer
 }
 
 func (s *User) GetByID(ctx context.Context, id uuid.UUID) (repository.User, error) {
@@ -27,7 +37,22 @@ func (s *User) GetByID(ctx context.Context, id uuid.UUID) (repository.User, erro
 	return u, nil
 }
 
-func (s *User) Create(ctx context.Context, name, email, password string) (repository.User, error) {
+// Create registers the user, whose budgets and reports are in currency. The
+// pivot currency of the exchange rates is used when it is empty. A token to
+// verify the email is sent to it.
+func (s *User) Create(ctx context.Context, name, email, password, currency string) (repository.User, error) {
+	if !validEmail(email) {
+		return repository.User{}, ErrInvalidEmail
+	}
+
+	if !validPassword(password) {
+		return repository.User{}, ErrInvalidPassword
+	}
+
+	if currency == "" {
+		currency = pivotCurrency
+	}
+
 	encryptedPassword, err := bcrypt.GenerateFromPassword(
 		[]byte(password),
 		bcrypt.DefaultCost,
@@ -36,24 +61,193 @@ func (s *User) Create(ctx context.Context, name, email, password string) (reposi
 		return repository.User{}, err
 	}
 
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
 	now := time.Now()
-	u, err := s.Queries.CreateUser(ctx, repository.CreateUserParams{
+	u, err := qtx.CreateUser(ctx, repository.CreateUserParams{
 		ID:        uuid.New(),
 		CreatedAt: now,
 		UpdatedAt: now,
 		Name:      name,
 		Email:     email,
 		Password:  string(encryptedPassword),
+		Currency:  currency,
 	})
+	if isUniqueViolation(err) {
+		return repository.User{}, ErrEmailExists
+	} else if err != nil {
+		return repository.User{}, err
+	}
+
+	// The personal workspace shares the ID of the user, so it is the one used
+	// when a request does not choose a workspace
+	_, err = createWorkspace(ctx, qtx, u.ID, u.ID, u.Name, u.Currency, true)
+	if err != nil {
+		return repository.User{}, err
+	}
+
+	token, err := createUserToken(ctx, qtx, u.ID, TokenEmailVerification)
 	if err != nil {
 		return repository.User{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return repository.User{}, err
+	}
+
+	s.sendToken(ctx, u, TokenEmailVerification, token)
+
+	return u, nil
+}
+
+// UpdateProfile changes the name and email of the user, empty values are
+// kept as they are. A new email must be verified again, so a token is sent
+// to it.
+func (s *User) UpdateProfile(ctx context.Context, id uuid.UUID, name, email string) (repository.User, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	u, err := qtx.GetUserByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.User{}, ErrUserNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return repository.User{}, err
+	}
+
+	if name == "" {
+		name = u.Name
+	}
+
+	if utf8.RuneCountInString(name) > maxUserNameLength {
+		return repository.User{}, ErrInvalidUserName
+	}
+
+	verifiedAt := u.EmailVerifiedAt
+	emailChanged := email != "" && email != u.Email
+	if emailChanged {
+		if !validEmail(email) {
+			return repository.User{}, ErrInvalidEmail
+		}
+
+		verifiedAt = pgtype.Timestamptz{}
+	} else {
+		email = u.Email
+	}
+
+	u, err = qtx.UpdateUserProfile(ctx, repository.UpdateUserProfileParams{
+		Name:            name,
+		Email:           email,
+		EmailVerifiedAt: verifiedAt,
+		UpdatedAt:       time.Now(),
+		ID:              id,
+	})
+	if isUniqueViolation(err) {
+		return repository.User{}, ErrEmailExists
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.User{}, err
+	}
+
+	var token string
+	if emailChanged {
+		token, err = createUserToken(ctx, qtx, u.ID, TokenEmailVerification)
+		if err != nil {
+			return repository.User{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.User{}, err
+	}
+
+	if emailChanged {
+		s.sendToken(ctx, u, TokenEmailVerification, token)
+	}
+
 	return u, nil
 }
 
+// ChangePassword sets a new password after checking the current one, and
+// signs out every session of the user since the old password may have been
+// leaked.
+func (s *User) ChangePassword(ctx context.Context, id uuid.UUID, currentPassword, newPassword string) error {
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !comparePassword(u.Password, currentPassword) {
+		return ErrWrongCredentials
+	}
+
+	if !validPassword(newPassword) {
+		return ErrInvalidPassword
+	}
+
+	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	now := time.Now()
+	err = qtx.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+		Password:  string(encryptedPassword),
+		UpdatedAt: now,
+		ID:        id,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	err = qtx.RevokeUserRefreshTokens(ctx, repository.RevokeUserRefreshTokensParams{
+		RevokedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		UserID:    id,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteByID deletes the user with the workspaces nobody else owns, leaving
+// the shared ones they are a member of.
 func (s *User) DeleteByID(ctx context.Context, id uuid.UUID) (repository.User, error) {
-	u, err := s.Queries.DeleteUser(ctx, id)
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	if err := qtx.DeleteUserWorkspaces(ctx, id); err != nil {
+		return repository.User{}, err
+	}
+
+	u, err := qtx.DeleteUser(ctx, id)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return repository.User{}, ErrUserNotFound
 	}
@@ -61,5 +255,14 @@ func (s *User) DeleteByID(ctx context.Context, id uuid.UUID) (repository.User, e
 		return repository.User{}, err
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return repository.User{}, err
+	}
+
 	return u, nil
 }
+
+func validEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/mailer"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Kinds of the tokens sent by email, matching the check of the user_tokens
+// table
+const (
+	TokenEmailVerification = "email_verification"
+	TokenPasswordReset     = "password_reset"
+)
+
+// How long the tokens sent by email can be used for
+var userTokenTTL = map[string]time.Duration{
+	TokenEmailVerification: 48 * time.Hour,
+	TokenPasswordReset:     time.Hour,
+}
+
+// bcrypt ignores anything after 72 bytes
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// VerifyEmail marks the email of the user of the token as verified.
+func (s *User) VerifyEmail(ctx context.Context, token string) (repository.User, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	now := time.Now()
+	t, err := useUserToken(ctx, qtx, token, TokenEmailVerification, now)
+	if err != nil {
+		return repository.User{}, err
+	}
+
+	u, err := qtx.SetUserEmailVerified(ctx, repository.SetUserEmailVerifiedParams{
+		EmailVerifiedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		UpdatedAt:       now,
+		ID:              t.UserID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.User{}, ErrUserNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.User{}, err
+	}
+
+	return u, nil
+}
+
+// ResendVerification sends a new token to verify the email of the user, the
+// ones sent before can no longer be used.
+func (s *User) ResendVerification(ctx context.Context, id uuid.UUID) error {
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if u.EmailVerifiedAt.Valid {
+		return ErrEmailVerified
+	}
+
+	token, err := createUserToken(ctx, s.Queries, u.ID, TokenEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	s.sendToken(ctx, u, TokenEmailVerification, token)
+
+	return nil
+}
+
+// ForgotPassword sends a token to reset the password to the email. Unknown
+// emails are ignored, so the response does not tell which ones have an
+// account.
+func (s *User) ForgotPassword(ctx context.Context, email string) error {
+	u, err := s.Queries.GetUserByEmail(ctx, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return err
+	}
+
+	token, err := createUserToken(ctx, s.Queries, u.ID, TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+
+	s.sendToken(ctx, u, TokenPasswordReset, token)
+
+	return nil
+}
+
+// ResetPassword changes the password of the user of the token and signs out
+// all their sessions. Receiving the token also proves the email is theirs,
+// so it is marked as verified.
+func (s *User) ResetPassword(ctx context.Context, token, password string) error {
+	if !validPassword(password) {
+		return ErrInvalidPassword
+	}
+
+	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	now := time.Now()
+	t, err := useUserToken(ctx, qtx, token, TokenPasswordReset, now)
+	if err != nil {
+		return err
+	}
+
+	err = qtx.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+		Password:  string(encryptedPassword),
+		UpdatedAt: now,
+		ID:        t.UserID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	u, err := qtx.GetUserByID(ctx, t.UserID)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return err
+	}
+
+	if !u.EmailVerifiedAt.Valid {
+		_, err = qtx.SetUserEmailVerified(ctx, repository.SetUserEmailVerifiedParams{
+			EmailVerifiedAt: pgtype.Timestamptz{Time: now, Valid: true},
+			UpdatedAt:       now,
+			ID:              u.ID,
+		})
+		if err != nil {
+			fmt.Println("failed to update:", err)
+			return err
+		}
+	}
+
+	err = qtx.RevokeUserRefreshTokens(ctx, repository.RevokeUserRefreshTokensParams{
+		RevokedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		UserID:    u.ID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteExpiredTokens removes the tokens sent by email that expired before
+// now, they can no longer be used.
+func (s *User) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	deleted, err := s.Queries.DeleteExpiredUserTokens(ctx, now)
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// createUserToken stores a new token of kind for the user, replacing the ones
+// of the same kind, and returns it. Only its hash is stored.
+func createUserToken(
+	ctx context.Context,
+	queries *repository.Queries,
+	userID uuid.UUID,
+	kind string,
+) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = queries.DeleteUserTokens(ctx, repository.DeleteUserTokensParams{
+		UserID: userID,
+		Kind:   kind,
+	})
+	if err != nil {
+		fmt.Println("failed to delete:", err)
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = queries.CreateUserToken(ctx, repository.CreateUserTokenParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		Kind:      kind,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(userTokenTTL[kind]),
+		UserID:    userID,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// useUserToken marks the token as used, returning ErrInvalidUserToken when it
+// does not exist, expired or was already used.
+func useUserToken(
+	ctx context.Context,
+	queries *repository.Queries,
+	token, kind string,
+	now time.Time,
+) (repository.UserToken, error) {
+	t, err := queries.UseUserToken(ctx, repository.UseUserTokenParams{
+		UsedAt:    pgtype.Timestamptz{Time: now, Valid: true},
+		TokenHash: hashToken(token),
+		Kind:      kind,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.UserToken{}, ErrInvalidUserToken
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.UserToken{}, err
+	}
+
+	return t, nil
+}
+
+// sendToken emails the token to the user. The token is already saved and
+// another one can be asked for, so failures are only logged.
+func (s *User) sendToken(ctx context.Context, u repository.User, kind, token string) {
+	m := mailer.Message{To: u.Email}
+
+	expires := time.Now().Add(userTokenTTL[kind]).UTC().Format(time.RFC3339)
+	switch kind {
+	case TokenEmailVerification:
+		m.Subject = "Verify your email"
+		m.Body = fmt.Sprintf(
+			"Hi %s,\n\nVerify your email with the token %s before %s.",
+			u.Name, token, expires,
+		)
+	case TokenPasswordReset:
+		m.Subject = "Reset your password"
+		m.Body = fmt.Sprintf(
+			"Hi %s,\n\nReset your password with the token %s before %s.\nIf you did not ask for it, ignore this email.",
+			u.Name, token, expires,
+		)
+	}
+
+	if err := s.Mailer.Send(ctx, m); err != nil {
+		fmt.Printf("failed to send %s token to user %s: %v\n", kind, u.ID, err)
+	}
+}
+
+func validPassword(password string) bool {
+	return utf8.RuneCountInString(password) >= minPasswordLength && len(password) <= maxPasswordLength
+}
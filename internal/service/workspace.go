@@ -0,0 +1,595 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jamcunha/expense-tracker/internal/mailer"
+	"github.com/jamcunha/expense-tracker/internal/repository"
+)
+
+// Roles of the members of a workspace. Owners manage the workspace and its
+// members, editors change the ledger and viewers only read it
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+const maxWorkspaceNameLength = 255
+
+// How long an invitation can be accepted for
+const invitationTTL = 7 * 24 * time.Hour
+
+// Workspace owns a ledger shared by its members. Every user has a personal
+// workspace, with the ID of the user, that can not be shared.
+type Workspace struct {
+	DB      *pgx.Conn
+	Queries *repository.Queries
+
+	// Sends the invitations by email
+	Mailer mailer.Mailer
+}
+
+// WorkspaceWithRole is a workspace with the role of the user in it
+type WorkspaceWithRole struct {
+	repository.Workspace
+	Role string `json:"role"`
+}
+
+// Invitation is a new invitation with its token, which is only known when it
+// is created
+type Invitation struct {
+	repository.WorkspaceInvitation
+	Token string `json:"token"`
+}
+
+// Role returns the role of the user in the workspace, ErrWorkspaceNotFound
+// when they are not a member.
+func (s *Workspace) Role(ctx context.Context, id, userID uuid.UUID) (string, error) {
+	role, err := s.Queries.GetWorkspaceRole(ctx, repository.GetWorkspaceRoleParams{
+		WorkspaceID: id,
+		UserID:      userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrWorkspaceNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return "", err
+	}
+
+	return role, nil
+}
+
+// GetAll returns the workspaces the user is a member of, the personal one
+// first.
+func (s *Workspace) GetAll(ctx context.Context, userID uuid.UUID) ([]WorkspaceWithRole, error) {
+	rows, err := s.Queries.GetUserWorkspaces(ctx, userID)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []WorkspaceWithRole{}, err
+	}
+
+	workspaces := make([]WorkspaceWithRole, len(rows))
+	for i, row := range rows {
+		workspaces[i] = WorkspaceWithRole{Workspace: row.Workspace, Role: row.Role}
+	}
+
+	return workspaces, nil
+}
+
+func (s *Workspace) GetByID(ctx context.Context, id, userID uuid.UUID) (WorkspaceWithRole, error) {
+	role, err := s.Role(ctx, id, userID)
+	if err != nil {
+		return WorkspaceWithRole{}, err
+	}
+
+	w, err := s.Queries.GetWorkspaceByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WorkspaceWithRole{}, ErrWorkspaceNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return WorkspaceWithRole{}, err
+	}
+
+	return WorkspaceWithRole{Workspace: w, Role: role}, nil
+}
+
+// Create adds a shared workspace owned by the user, with its budgets and
+// reports in currency, the base currency of the user when empty.
+func (s *Workspace) Create(
+	ctx context.Context,
+	userID uuid.UUID,
+	name, currency string,
+) (WorkspaceWithRole, error) {
+	if !validWorkspaceName(name) {
+		return WorkspaceWithRole{}, ErrInvalidWorkspace
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return WorkspaceWithRole{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	if currency == "" {
+		u, err := qtx.GetUserByID(ctx, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WorkspaceWithRole{}, ErrUserNotFound
+		} else if err != nil {
+			fmt.Println("failed to find:", err)
+			return WorkspaceWithRole{}, err
+		}
+
+		currency = u.Currency
+	}
+
+	w, err := createWorkspace(ctx, qtx, uuid.New(), userID, name, currency, false)
+	if err != nil {
+		return WorkspaceWithRole{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return WorkspaceWithRole{}, err
+	}
+
+	return WorkspaceWithRole{Workspace: w, Role: RoleOwner}, nil
+}
+
+// createWorkspace inserts the workspace with the user as its owner. queries
+// should be bound to a transaction.
+func createWorkspace(
+	ctx context.Context,
+	queries *repository.Queries,
+	id, userID uuid.UUID,
+	name, currency string,
+	personal bool,
+) (repository.Workspace, error) {
+	now := time.Now()
+	w, err := queries.CreateWorkspace(ctx, repository.CreateWorkspaceParams{
+		ID:        id,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Name:      name,
+		Currency:  currency,
+		Personal:  personal,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return repository.Workspace{}, err
+	}
+
+	err = queries.AddWorkspaceMember(ctx, repository.AddWorkspaceMemberParams{
+		WorkspaceID: w.ID,
+		UserID:      userID,
+		Role:        RoleOwner,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return repository.Workspace{}, err
+	}
+
+	return w, nil
+}
+
+// Update renames the workspace, only its owners can.
+func (s *Workspace) Update(ctx context.Context, id, userID uuid.UUID, name string) (WorkspaceWithRole, error) {
+	if !validWorkspaceName(name) {
+		return WorkspaceWithRole{}, ErrInvalidWorkspace
+	}
+
+	if err := s.checkOwner(ctx, id, userID); err != nil {
+		return WorkspaceWithRole{}, err
+	}
+
+	w, err := s.Queries.UpdateWorkspace(ctx, repository.UpdateWorkspaceParams{
+		Name:      name,
+		UpdatedAt: time.Now(),
+		ID:        id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WorkspaceWithRole{}, ErrWorkspaceNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return WorkspaceWithRole{}, err
+	}
+
+	return WorkspaceWithRole{Workspace: w, Role: RoleOwner}, nil
+}
+
+// DeleteByID deletes a shared workspace with its whole ledger, only its
+// owners can. Personal workspaces are deleted with their user.
+func (s *Workspace) DeleteByID(ctx context.Context, id, userID uuid.UUID) (repository.Workspace, error) {
+	if err := s.checkOwner(ctx, id, userID); err != nil {
+		return repository.Workspace{}, err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.Workspace{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	w, err := qtx.DeleteWorkspace(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Workspace{}, ErrWorkspaceNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.Workspace{}, err
+	}
+
+	if w.Personal {
+		return repository.Workspace{}, ErrPersonalWorkspace
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.Workspace{}, err
+	}
+
+	return w, nil
+}
+
+// Members returns the members of the workspace with their names and emails,
+// any member can see them.
+func (s *Workspace) Members(
+	ctx context.Context,
+	id, userID uuid.UUID,
+) ([]repository.GetWorkspaceMembersRow, error) {
+	if _, err := s.Role(ctx, id, userID); err != nil {
+		return []repository.GetWorkspaceMembersRow{}, err
+	}
+
+	members, err := s.Queries.GetWorkspaceMembers(ctx, id)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.GetWorkspaceMembersRow{}, err
+	}
+
+	return members, nil
+}
+
+// UpdateMember changes the role of a member, only the owners can. The
+// workspace always keeps an owner.
+func (s *Workspace) UpdateMember(
+	ctx context.Context,
+	id, userID, memberID uuid.UUID,
+	role string,
+) (repository.WorkspaceMember, error) {
+	if !validRole(role) {
+		return repository.WorkspaceMember{}, ErrInvalidRole
+	}
+
+	if err := s.checkOwner(ctx, id, userID); err != nil {
+		return repository.WorkspaceMember{}, err
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.WorkspaceMember{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	m, err := qtx.UpdateWorkspaceMember(ctx, repository.UpdateWorkspaceMemberParams{
+		Role:        role,
+		WorkspaceID: id,
+		UserID:      memberID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.WorkspaceMember{}, ErrWorkspaceMemberNotFound
+	} else if err != nil {
+		fmt.Println("failed to update:", err)
+		return repository.WorkspaceMember{}, err
+	}
+
+	if err := checkWorkspaceOwners(ctx, qtx, id); err != nil {
+		return repository.WorkspaceMember{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.WorkspaceMember{}, err
+	}
+
+	return m, nil
+}
+
+// RemoveMember removes a member from the workspace. The owners can remove
+// anyone and every member can leave, as long as the workspace keeps an owner.
+func (s *Workspace) RemoveMember(
+	ctx context.Context,
+	id, userID, memberID uuid.UUID,
+) (repository.WorkspaceMember, error) {
+	if memberID != userID {
+		if err := s.checkOwner(ctx, id, userID); err != nil {
+			return repository.WorkspaceMember{}, err
+		}
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return repository.WorkspaceMember{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	m, err := qtx.DeleteWorkspaceMember(ctx, repository.DeleteWorkspaceMemberParams{
+		WorkspaceID: id,
+		UserID:      memberID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.WorkspaceMember{}, ErrWorkspaceMemberNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.WorkspaceMember{}, err
+	}
+
+	if err := checkWorkspaceOwners(ctx, qtx, id); err != nil {
+		return repository.WorkspaceMember{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return repository.WorkspaceMember{}, err
+	}
+
+	return m, nil
+}
+
+// Invite creates an invitation to join the workspace with role, sent to
+// email when there is a mailer. Only the owners of a shared workspace can
+// invite.
+func (s *Workspace) Invite(
+	ctx context.Context,
+	id, userID uuid.UUID,
+	email, role string,
+) (Invitation, error) {
+	if !validEmail(email) {
+		return Invitation{}, ErrInvalidInvitationEmail
+	}
+
+	if !validRole(role) {
+		return Invitation{}, ErrInvalidRole
+	}
+
+	if err := s.checkOwner(ctx, id, userID); err != nil {
+		return Invitation{}, err
+	}
+
+	w, err := s.Queries.GetWorkspaceByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Invitation{}, ErrWorkspaceNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return Invitation{}, err
+	}
+
+	if w.Personal {
+		return Invitation{}, ErrPersonalWorkspace
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	now := time.Now()
+	i, err := s.Queries.CreateWorkspaceInvitation(ctx, repository.CreateWorkspaceInvitationParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		Email:       email,
+		Role:        role,
+		TokenHash:   hashToken(token),
+		ExpiresAt:   now.Add(invitationTTL),
+		WorkspaceID: id,
+		InvitedBy:   pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return Invitation{}, err
+	}
+
+	s.sendInvitation(ctx, w, i, token)
+
+	return Invitation{WorkspaceInvitation: i, Token: token}, nil
+}
+
+// sendInvitation emails the token to the invited user. The invitation is
+// already saved, so failures are only logged.
+func (s *Workspace) sendInvitation(
+	ctx context.Context,
+	w repository.Workspace,
+	i repository.WorkspaceInvitation,
+	token string,
+) {
+	err := s.Mailer.Send(ctx, mailer.Message{
+		To:      i.Email,
+		Subject: fmt.Sprintf("You were invited to %s", w.Name),
+		Body: fmt.Sprintf(
+			"You were invited to join the %s workspace as %s. Accept the invitation with the token %s before %s.",
+			w.Name, i.Role, token, i.ExpiresAt.UTC().Format(time.RFC3339),
+		),
+	})
+	if err != nil {
+		fmt.Printf("failed to send invitation %s: %v\n", i.ID, err)
+	}
+}
+
+// Invitations returns the invitations of the workspace not accepted yet, only
+// the owners can see them.
+func (s *Workspace) Invitations(
+	ctx context.Context,
+	id, userID uuid.UUID,
+) ([]repository.WorkspaceInvitation, error) {
+	if err := s.checkOwner(ctx, id, userID); err != nil {
+		return []repository.WorkspaceInvitation{}, err
+	}
+
+	invitations, err := s.Queries.GetWorkspaceInvitations(ctx, id)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return []repository.WorkspaceInvitation{}, err
+	}
+
+	if invitations == nil {
+		invitations = []repository.WorkspaceInvitation{}
+	}
+
+	return invitations, nil
+}
+
+// DeleteInvitation revokes an invitation, only the owners can.
+func (s *Workspace) DeleteInvitation(
+	ctx context.Context,
+	id, userID, invitationID uuid.UUID,
+) (repository.WorkspaceInvitation, error) {
+	if err := s.checkOwner(ctx, id, userID); err != nil {
+		return repository.WorkspaceInvitation{}, err
+	}
+
+	i, err := s.Queries.DeleteWorkspaceInvitation(ctx, repository.DeleteWorkspaceInvitationParams{
+		ID:          invitationID,
+		WorkspaceID: id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.WorkspaceInvitation{}, ErrInvitationNotFound
+	} else if err != nil {
+		fmt.Println("failed to delete:", err)
+		return repository.WorkspaceInvitation{}, err
+	}
+
+	return i, nil
+}
+
+// AcceptInvitation makes the user a member of the workspace of the
+// invitation with its token. The invitation must have been sent to the email
+// of the user, who must have verified it, and members accepting another one
+// keep their role.
+func (s *Workspace) AcceptInvitation(
+	ctx context.Context,
+	userID uuid.UUID,
+	token string,
+) (WorkspaceWithRole, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return WorkspaceWithRole{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.Queries.WithTx(tx)
+
+	i, err := qtx.GetWorkspaceInvitationByHash(ctx, hashToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WorkspaceWithRole{}, ErrInvalidInvitation
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return WorkspaceWithRole{}, err
+	}
+
+	now := time.Now()
+	if i.AcceptedAt.Valid || now.After(i.ExpiresAt) {
+		return WorkspaceWithRole{}, ErrInvalidInvitation
+	}
+
+	u, err := qtx.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return WorkspaceWithRole{}, ErrUserNotFound
+	} else if err != nil {
+		fmt.Println("failed to find:", err)
+		return WorkspaceWithRole{}, err
+	}
+
+	if !strings.EqualFold(u.Email, i.Email) {
+		return WorkspaceWithRole{}, ErrInvitationEmail
+	}
+
+	if !u.EmailVerifiedAt.Valid {
+		return WorkspaceWithRole{}, ErrEmailNotVerified
+	}
+
+	accepted, err := qtx.AcceptWorkspaceInvitation(ctx, repository.AcceptWorkspaceInvitationParams{
+		AcceptedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		ID:         i.ID,
+	})
+	if err != nil {
+		fmt.Println("failed to update:", err)
+		return WorkspaceWithRole{}, err
+	}
+
+	if accepted == 0 {
+		return WorkspaceWithRole{}, ErrInvalidInvitation
+	}
+
+	err = qtx.AddWorkspaceMember(ctx, repository.AddWorkspaceMemberParams{
+		WorkspaceID: i.WorkspaceID,
+		UserID:      userID,
+		Role:        i.Role,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		fmt.Println("failed to insert:", err)
+		return WorkspaceWithRole{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return WorkspaceWithRole{}, err
+	}
+
+	return s.GetByID(ctx, i.WorkspaceID, userID)
+}
+
+// checkOwner returns ErrWorkspaceForbidden unless the user is an owner of
+// the workspace.
+func (s *Workspace) checkOwner(ctx context.Context, id, userID uuid.UUID) error {
+	role, err := s.Role(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if role != RoleOwner {
+		return ErrWorkspaceForbidden
+	}
+
+	return nil
+}
+
+// checkWorkspaceOwners returns ErrLastOwner when the workspace was left
+// without owners, so the changes can be rolled back.
+func checkWorkspaceOwners(ctx context.Context, queries *repository.Queries, id uuid.UUID) error {
+	owners, err := queries.CountWorkspaceOwners(ctx, id)
+	if err != nil {
+		fmt.Println("failed to find:", err)
+		return err
+	}
+
+	if owners == 0 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+func validWorkspaceName(name string) bool {
+	return name != "" && utf8.RuneCountInString(name) <= maxWorkspaceNameLength
+}
+
+func validRole(role string) bool {
+	switch role {
+	case RoleOwner, RoleEditor, RoleViewer:
+		return true
+	}
+
+	return false
+}